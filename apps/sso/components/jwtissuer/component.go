@@ -13,6 +13,7 @@ import (
 	"github.com/justinsb/kweb/apps/sso/pkg/oidc"
 	"github.com/justinsb/kweb/components"
 	"github.com/justinsb/kweb/components/cookies"
+	"github.com/justinsb/kweb/components/csrf"
 	"github.com/justinsb/kweb/components/keystore"
 	"github.com/justinsb/kweb/components/users"
 	userapi "github.com/justinsb/kweb/components/users/pb"
@@ -42,7 +43,12 @@ func NewJWTIssuerComponent(keys keystore.KeySet, oidcAuthenticator *oidc.Authent
 	}
 }
 
-var _ components.RequestFilter = &JWTIssuerComponent{}
+var _ components.OrderedRequestFilter = &JWTIssuerComponent{}
+
+func (c *JWTIssuerComponent) FilterOrder() components.FilterOrder {
+	// We issue a JWT for the current user, and use the session
+	return components.FilterOrder{Name: "jwtissuer", After: []string{"users"}}
+}
 
 func (c *JWTIssuerComponent) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
 	log := klog.FromContext(ctx)
@@ -133,9 +139,22 @@ func (c *JWTIssuerComponent) ProcessRequest(ctx context.Context, req *components
 }
 
 func (c *JWTIssuerComponent) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
-	mux.HandleFunc("/.well-known/openid-configuration", s.ServeHTTP(c.ServeOpenIDConfiguration))
-	mux.HandleFunc("/.oidc/jwks", s.ServeHTTP(c.ServeJWKS))
-	mux.HandleFunc("/.oidc/userinfo", s.ServeHTTP(c.ServeUserInfo))
+	routes := s.Routes()
+	if err := routes.GET("/.well-known/openid-configuration", c.ServeOpenIDConfiguration); err != nil {
+		return err
+	}
+	if err := routes.GET("/.oidc/jwks", c.ServeJWKS); err != nil {
+		return err
+	}
+	// The OIDC spec allows userinfo to be requested with GET or POST
+	if err := routes.GET("/.oidc/userinfo", c.ServeUserInfo); err != nil {
+		return err
+	}
+	if err := routes.POST("/.oidc/userinfo", c.ServeUserInfo); err != nil {
+		return err
+	}
+	// userinfo is authenticated with a bearer token, not cookies
+	csrf.Exempt(s, "/.oidc/userinfo")
 	return nil
 }
 
@@ -10,7 +10,6 @@ import (
 	"github.com/justinsb/kweb/components"
 	userapi "github.com/justinsb/kweb/components/users/pb"
 	"gopkg.in/square/go-jose.v2"
-	"k8s.io/klog/v2"
 )
 
 type discovery struct {
@@ -65,15 +64,14 @@ func (c *JWTIssuerComponent) ServeUserInfo(ctx context.Context, req *components.
 		token = strings.TrimPrefix(auth, "Bearer ")
 	}
 	if token == "" {
-		return components.ErrorResponse(http.StatusUnauthorized), nil
+		return nil, components.NewHTTPError(http.StatusUnauthorized, "", nil).WithHeader("WWW-Authenticate", "Bearer")
 	}
 
 	var user *userapi.User
 	if token != "" {
 		u, err := c.oidcAuthenticator.UserFromJWT(ctx, token)
 		if err != nil {
-			klog.Warningf("error get user from jwt: %v", err)
-			return components.ErrorResponse(http.StatusInternalServerError), nil
+			return nil, fmt.Errorf("error getting user from jwt: %w", err)
 		}
 		user = u
 	}
@@ -97,5 +95,5 @@ func (c *JWTIssuerComponent) ServeUserInfo(ctx context.Context, req *components.
 		return &components.JSONResponse{Object: info}, nil
 	}
 
-	return components.ErrorResponse(http.StatusUnauthorized), nil
+	return nil, components.NewHTTPError(http.StatusUnauthorized, "", nil).WithHeader("WWW-Authenticate", "Bearer")
 }
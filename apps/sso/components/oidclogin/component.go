@@ -20,7 +20,12 @@ func NewOIDCLoginComponent(ctx context.Context, oidcAuthentiator *oidc.Authentic
 	}
 }
 
-var _ components.RequestFilter = &OIDCLoginComponent{}
+var _ components.OrderedRequestFilter = &OIDCLoginComponent{}
+
+func (c *OIDCLoginComponent) FilterOrder() components.FilterOrder {
+	// We set the current user, which jwtissuer then reads
+	return components.FilterOrder{Name: "oidclogin", After: []string{"users"}, Before: []string{"jwtissuer"}}
+}
 
 func (c *OIDCLoginComponent) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
 	userInfo, err := c.userFromJWTToken(ctx, req, c.oidcAuthenticator)
@@ -0,0 +1,123 @@
+// kweb-migrate-sessions rewrites Session objects that use the legacy JSON encoding in the current (proto) encoding.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/sessions"
+	"github.com/justinsb/kweb/components/sessions/kubesessionstorage/api"
+	"github.com/justinsb/kweb/server"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func main() {
+	if err := run(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context) error {
+	namespace := "sessions"
+	dryRun := false
+	batchSize := int64(500)
+	flag.StringVar(&namespace, "namespace", namespace, "namespace holding the Session objects")
+	flag.BoolVar(&dryRun, "dry-run", dryRun, "report the sessions that would be migrated, without changing them")
+	flag.Int64Var(&batchSize, "batch-size", batchSize, "number of sessions to list at a time")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	restConfig, err := server.GetRESTConfig()
+	if err != nil {
+		return fmt.Errorf("error getting kubernetes configuration: %w", err)
+	}
+
+	kube, err := kubeclient.New(restConfig, runtime.NewScheme())
+	if err != nil {
+		return fmt.Errorf("error building kubernetes client: %w", err)
+	}
+
+	scanned := 0
+	migrated := 0
+	failed := 0
+	continueToken := ""
+	for {
+		var list api.SessionList
+		listOptions := []client.ListOption{
+			client.InNamespace(namespace),
+			client.Limit(batchSize),
+		}
+		if continueToken != "" {
+			listOptions = append(listOptions, client.Continue(continueToken))
+		}
+		if err := kube.Uncached().List(ctx, &list, listOptions...); err != nil {
+			return fmt.Errorf("error listing sessions: %w", err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			scanned++
+			if !sessions.IsLegacyFormat(obj.Spec.Data) {
+				continue
+			}
+
+			if dryRun {
+				klog.Infof("would migrate session %s/%s", obj.Namespace, obj.Name)
+				migrated++
+				continue
+			}
+
+			if err := migrate(ctx, kube, obj); err != nil {
+				klog.Warningf("error migrating session %s/%s: %v", obj.Namespace, obj.Name, err)
+				failed++
+				continue
+			}
+			migrated++
+		}
+
+		continueToken = list.Continue
+		if continueToken == "" {
+			break
+		}
+	}
+
+	klog.Infof("scanned %d sessions; migrated %d; failed %d", scanned, migrated, failed)
+	if failed != 0 {
+		return fmt.Errorf("failed to migrate %d sessions", failed)
+	}
+	return nil
+}
+
+// migrate re-encodes the session data; the update uses the listed resourceVersion, so concurrent changes are not overwritten.
+func migrate(ctx context.Context, kube *kubeclient.Client, obj *api.Session) error {
+	session, err := sessions.Decode(obj.Name, obj.Spec.Data)
+	if err != nil {
+		return err
+	}
+	b, err := sessions.Encode(session)
+	if err != nil {
+		return err
+	}
+	obj.Spec.Data = b
+	if err := kube.Uncached().Update(ctx, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Deleted (e.g. expired) since we listed it
+			return nil
+		}
+		if apierrors.IsConflict(err) {
+			// The session was written since we listed it, which will have used the new format
+			klog.Infof("session %s/%s changed concurrently; skipping", obj.Namespace, obj.Name)
+			return nil
+		}
+		return err
+	}
+	klog.Infof("migrated session %s/%s", obj.Namespace, obj.Name)
+	return nil
+}
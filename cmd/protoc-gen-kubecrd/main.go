@@ -0,0 +1,342 @@
+// protoc-gen-kubecrd generates CustomResourceDefinition manifests for proto messages annotated with kube.kind.
+//
+// For each kind, it writes config/<group>_<resource>.yaml alongside the proto file.
+// The schema follows the JSON encoding of kubejson: fields use their JSON names and 64-bit integers are numbers.
+// Kinds with a status field get the status subresource.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/justinsb/kweb/components/kube"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	protogen.Options{}.Run(generate)
+}
+
+// generate writes the CRDs for the kinds in the files we were asked to generate.
+func generate(plugin *protogen.Plugin) error {
+	plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+		for _, message := range file.Messages {
+			if !kube.IsKind(message.Desc) {
+				continue
+			}
+			if err := generateCRD(plugin, file, message); err != nil {
+				return fmt.Errorf("generating CRD for %v: %w", message.Desc.FullName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// generateCRD writes the CRD for a kind.
+func generateCRD(plugin *protogen.Plugin, file *protogen.File, message *protogen.Message) error {
+	kindInfo := kube.GetKindInfoForDescriptor(message.Desc)
+	annotation := kube.GetKindAnnotation(message.Desc)
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	crd.APIVersion = "apiextensions.k8s.io/v1"
+	crd.Kind = "CustomResourceDefinition"
+	crd.Name = kindInfo.Resource + "." + kindInfo.Group
+	crd.Spec.Group = kindInfo.Group
+	crd.Spec.Names = apiextensionsv1.CustomResourceDefinitionNames{
+		Kind:     kindInfo.Kind,
+		ListKind: kindInfo.Kind + "List",
+		Plural:   kindInfo.Resource,
+		Singular: strings.ToLower(kindInfo.Kind),
+	}
+	// TODO: Support cluster-scoped kinds
+	crd.Spec.Scope = apiextensionsv1.NamespaceScoped
+
+	schema, err := buildObjectSchema(message)
+	if err != nil {
+		return err
+	}
+
+	version := apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    kindInfo.Version,
+		Served:  true,
+		Storage: true,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: schema,
+		},
+	}
+
+	for _, field := range message.Fields {
+		if field.Desc.JSONName() == "status" {
+			version.Subresources = &apiextensionsv1.CustomResourceSubresources{
+				Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+			}
+		}
+	}
+
+	for _, column := range annotation.GetPrinterColumns() {
+		version.AdditionalPrinterColumns = append(version.AdditionalPrinterColumns, apiextensionsv1.CustomResourceColumnDefinition{
+			Name:        column.GetName(),
+			Type:        column.GetType(),
+			JSONPath:    column.GetJsonPath(),
+			Description: column.GetDescription(),
+			Priority:    column.GetPriority(),
+		})
+	}
+	if len(version.AdditionalPrinterColumns) != 0 {
+		// kubectl only shows the age when there are no custom columns
+		version.AdditionalPrinterColumns = append(version.AdditionalPrinterColumns, apiextensionsv1.CustomResourceColumnDefinition{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		})
+	}
+
+	crd.Spec.Versions = append(crd.Spec.Versions, version)
+
+	b, err := marshalCRD(crd)
+	if err != nil {
+		return err
+	}
+
+	dir := path.Dir(file.GeneratedFilenamePrefix)
+	g := plugin.NewGeneratedFile(path.Join(dir, "config", kindInfo.Group+"_"+kindInfo.Resource+".yaml"), "")
+	g.P("# Code generated by protoc-gen-kubecrd. DO NOT EDIT.")
+	g.P("# source: ", file.Desc.Path())
+	g.P("---")
+	if _, err := g.Write(b); err != nil {
+		return err
+	}
+	return nil
+}
+
+// marshalCRD converts the CRD to yaml.
+// The CRD type always serializes creationTimestamp and status (as null and empty values), which are set by the apiserver,
+// so we remove them rather than checking them in.
+func marshalCRD(crd *apiextensionsv1.CustomResourceDefinition) ([]byte, error) {
+	j, err := json.Marshal(crd)
+	if err != nil {
+		return nil, fmt.Errorf("converting to json: %w", err)
+	}
+	obj := make(map[string]any)
+	if err := json.Unmarshal(j, &obj); err != nil {
+		return nil, fmt.Errorf("parsing json: %w", err)
+	}
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]any); ok {
+		delete(metadata, "creationTimestamp")
+	}
+
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("converting to yaml: %w", err)
+	}
+	return b, nil
+}
+
+// buildObjectSchema builds the schema for the top-level object of a kind.
+func buildObjectSchema(message *protogen.Message) (*apiextensionsv1.JSONSchemaProps, error) {
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:        "object",
+		Description: description(message.Comments),
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"apiVersion": {
+				Type:        "string",
+				Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+			},
+			"kind": {
+				Type:        "string",
+				Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+			},
+			"metadata": {
+				Type: "object",
+			},
+		},
+	}
+
+	b := &schemaBuilder{}
+	for _, field := range message.Fields {
+		if field.Message != nil {
+			switch field.Message.Desc.FullName() {
+			case "kube.TypeMeta", "kube.ObjectMeta":
+				// Written as apiVersion, kind and metadata
+				continue
+			}
+		}
+		fieldSchema, err := b.buildFieldSchema(field)
+		if err != nil {
+			return nil, err
+		}
+		schema.Properties[field.Desc.JSONName()] = *fieldSchema
+	}
+	return schema, nil
+}
+
+// schemaBuilder builds the OpenAPI schema for proto messages.
+type schemaBuilder struct {
+	// stack holds the messages we are building, to detect recursive types
+	stack []protoreflect.FullName
+}
+
+func (b *schemaBuilder) buildFieldSchema(field *protogen.Field) (*apiextensionsv1.JSONSchemaProps, error) {
+	var schema *apiextensionsv1.JSONSchemaProps
+	switch {
+	case field.Desc.IsMap():
+		valueSchema, err := b.buildValueSchema(field.Message.Fields[1])
+		if err != nil {
+			return nil, err
+		}
+		schema = &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+				Allows: true,
+				Schema: valueSchema,
+			},
+		}
+
+	case field.Desc.IsList():
+		itemSchema, err := b.buildValueSchema(field)
+		if err != nil {
+			return nil, err
+		}
+		schema = &apiextensionsv1.JSONSchemaProps{
+			Type: "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+				Schema: itemSchema,
+			},
+		}
+
+	default:
+		s, err := b.buildValueSchema(field)
+		if err != nil {
+			return nil, err
+		}
+		schema = s
+	}
+
+	schema.Description = description(field.Comments)
+	return schema, nil
+}
+
+// buildValueSchema builds the schema for a single value of the field (a list item or map value, for repeated fields).
+func (b *schemaBuilder) buildValueSchema(field *protogen.Field) (*apiextensionsv1.JSONSchemaProps, error) {
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return &apiextensionsv1.JSONSchemaProps{Type: "boolean"}, nil
+
+	case protoreflect.StringKind:
+		return &apiextensionsv1.JSONSchemaProps{Type: "string"}, nil
+
+	case protoreflect.BytesKind:
+		return &apiextensionsv1.JSONSchemaProps{Type: "string", Format: "byte"}, nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int32"}, nil
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64"}, nil
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return &apiextensionsv1.JSONSchemaProps{Type: "number"}, nil
+
+	case protoreflect.EnumKind:
+		schema := &apiextensionsv1.JSONSchemaProps{Type: "string"}
+		for _, value := range field.Enum.Values {
+			schema.Enum = append(schema.Enum, apiextensionsv1.JSON{Raw: []byte(`"` + string(value.Desc.Name()) + `"`)})
+		}
+		return schema, nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.buildMessageSchema(field.Message)
+	}
+
+	return nil, fmt.Errorf("unhandled kind %v for field %v", field.Desc.Kind(), field.Desc.FullName())
+}
+
+func (b *schemaBuilder) buildMessageSchema(message *protogen.Message) (*apiextensionsv1.JSONSchemaProps, error) {
+	fullName := message.Desc.FullName()
+	switch fullName {
+	case "google.protobuf.Timestamp":
+		return &apiextensionsv1.JSONSchemaProps{Type: "string", Format: "date-time"}, nil
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return &apiextensionsv1.JSONSchemaProps{Type: "string"}, nil
+	case "google.protobuf.Struct", "google.protobuf.Any":
+		return &apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: boolPtr(true)}, nil
+	case "google.protobuf.ListValue":
+		return &apiextensionsv1.JSONSchemaProps{
+			Type: "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+				Schema: &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)},
+			},
+		}, nil
+	case "google.protobuf.Value":
+		return &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}, nil
+	case "google.protobuf.Empty":
+		return &apiextensionsv1.JSONSchemaProps{Type: "object"}, nil
+	case "google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value", "google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		// Wrappers are encoded as the value, or null
+		schema, err := b.buildValueSchema(message.Fields[0])
+		if err != nil {
+			return nil, err
+		}
+		schema.Nullable = true
+		return schema, nil
+	case "kube.ObjectMeta":
+		return &apiextensionsv1.JSONSchemaProps{Type: "object"}, nil
+	}
+
+	for _, parent := range b.stack {
+		if parent == fullName {
+			// Structural schemas cannot be recursive, so we stop validating here
+			return &apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: boolPtr(true)}, nil
+		}
+	}
+	b.stack = append(b.stack, fullName)
+	defer func() {
+		b.stack = b.stack[:len(b.stack)-1]
+	}()
+
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: make(map[string]apiextensionsv1.JSONSchemaProps),
+	}
+	for _, field := range message.Fields {
+		fieldSchema, err := b.buildFieldSchema(field)
+		if err != nil {
+			return nil, err
+		}
+		schema.Properties[field.Desc.JSONName()] = *fieldSchema
+	}
+	return schema, nil
+}
+
+// description returns the leading comment, as used for the OpenAPI description.
+func description(comments protogen.CommentSet) string {
+	s := string(comments.Leading)
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	githubpb "github.com/justinsb/kweb/components/github/pb"
+	oauthsessionspb "github.com/justinsb/kweb/components/oauthsessions/pb"
+	userspb "github.com/justinsb/kweb/components/users/pb"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// goldenTests are the kinds we generate CRDs for; testdata/<name>.golden.yaml is the expected CRD.
+// We generate from the descriptors compiled into the go code, which don't include comments,
+// so the golden CRDs don't have the descriptions of the CRDs checked in alongside the protos.
+var goldenTests = []struct {
+	name string
+	file protoreflect.FileDescriptor
+	// output is the path of the generated CRD
+	output string
+}{
+	{name: "users", file: userspb.File_components_users_pb_user_proto, output: "components/users/pb/config/kweb.dev_users.yaml"},
+	{name: "oauthsessions", file: oauthsessionspb.File_components_oauthsessions_pb_oauthsession_proto, output: "components/oauthsessions/pb/config/kweb.dev_oauthsessions.yaml"},
+	{name: "appinstallations", file: githubpb.File_components_github_pb_types_proto, output: "components/github/pb/config/github.kweb.dev_appinstallations.yaml"},
+}
+
+func TestGolden(t *testing.T) {
+	for _, tc := range goldenTests {
+		t.Run(tc.name, func(t *testing.T) {
+			files := runPlugin(t, tc.file)
+			got, found := files[tc.output]
+			if !found {
+				t.Fatalf("%s was not generated (generated %v)", tc.output, keys(files))
+			}
+
+			goldenPath := filepath.Join("testdata", tc.name+".golden.yaml")
+			if *update {
+				if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+			}
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			if got != string(golden) {
+				t.Errorf("generated CRD does not match %s (run with -update to update):\n%s", goldenPath, got)
+			}
+
+			// Fields set by the apiserver are not written
+			for _, s := range []string{"creationTimestamp: null", "\nstatus:"} {
+				if strings.Contains(got, s) {
+					t.Errorf("generated CRD contains %q", strings.TrimSpace(s))
+				}
+			}
+		})
+	}
+}
+
+// runPlugin runs the generator on the file, returning the contents of the generated files by name.
+func runPlugin(t *testing.T, file protoreflect.FileDescriptor) map[string]string {
+	t.Helper()
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.Path()},
+		Parameter:      proto.String("paths=source_relative"),
+	}
+	// Dependencies must come before the files that import them
+	seen := make(map[string]bool)
+	var addFile func(fd protoreflect.FileDescriptor)
+	addFile = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			addFile(imports.Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	addFile(file)
+
+	plugin, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("error building plugin: %v", err)
+	}
+	if err := generate(plugin); err != nil {
+		t.Fatalf("error generating: %v", err)
+	}
+	response := plugin.Response()
+	if response.Error != nil {
+		t.Fatalf("generator failed: %v", response.GetError())
+	}
+
+	files := make(map[string]string)
+	for _, f := range response.GetFile() {
+		files[f.GetName()] = f.GetContent()
+	}
+	return files
+}
+
+func keys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -26,3 +26,12 @@ type AuthenticationProvider interface {
 type UserMapper interface {
 	MapToUser(ctx context.Context, token *oauth2.Token, info *AuthenticationInfo) (*userapi.User, error)
 }
+
+// TokenProvider is implemented by authentication providers whose tokens we store, so that we can call the provider's APIs on behalf of the user.
+type TokenProvider interface {
+	AuthenticationProvider
+	// TokenSource returns a token source that refreshes token when it expires.
+	TokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource
+	// RevokeToken revokes token with the provider, so that it can no longer be used.
+	RevokeToken(ctx context.Context, token *oauth2.Token) error
+}
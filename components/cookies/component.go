@@ -30,7 +30,11 @@ func getResponseCookies(ctx context.Context) *responseCookies {
 	return cookies.(*responseCookies)
 }
 
-var _ components.RequestFilter = &CookiesComponent{}
+var _ components.OrderedRequestFilter = &CookiesComponent{}
+
+func (c *CookiesComponent) FilterOrder() components.FilterOrder {
+	return components.FilterOrder{Name: "cookies"}
+}
 
 func (c *CookiesComponent) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
 	responseCookies := &responseCookies{}
@@ -0,0 +1,254 @@
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/csrf/pb"
+	"github.com/justinsb/kweb/templates/scopes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HeaderName is the request header that can carry the CSRF token (for script-initiated requests).
+	HeaderName = "X-CSRF-Token"
+
+	// FieldName is the form field that can carry the CSRF token (for HTML forms).
+	FieldName = "_csrf"
+)
+
+// Options configures the CSRF component.
+type Options struct {
+	// ExemptPaths are paths that are not checked, along with the paths below them
+	// (so "/webhook" exempts "/webhook" and "/webhook/github", but not "/webhooks").
+	// This is intended for endpoints that do not use cookie authentication, such as webhooks.
+	ExemptPaths []string
+
+	// TrustedOrigins are additional origins (e.g. "https://example.com") that may send state-changing requests.
+	TrustedOrigins []string
+}
+
+// Component implements protection against cross-site request forgery.
+//
+// State-changing requests (anything other than GET, HEAD, OPTIONS or TRACE) must come from
+// our own origin, and must carry the per-session token, either in the X-CSRF-Token header
+// or in the _csrf form field.
+//
+// Templates can include the token in forms using the csrf scope value, either as a ready-made hidden input:
+//
+//	{{csrf.field.html}}
+//
+// or by building the input themselves:
+//
+//	<input type="hidden" name="{{csrf.field.name}}" value="{{csrf.field.value}}">
+type Component struct {
+	mutex          sync.Mutex
+	exemptPaths    []string
+	trustedOrigins map[string]bool
+}
+
+var _ components.Component = &Component{}
+
+// New constructs a CSRF component.
+func New(opt Options) *Component {
+	c := &Component{
+		trustedOrigins: make(map[string]bool),
+	}
+	for _, prefix := range opt.ExemptPaths {
+		c.ExemptPathPrefix(prefix)
+	}
+	for _, origin := range opt.TrustedOrigins {
+		c.trustedOrigins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	return c
+}
+
+// ExemptPathPrefix disables CSRF checks for requests to the path, and to the paths below it.
+// Only use this for endpoints that authenticate requests some other way (not with cookies).
+func (c *Component) ExemptPathPrefix(prefix string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.exemptPaths = append(c.exemptPaths, prefix)
+}
+
+// Exempt disables CSRF checks for the path (and the paths below it), if the server has a CSRF component.
+func Exempt(s *components.Server, prefix string) {
+	var c *Component
+	if err := components.GetComponentFromServer(s, &c); err != nil {
+		klog.V(2).Infof("csrf component not found; not exempting %q: %v", prefix, err)
+		return
+	}
+	c.ExemptPathPrefix(prefix)
+}
+
+func (c *Component) isExempt(path string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, prefix := range c.exemptPaths {
+		if isUnderPath(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnderPath returns true if path is prefix, or is below prefix (i.e. the match ends at a / boundary).
+func isUnderPath(path string, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+var _ components.OrderedRequestFilter = &Component{}
+
+func (c *Component) FilterOrder() components.FilterOrder {
+	// We store the token in the session
+	return components.FilterOrder{Name: "csrf", After: []string{"sessions"}}
+}
+
+func (c *Component) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
+	if isSafeMethod(req.Method) || c.isExempt(req.URL.Path) {
+		return next(ctx, req)
+	}
+
+	if err := c.checkOrigin(req); err != nil {
+		klog.Infof("rejecting %s %s: %v", req.Method, req.URL.Path, err)
+		return nil, components.NewHTTPError(http.StatusForbidden, "cross-site request rejected", err)
+	}
+
+	if err := c.checkToken(req); err != nil {
+		klog.Infof("rejecting %s %s: %v", req.Method, req.URL.Path, err)
+		return nil, components.NewHTTPError(http.StatusForbidden, "invalid or missing CSRF token", err)
+	}
+
+	return next(ctx, req)
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// checkOrigin uses the Sec-Fetch-Site and Origin headers (where the browser sends them) to reject cross-site requests.
+func (c *Component) checkOrigin(req *components.Request) error {
+	switch secFetchSite := req.Header.Get("Sec-Fetch-Site"); secFetchSite {
+	case "", "same-origin", "none":
+	default:
+		return fmt.Errorf("Sec-Fetch-Site was %q", secFetchSite)
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if origin == "null" {
+		return fmt.Errorf("origin was null")
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("cannot parse origin %q: %w", origin, err)
+	}
+
+	scheme := "http"
+	if req.BrowserUsingHTTPS() {
+		scheme = "https"
+	}
+	if strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, req.Host) {
+		return nil
+	}
+
+	if c.trustedOrigins[strings.ToLower(u.Scheme+"://"+u.Host)] {
+		return nil
+	}
+	return fmt.Errorf("origin %q does not match host %q", origin, req.Host)
+}
+
+// checkToken verifies that the request carries the token for the session.
+func (c *Component) checkToken(req *components.Request) error {
+	if req.Session == nil {
+		return fmt.Errorf("no session")
+	}
+	data := &pb.CSRFSessionData{}
+	if !req.Session.Get(data) || data.GetToken() == "" {
+		return fmt.Errorf("no CSRF token in session")
+	}
+
+	token := req.Header.Get(HeaderName)
+	if token == "" {
+		token = req.PostFormValue(FieldName)
+	}
+	if token == "" {
+		return fmt.Errorf("no CSRF token in request")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(data.GetToken())) != 1 {
+		return fmt.Errorf("CSRF token did not match")
+	}
+	return nil
+}
+
+// Token returns the CSRF token for the current session, creating one if needed.
+func Token(ctx context.Context) (string, error) {
+	req := components.GetRequest(ctx)
+	if req.Session == nil {
+		return "", fmt.Errorf("csrf requires the sessions component")
+	}
+
+	data := &pb.CSRFSessionData{}
+	if req.Session.Get(data) && data.GetToken() != "" {
+		return data.GetToken(), nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating CSRF token: %w", err)
+	}
+	data.Token = base64.RawURLEncoding.EncodeToString(b)
+	req.Session.Set(data)
+	return data.Token, nil
+}
+
+func (c *Component) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
+	return nil
+}
+
+// fieldHTML returns a hidden form input carrying the token.
+func fieldHTML(token string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(FieldName) + `" value="` + template.HTMLEscapeString(token) + `">`)
+}
+
+func (c *Component) AddToScope(ctx context.Context, scope *scopes.Scope) {
+	scope.Values["csrf"] = scopes.Value{
+		Function: func() (any, error) {
+			token, err := Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{
+				"token":      token,
+				"fieldName":  FieldName,
+				"headerName": HeaderName,
+				"field": map[string]any{
+					"name":  FieldName,
+					"value": token,
+					"html":  fieldHTML(token),
+				},
+			}, nil
+		},
+	}
+}
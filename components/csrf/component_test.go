@@ -0,0 +1,153 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/csrf/pb"
+	"github.com/justinsb/kweb/components/sessions"
+)
+
+const testToken = "the-token"
+
+// serve runs the request through the CSRF filter, returning the status code (200 if the request was allowed).
+func serve(t *testing.T, c *Component, r *http.Request) int {
+	t.Helper()
+	session := &sessions.Session{}
+	session.Set(&pb.CSRFSessionData{Token: testToken})
+	req := &components.Request{
+		Request:        r,
+		Session:        session,
+		PathParameters: make(map[string]string),
+	}
+
+	next := func(ctx context.Context, req *components.Request) (components.Response, error) {
+		return &components.SimpleResponse{}, nil
+	}
+	_, err := c.ProcessRequest(context.Background(), req, next)
+	if err == nil {
+		return http.StatusOK
+	}
+	var httpError *components.HTTPError
+	if !errors.As(err, &httpError) {
+		t.Fatalf("expected HTTPError, got %v", err)
+	}
+	return httpError.Status
+}
+
+func TestTokenIsRequired(t *testing.T) {
+	c := New(Options{})
+
+	grid := []struct {
+		name   string
+		header string
+		form   string
+		want   int
+	}{
+		{name: "missing token", want: http.StatusForbidden},
+		{name: "wrong header token", header: "wrong", want: http.StatusForbidden},
+		{name: "wrong form token", form: "wrong", want: http.StatusForbidden},
+		{name: "header token", header: testToken, want: http.StatusOK},
+		{name: "form token", form: testToken, want: http.StatusOK},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			form := url.Values{}
+			if g.form != "" {
+				form.Set(FieldName, g.form)
+			}
+			r := httptest.NewRequest("POST", "https://example.com/items", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if g.header != "" {
+				r.Header.Set(HeaderName, g.header)
+			}
+			if got := serve(t, c, r); got != g.want {
+				t.Errorf("got status %d, want %d", got, g.want)
+			}
+		})
+	}
+}
+
+func TestCrossSiteRequestsAreRejected(t *testing.T) {
+	c := New(Options{TrustedOrigins: []string{"https://trusted.example.com/"}})
+
+	grid := []struct {
+		name         string
+		origin       string
+		secFetchSite string
+		want         int
+	}{
+		{name: "same origin", origin: "https://example.com", want: http.StatusOK},
+		{name: "no origin", want: http.StatusOK},
+		{name: "cross-site origin", origin: "https://evil.com", want: http.StatusForbidden},
+		{name: "other scheme", origin: "http://example.com", want: http.StatusForbidden},
+		{name: "null origin", origin: "null", want: http.StatusForbidden},
+		{name: "trusted origin", origin: "https://trusted.example.com", want: http.StatusOK},
+		{name: "same-origin fetch", secFetchSite: "same-origin", want: http.StatusOK},
+		{name: "cross-site fetch", secFetchSite: "cross-site", want: http.StatusForbidden},
+		{name: "same-site fetch", secFetchSite: "same-site", want: http.StatusForbidden},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "https://example.com/items", nil)
+			r.Header.Set(HeaderName, testToken)
+			if g.origin != "" {
+				r.Header.Set("Origin", g.origin)
+			}
+			if g.secFetchSite != "" {
+				r.Header.Set("Sec-Fetch-Site", g.secFetchSite)
+			}
+			if got := serve(t, c, r); got != g.want {
+				t.Errorf("got status %d, want %d", got, g.want)
+			}
+		})
+	}
+}
+
+func TestSafeMethodsAreNotChecked(t *testing.T) {
+	c := New(Options{})
+
+	for _, method := range []string{"GET", "HEAD", "OPTIONS", "TRACE"} {
+		r := httptest.NewRequest(method, "https://example.com/items", nil)
+		r.Header.Set("Origin", "https://evil.com")
+		if got := serve(t, c, r); got != http.StatusOK {
+			t.Errorf("%s: got status %d, want %d", method, got, http.StatusOK)
+		}
+	}
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		r := httptest.NewRequest(method, "https://example.com/items", nil)
+		if got := serve(t, c, r); got != http.StatusForbidden {
+			t.Errorf("%s: got status %d, want %d", method, got, http.StatusForbidden)
+		}
+	}
+}
+
+func TestExemptPaths(t *testing.T) {
+	c := New(Options{ExemptPaths: []string{"/_ghapp/webhook", "/hooks/"}})
+
+	grid := []struct {
+		path string
+		want int
+	}{
+		{path: "/_ghapp/webhook", want: http.StatusOK},
+		{path: "/_ghapp/webhook/github", want: http.StatusOK},
+		{path: "/_ghapp/webhookX", want: http.StatusForbidden},
+		{path: "/_ghapp/web", want: http.StatusForbidden},
+		{path: "/_ghapp/select", want: http.StatusForbidden},
+		{path: "/hooks/a", want: http.StatusOK},
+		{path: "/hooksX", want: http.StatusForbidden},
+	}
+	for _, g := range grid {
+		r := httptest.NewRequest("POST", "https://example.com"+g.path, nil)
+		r.Header.Set("Origin", "https://evil.com")
+		if got := serve(t, c, r); got != g.want {
+			t.Errorf("%s: got status %d, want %d", g.path, got, g.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: components/csrf/pb/csrf.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CSRFSessionData is stored in the session, and holds the CSRF token for the session.
+type CSRFSessionData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (x *CSRFSessionData) Reset() {
+	*x = CSRFSessionData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_csrf_pb_csrf_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSRFSessionData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSRFSessionData) ProtoMessage() {}
+
+func (x *CSRFSessionData) ProtoReflect() protoreflect.Message {
+	mi := &file_components_csrf_pb_csrf_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSRFSessionData.ProtoReflect.Descriptor instead.
+func (*CSRFSessionData) Descriptor() ([]byte, []int) {
+	return file_components_csrf_pb_csrf_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CSRFSessionData) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+var File_components_csrf_pb_csrf_proto protoreflect.FileDescriptor
+
+var file_components_csrf_pb_csrf_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x63, 0x73, 0x72,
+	0x66, 0x2f, 0x70, 0x62, 0x2f, 0x63, 0x73, 0x72, 0x66, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x02, 0x70, 0x62, 0x22, 0x27, 0x0a, 0x0f, 0x43, 0x53, 0x52, 0x46, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x44, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x42, 0x68, 0x0a, 0x06,
+	0x63, 0x6f, 0x6d, 0x2e, 0x70, 0x62, 0x42, 0x09, 0x43, 0x73, 0x72, 0x66, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x50, 0x01, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x6a, 0x75, 0x73, 0x74, 0x69, 0x6e, 0x73, 0x62, 0x2f, 0x6b, 0x77, 0x65, 0x62, 0x2f, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x63, 0x73, 0x72, 0x66, 0x2f, 0x70, 0x62,
+	0xa2, 0x02, 0x03, 0x50, 0x58, 0x58, 0xaa, 0x02, 0x02, 0x50, 0x62, 0xca, 0x02, 0x02, 0x50, 0x62,
+	0xe2, 0x02, 0x0e, 0x50, 0x62, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0xea, 0x02, 0x02, 0x50, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_components_csrf_pb_csrf_proto_rawDescOnce sync.Once
+	file_components_csrf_pb_csrf_proto_rawDescData = file_components_csrf_pb_csrf_proto_rawDesc
+)
+
+func file_components_csrf_pb_csrf_proto_rawDescGZIP() []byte {
+	file_components_csrf_pb_csrf_proto_rawDescOnce.Do(func() {
+		file_components_csrf_pb_csrf_proto_rawDescData = protoimpl.X.CompressGZIP(file_components_csrf_pb_csrf_proto_rawDescData)
+	})
+	return file_components_csrf_pb_csrf_proto_rawDescData
+}
+
+var file_components_csrf_pb_csrf_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_components_csrf_pb_csrf_proto_goTypes = []interface{}{
+	(*CSRFSessionData)(nil), // 0: pb.CSRFSessionData
+}
+var file_components_csrf_pb_csrf_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_components_csrf_pb_csrf_proto_init() }
+func file_components_csrf_pb_csrf_proto_init() {
+	if File_components_csrf_pb_csrf_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_components_csrf_pb_csrf_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSRFSessionData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_components_csrf_pb_csrf_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_components_csrf_pb_csrf_proto_goTypes,
+		DependencyIndexes: file_components_csrf_pb_csrf_proto_depIdxs,
+		MessageInfos:      file_components_csrf_pb_csrf_proto_msgTypes,
+	}.Build()
+	File_components_csrf_pb_csrf_proto = out.File
+	file_components_csrf_pb_csrf_proto_rawDesc = nil
+	file_components_csrf_pb_csrf_proto_goTypes = nil
+	file_components_csrf_pb_csrf_proto_depIdxs = nil
+}
@@ -0,0 +1,214 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// HTTPError is an error that is reported to the client with a specific status code.
+// Only Message and Details are sent to the client; Cause is logged but never exposed.
+type HTTPError struct {
+	// Status is the HTTP status code.
+	Status int
+
+	// Message is a human-readable explanation, safe to show to the client.
+	Message string
+
+	// Cause is the underlying (internal) error.
+	Cause error
+
+	// Details are additional values to show to the client.
+	Details map[string]any
+
+	// Headers are additional headers for the response, such as Allow for a 405.
+	Headers http.Header
+}
+
+var _ error = &HTTPError{}
+
+// NewHTTPError constructs an HTTPError.
+func NewHTTPError(status int, message string, cause error) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Message: message,
+		Cause:   cause,
+	}
+}
+
+// WithDetail adds a public detail value to the error.
+func (e *HTTPError) WithDetail(key string, value any) *HTTPError {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// WithHeader adds a header to the error response.
+func (e *HTTPError) WithHeader(key string, value string) *HTTPError {
+	if e.Headers == nil {
+		e.Headers = make(http.Header)
+	}
+	e.Headers.Add(key, value)
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	s := fmt.Sprintf("http %d", e.Status)
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	if e.Cause != nil {
+		s += ": " + e.Cause.Error()
+	}
+	return s
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// Title returns the standard text for the status code.
+func (e *HTTPError) Title() string {
+	return http.StatusText(e.Status)
+}
+
+// AsHTTPError returns the HTTPError in the chain of err, or wraps err as an internal server error.
+func AsHTTPError(err error) *HTTPError {
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return httpError
+	}
+	return NewHTTPError(http.StatusInternalServerError, "", err)
+}
+
+// ErrorPageRenderer is implemented by components that can render an HTML error page.
+type ErrorPageRenderer interface {
+	RenderErrorPage(ctx context.Context, req *Request, httpError *HTTPError) (Response, error)
+}
+
+// ProblemResponse writes an error as an RFC 7807 problem document.
+type ProblemResponse struct {
+	Error    *HTTPError
+	Instance string
+}
+
+func (r *ProblemResponse) WriteTo(ctx context.Context, w http.ResponseWriter) {
+	problem := make(map[string]any)
+	for k, v := range r.Error.Details {
+		problem[k] = v
+	}
+	problem["type"] = "about:blank"
+	problem["title"] = r.Error.Title()
+	problem["status"] = r.Error.Status
+	if r.Error.Message != "" {
+		problem["detail"] = r.Error.Message
+	}
+	if r.Instance != "" {
+		problem["instance"] = r.Instance
+	}
+
+	b, err := json.Marshal(problem)
+	if err != nil {
+		klog.Warningf("error from json.Marshal(%T): %v", problem, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(r.Error.Status)
+	w.Write(b)
+}
+
+// textErrorResponse writes an error as plain text.
+type textErrorResponse struct {
+	Error *HTTPError
+}
+
+func (r *textErrorResponse) WriteTo(ctx context.Context, w http.ResponseWriter) {
+	text := r.Error.Message
+	if text == "" {
+		text = r.Error.Title()
+	}
+	http.Error(w, text, r.Error.Status)
+}
+
+type errorFormat int
+
+const (
+	errorFormatText errorFormat = iota
+	errorFormatHTML
+	errorFormatJSON
+)
+
+// negotiateErrorFormat picks the best error format based on the Accept header.
+func negotiateErrorFormat(accept string) errorFormat {
+	type candidate struct {
+		format errorFormat
+		q      float64
+		order  int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				q = f
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		switch mediaType {
+		case "application/problem+json", "application/json":
+			candidates = append(candidates, candidate{format: errorFormatJSON, q: q, order: i})
+		case "text/html", "application/xhtml+xml":
+			candidates = append(candidates, candidate{format: errorFormatHTML, q: q, order: i})
+		}
+	}
+	if len(candidates) == 0 {
+		return errorFormatText
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].format
+}
+
+// errorResponse builds the response for an HTTPError, based on what the client accepts.
+func (s *Server) errorResponse(ctx context.Context, req *Request, httpError *HTTPError) Response {
+	switch negotiateErrorFormat(req.Header.Get("Accept")) {
+	case errorFormatJSON:
+		return &ProblemResponse{Error: httpError, Instance: req.URL.Path}
+
+	case errorFormatHTML:
+		for _, component := range s.Components {
+			renderer, ok := component.(ErrorPageRenderer)
+			if !ok {
+				continue
+			}
+			response, err := renderer.RenderErrorPage(ctx, req, httpError)
+			if err != nil {
+				klog.Warningf("error rendering error page with %T: %v", component, err)
+				continue
+			}
+			if response != nil {
+				return response
+			}
+		}
+	}
+
+	return &textErrorResponse{Error: httpError}
+}
@@ -0,0 +1,183 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/justinsb/kweb/templates/scopes"
+)
+
+func TestNegotiateErrorFormat(t *testing.T) {
+	grid := []struct {
+		accept string
+		want   errorFormat
+	}{
+		{accept: "", want: errorFormatText},
+		{accept: "*/*", want: errorFormatText},
+		{accept: "text/plain", want: errorFormatText},
+		{accept: "application/json", want: errorFormatJSON},
+		{accept: "application/problem+json", want: errorFormatJSON},
+		{accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: errorFormatHTML},
+		{accept: "application/json, text/html", want: errorFormatJSON},
+		{accept: "text/html;q=0.5, application/json", want: errorFormatJSON},
+		{accept: "application/json;q=0, text/html", want: errorFormatHTML},
+		{accept: "application/json;q=0", want: errorFormatText},
+		{accept: "not a media type, application/json", want: errorFormatJSON},
+	}
+	for _, g := range grid {
+		if got := negotiateErrorFormat(g.accept); got != g.want {
+			t.Errorf("negotiateErrorFormat(%q): got %v, want %v", g.accept, got, g.want)
+		}
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	httpError := NewHTTPError(http.StatusConflict, "already exists", errors.New("internal detail")).WithDetail("name", "foo")
+
+	w := httptest.NewRecorder()
+	response := &ProblemResponse{Error: httpError, Instance: "/items/foo"}
+	response.WriteTo(context.Background(), w)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusConflict)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("got content type %q", got)
+	}
+	problem := make(map[string]any)
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("error parsing problem %q: %v", w.Body.String(), err)
+	}
+	want := map[string]any{
+		"type":     "about:blank",
+		"title":    "Conflict",
+		"status":   float64(http.StatusConflict),
+		"detail":   "already exists",
+		"instance": "/items/foo",
+		"name":     "foo",
+	}
+	for k, v := range want {
+		if problem[k] != v {
+			t.Errorf("problem[%q]: got %v, want %v", k, problem[k], v)
+		}
+	}
+	if len(problem) != len(want) {
+		t.Errorf("unexpected problem fields %v", problem)
+	}
+	if strings.Contains(w.Body.String(), "internal detail") {
+		t.Errorf("problem exposes the cause of the error: %s", w.Body.String())
+	}
+}
+
+// errorPageComponent renders error pages, or fails with err.
+type errorPageComponent struct {
+	err error
+}
+
+func (c *errorPageComponent) RegisterHandlers(s *Server, mux *http.ServeMux) error {
+	return nil
+}
+
+func (c *errorPageComponent) AddToScope(ctx context.Context, scope *scopes.Scope) {
+}
+
+func (c *errorPageComponent) RenderErrorPage(ctx context.Context, req *Request, httpError *HTTPError) (Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &SimpleResponse{StatusCode: httpError.Status, Body: []byte("<p>" + httpError.Message + "</p>")}, nil
+}
+
+func TestErrorResponses(t *testing.T) {
+	grid := []struct {
+		name       string
+		components []Component
+		accept     string
+		wantType   string
+		wantBody   string
+	}{
+		{
+			name:       "error page",
+			components: []Component{&errorPageComponent{}},
+			accept:     "text/html",
+			wantBody:   "<p>not here</p>",
+		},
+		{
+			name:       "error page fails",
+			components: []Component{&errorPageComponent{err: errors.New("template error")}},
+			accept:     "text/html",
+			wantType:   "text/plain; charset=utf-8",
+			wantBody:   "not here\n",
+		},
+		{
+			name:     "no error page",
+			accept:   "text/html",
+			wantType: "text/plain; charset=utf-8",
+			wantBody: "not here\n",
+		},
+		{
+			name:       "json",
+			components: []Component{&errorPageComponent{}},
+			accept:     "application/json",
+			wantType:   "application/problem+json",
+			wantBody:   `"detail":"not here"`,
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			server := &Server{Components: g.components}
+			if err := server.BuildFilterChain(); err != nil {
+				t.Fatalf("error building filter chain: %v", err)
+			}
+			handler := server.ServeHTTP(func(ctx context.Context, req *Request) (Response, error) {
+				return nil, NewHTTPError(http.StatusNotFound, "not here", nil).WithHeader("X-Test", "value")
+			})
+
+			r := httptest.NewRequest("GET", "/missing", nil)
+			r.Header.Set("Accept", g.accept)
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+			}
+			if got := w.Header().Get("X-Test"); got != "value" {
+				t.Errorf("expected headers of the error to be sent, got %q", got)
+			}
+			if g.wantType != "" {
+				if got := w.Header().Get("Content-Type"); got != g.wantType {
+					t.Errorf("got content type %q, want %q", got, g.wantType)
+				}
+			}
+			if !strings.Contains(w.Body.String(), g.wantBody) {
+				t.Errorf("got body %q, want %q", w.Body.String(), g.wantBody)
+			}
+		})
+	}
+}
+
+func TestErrorsAreNotMaskedByResponses(t *testing.T) {
+	server := &Server{}
+	if err := server.BuildFilterChain(); err != nil {
+		t.Fatalf("error building filter chain: %v", err)
+	}
+	// A handler that returns both a response and an error gets the error response
+	handler := server.ServeHTTP(func(ctx context.Context, req *Request) (Response, error) {
+		return &SimpleResponse{Body: []byte("partial")}, errors.New("internal error")
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(w.Body.String(), "partial") || strings.Contains(w.Body.String(), "internal error") {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+}
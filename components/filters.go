@@ -0,0 +1,167 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FilterOrder describes where a RequestFilter should run in the filter chain.
+type FilterOrder struct {
+	// Name identifies the filter, so that other filters can refer to it in Before / After.
+	Name string
+
+	// Priority orders filters that do not otherwise constrain each other; lower values run first (outermost).
+	Priority int
+
+	// After lists the filters that must run before this filter; they must be registered.
+	After []string
+
+	// Before lists the filters that must run after this filter; they must be registered.
+	Before []string
+}
+
+// OrderedRequestFilter is implemented by a RequestFilter that needs to run before or after other filters.
+type OrderedRequestFilter interface {
+	RequestFilter
+
+	// FilterOrder returns the ordering constraints for this filter.
+	FilterOrder() FilterOrder
+}
+
+type filterNode struct {
+	index  int
+	filter RequestFilter
+	order  FilterOrder
+
+	// after holds the indexes of nodes that must run before this node.
+	after map[int]bool
+}
+
+// sortFilters returns the filters sorted so that all ordering constraints are satisfied.
+// Where there is a choice, we prefer lower priority, and then registration order.
+func sortFilters(filters []RequestFilter) ([]RequestFilter, error) {
+	var nodes []*filterNode
+	byName := make(map[string]*filterNode)
+	for i, filter := range filters {
+		node := &filterNode{index: i, filter: filter, after: make(map[int]bool)}
+		if ordered, ok := filter.(OrderedRequestFilter); ok {
+			node.order = ordered.FilterOrder()
+		}
+		if name := node.order.Name; name != "" {
+			if existing := byName[name]; existing != nil {
+				return nil, fmt.Errorf("filter name %q is used by both %T and %T", name, existing.filter, filter)
+			}
+			byName[name] = node
+		}
+		nodes = append(nodes, node)
+	}
+
+	describe := func(node *filterNode) string {
+		if node.order.Name != "" {
+			return fmt.Sprintf("%q (%T)", node.order.Name, node.filter)
+		}
+		return fmt.Sprintf("%T", node.filter)
+	}
+
+	for _, node := range nodes {
+		for _, name := range node.order.After {
+			dep := byName[name]
+			if dep == nil {
+				return nil, fmt.Errorf("filter %s must run after filter %q, but no filter with that name is registered", describe(node), name)
+			}
+			node.after[dep.index] = true
+		}
+		for _, name := range node.order.Before {
+			dep := byName[name]
+			if dep == nil {
+				return nil, fmt.Errorf("filter %s must run before filter %q, but no filter with that name is registered", describe(node), name)
+			}
+			dep.after[node.index] = true
+		}
+	}
+
+	done := make(map[int]bool)
+	var sorted []RequestFilter
+	for len(sorted) < len(nodes) {
+		var ready []*filterNode
+		for _, node := range nodes {
+			if done[node.index] {
+				continue
+			}
+			blocked := false
+			for dep := range node.after {
+				if !done[dep] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, node)
+			}
+		}
+
+		if len(ready) == 0 {
+			var stuck []string
+			for _, node := range nodes {
+				if !done[node.index] {
+					stuck = append(stuck, describe(node))
+				}
+			}
+			return nil, fmt.Errorf("cannot order request filters, there is a cycle between %s", strings.Join(stuck, ", "))
+		}
+
+		sort.SliceStable(ready, func(i, j int) bool {
+			if ready[i].order.Priority != ready[j].order.Priority {
+				return ready[i].order.Priority < ready[j].order.Priority
+			}
+			return ready[i].index < ready[j].index
+		})
+
+		next := ready[0]
+		done[next.index] = true
+		sorted = append(sorted, next.filter)
+	}
+
+	return sorted, nil
+}
+
+// BuildFilterChain computes the order of the request filters from the components.
+// It should be called once all components have been added, before serving requests.
+func (s *Server) BuildFilterChain() error {
+	var filters []RequestFilter
+	for _, component := range s.Components {
+		if filter, ok := component.(RequestFilter); ok {
+			filters = append(filters, filter)
+		}
+	}
+
+	sorted, err := sortFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	var chain []RequestFilterFunction
+	for _, filter := range sorted {
+		chain = append(chain, filter.ProcessRequest)
+	}
+	s.filters = chain
+	s.filtersBuilt = true
+	return nil
+}
+
+// runFilters invokes the filter chain, calling fn as the innermost handler.
+func (s *Server) runFilters(ctx context.Context, req *Request, fn RequestFilterChain) (Response, error) {
+	return s.runFilter(ctx, req, 0, fn)
+}
+
+func (s *Server) runFilter(ctx context.Context, req *Request, i int, fn RequestFilterChain) (Response, error) {
+	if i >= len(s.filters) {
+		return fn(ctx, req)
+	}
+	filter := s.filters[i]
+	return filter(ctx, req, func(ctx context.Context, req *Request) (Response, error) {
+		return s.runFilter(ctx, req, i+1, fn)
+	})
+}
@@ -0,0 +1,170 @@
+package components
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// testFilter is a filter with ordering constraints; id identifies it in the sorted result.
+type testFilter struct {
+	id    string
+	order FilterOrder
+}
+
+func (f *testFilter) ProcessRequest(ctx context.Context, req *Request, next RequestFilterChain) (Response, error) {
+	return next(ctx, req)
+}
+
+func (f *testFilter) FilterOrder() FilterOrder {
+	return f.order
+}
+
+// unorderedFilter is a filter without ordering constraints.
+type unorderedFilter struct {
+	id string
+}
+
+func (f *unorderedFilter) ProcessRequest(ctx context.Context, req *Request, next RequestFilterChain) (Response, error) {
+	return next(ctx, req)
+}
+
+func named(name string, order FilterOrder) *testFilter {
+	order.Name = name
+	return &testFilter{id: name, order: order}
+}
+
+func filterIDs(filters []RequestFilter) string {
+	var ids []string
+	for _, filter := range filters {
+		switch f := filter.(type) {
+		case *testFilter:
+			ids = append(ids, f.id)
+		case *unorderedFilter:
+			ids = append(ids, f.id)
+		}
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestSortFilters(t *testing.T) {
+	grid := []struct {
+		name    string
+		filters []RequestFilter
+		want    string
+	}{
+		{
+			name:    "registration order",
+			filters: []RequestFilter{named("a", FilterOrder{}), &unorderedFilter{id: "b"}, named("c", FilterOrder{})},
+			want:    "a,b,c",
+		},
+		{
+			name: "after",
+			filters: []RequestFilter{
+				named("users", FilterOrder{After: []string{"sessions"}}),
+				named("sessions", FilterOrder{After: []string{"cookies"}}),
+				named("cookies", FilterOrder{}),
+			},
+			want: "cookies,sessions,users",
+		},
+		{
+			name: "before",
+			filters: []RequestFilter{
+				named("sessions", FilterOrder{}),
+				named("cookies", FilterOrder{Before: []string{"sessions"}}),
+			},
+			want: "cookies,sessions",
+		},
+		{
+			name: "priority",
+			filters: []RequestFilter{
+				named("a", FilterOrder{Priority: 10}),
+				named("b", FilterOrder{Priority: -10}),
+				&unorderedFilter{id: "c"},
+			},
+			want: "b,c,a",
+		},
+		{
+			name: "priority ties are broken by registration order",
+			filters: []RequestFilter{
+				named("a", FilterOrder{Priority: 5}),
+				named("b", FilterOrder{Priority: 1}),
+				named("c", FilterOrder{Priority: 5}),
+				named("d", FilterOrder{Priority: 1}),
+			},
+			want: "b,d,a,c",
+		},
+		{
+			name: "constraints take precedence over priority",
+			filters: []RequestFilter{
+				named("a", FilterOrder{Priority: -10, After: []string{"b"}}),
+				named("b", FilterOrder{Priority: 10}),
+				named("c", FilterOrder{}),
+			},
+			want: "c,b,a",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			sorted, err := sortFilters(g.filters)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := filterIDs(sorted); got != g.want {
+				t.Errorf("got order %q, want %q", got, g.want)
+			}
+		})
+	}
+}
+
+func TestSortFiltersErrors(t *testing.T) {
+	grid := []struct {
+		name    string
+		filters []RequestFilter
+		want    string
+	}{
+		{
+			name: "cycle",
+			filters: []RequestFilter{
+				named("a", FilterOrder{After: []string{"b"}}),
+				named("b", FilterOrder{After: []string{"a"}}),
+				named("c", FilterOrder{}),
+			},
+			want: `there is a cycle between "a" (*components.testFilter), "b" (*components.testFilter)`,
+		},
+		{
+			name: "cycle through before",
+			filters: []RequestFilter{
+				named("a", FilterOrder{Before: []string{"b"}}),
+				named("b", FilterOrder{Before: []string{"a"}}),
+			},
+			want: "there is a cycle",
+		},
+		{
+			name:    "unknown after",
+			filters: []RequestFilter{named("a", FilterOrder{After: []string{"missing"}})},
+			want:    `must run after filter "missing", but no filter with that name is registered`,
+		},
+		{
+			name:    "unknown before",
+			filters: []RequestFilter{named("a", FilterOrder{Before: []string{"missing"}})},
+			want:    `must run before filter "missing", but no filter with that name is registered`,
+		},
+		{
+			name:    "duplicate name",
+			filters: []RequestFilter{named("a", FilterOrder{}), named("a", FilterOrder{})},
+			want:    `filter name "a" is used by both`,
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			_, err := sortFilters(g.filters)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), g.want) {
+				t.Errorf("got error %q, want it to contain %q", err.Error(), g.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,11 @@ package github
 import (
 	"context"
 	"crypto/rsa"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -19,29 +23,116 @@ import (
 // They can then use that app token to generate a user-scoped token (technically, an installation scoped token)
 // https://docs.github.com/en/developers/apps/building-github-apps/authenticating-with-github-apps#authenticating-as-an-installation
 
+// authForInstallation returns a client that acts as the installation, with a token limited by options.
+// Token sources are cached by installation and scope, so that a token is reused until it expires.
 func (c *Component) authForInstallation(ctx context.Context, installationID int64, options github.InstallationTokenOptions) (*Client, error) {
-	// TODO: Cache userTokenSource
+	key, err := tokenScopeKey(installationID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 
-	ts := &userTokenSource{
-		installationID: installationID,
-		component:      c,
-		options:        options,
+	c.tokenSourcesMutex.Lock()
+	if now.After(c.nextTokenSourceSweep) {
+		c.evictIdleTokenSources(now)
+		c.nextTokenSourceSweep = now.Add(tokenSourceSweepInterval)
 	}
-	tc := oauth2.NewClient(ctx, ts)
+	ts := c.tokenSources[key]
+	if ts == nil {
+		ts = &userTokenSource{
+			installationID: installationID,
+			component:      c,
+			options:        options,
+		}
+		ts.client = c.newGitHubClient(ts, strconv.FormatInt(installationID, 10), key)
+		c.tokenSources[key] = ts
+	}
+	ts.lastUsed = now
+	c.tokenSourcesMutex.Unlock()
 
-	ghClient := github.NewClient(tc)
 	return &Client{
-		Client: ghClient,
+		Client:         ts.client,
+		InstallationID: installationID,
+		tokenSource:    ts,
 	}, nil
 }
 
+const (
+	// tokenSourceIdleTimeout is how long we keep a token source that is not being used.
+	// Installation tokens are valid for an hour, so by then any token it holds has expired.
+	tokenSourceIdleTimeout = time.Hour
+
+	// tokenSourceSweepInterval is how often we look for idle token sources.
+	tokenSourceSweepInterval = time.Minute
+)
+
+// evictIdleTokenSources removes token sources that have not been used recently, so that the cache does not grow
+// with every combination of options we have seen.  tokenSourcesMutex must be held.
+func (c *Component) evictIdleTokenSources(now time.Time) {
+	for key, ts := range c.tokenSources {
+		if now.Sub(ts.lastUsed) > tokenSourceIdleTimeout {
+			delete(c.tokenSources, key)
+		}
+	}
+}
+
+// newGitHubClient builds a github client that authenticates with ts.
+// Requests are tracked against the rate limit for installation, and responses are cached for the token scope.
+// The caller must hold tokenSourcesMutex, except during construction.
+func (c *Component) newGitHubClient(ts oauth2.TokenSource, installation string, scope string) *github.Client {
+	limiter := c.rateLimiters[installation]
+	if limiter == nil {
+		limiter = &rateLimiter{installation: installation}
+		c.rateLimiters[installation] = limiter
+	}
+
+	httpClient := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: oauth2.ReuseTokenSource(nil, ts),
+			Base: &transport{
+				base:         http.DefaultTransport,
+				installation: installation,
+				scope:        scope,
+				limiter:      limiter,
+				cache:        c.responses,
+			},
+		},
+	}
+	return github.NewClient(httpClient)
+}
+
+// tokenScopeKey returns the cache key for tokens for the installation with the options.
+// Options that differ only in the order of repositories have the same key.
+func tokenScopeKey(installationID int64, options github.InstallationTokenOptions) (string, error) {
+	options.Repositories = append([]string(nil), options.Repositories...)
+	sort.Strings(options.Repositories)
+	options.RepositoryIDs = append([]int64(nil), options.RepositoryIDs...)
+	sort.Slice(options.RepositoryIDs, func(i, j int) bool { return options.RepositoryIDs[i] < options.RepositoryIDs[j] })
+
+	b, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("error building token cache key: %w", err)
+	}
+	return strconv.FormatInt(installationID, 10) + ":" + string(b), nil
+}
+
 type userTokenSource struct {
 	component      *Component
 	installationID int64
 	options        github.InstallationTokenOptions
 
+	// client is shared by all the users of the token source
+	client *github.Client
+
+	// lastUsed is when the token source was last returned from authForInstallation; it is guarded by tokenSourcesMutex
+	lastUsed time.Time
+
 	mutex sync.Mutex
 	token *oauth2.Token
+	// permissions and repositories are returned with the token
+	permissions  *github.InstallationPermissions
+	repositories []*github.Repository
 }
 
 var _ oauth2.TokenSource = &userTokenSource{}
@@ -78,23 +169,26 @@ func (t *userTokenSource) Token() (*oauth2.Token, error) {
 		Expiry:      ghToken.GetExpiresAt(),
 	}
 	t.token = token
-	// TODO: Expose Permissions and Repositories?
-	// Permissions  *InstallationPermissions `json:"permissions,omitempty"`
-	// Repositories []*Repository            `json:"repositories,omitempty"`
+	t.permissions = ghToken.GetPermissions()
+	t.repositories = ghToken.Repositories
 
 	return token, nil
 }
 
-func (c *Component) appClient(ctx context.Context) (*github.Client, error) {
-	// TODO: Caching?
-	ts := &appTokenSource{
-		appPrivateKey: c.appPrivateKey,
-		githubAppID:   c.githubAppID,
+// scope returns the permissions and repositories of the current token, creating a token if needed.
+func (t *userTokenSource) scope() (*github.InstallationPermissions, []*github.Repository, error) {
+	if _, err := t.Token(); err != nil {
+		return nil, nil, err
 	}
-	tc := oauth2.NewClient(ctx, ts)
 
-	client := github.NewClient(tc)
-	return client, nil
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.permissions, t.repositories, nil
+}
+
+func (c *Component) appClient(ctx context.Context) (*github.Client, error) {
+	return c.appGitHubClient, nil
 }
 
 type appTokenSource struct {
@@ -4,6 +4,25 @@ import (
 	"github.com/google/go-github/v45/github"
 )
 
+// Client is a GitHub client that acts as an installation of the app.
 type Client struct {
 	*github.Client
+
+	// InstallationID is the id of the installation that the client acts as.
+	InstallationID int64
+
+	tokenSource *userTokenSource
+}
+
+// Permissions returns the permissions granted to the client's token.
+func (c *Client) Permissions() (*github.InstallationPermissions, error) {
+	permissions, _, err := c.tokenSource.scope()
+	return permissions, err
+}
+
+// Repositories returns the repositories that the client's token is limited to;
+// it is empty if the token can access all the repositories of the installation.
+func (c *Client) Repositories() ([]*github.Repository, error) {
+	_, repositories, err := c.tokenSource.scope()
+	return repositories, err
 }
@@ -3,35 +3,178 @@ package github
 import (
 	"context"
 	"crypto/rsa"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/google/go-github/v45/github"
 	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/csrf"
+	"github.com/justinsb/kweb/components/github/pb"
 	"github.com/justinsb/kweb/components/kube/kubeclient"
 	"github.com/justinsb/kweb/templates/scopes"
+	"golang.org/x/oauth2"
+	githuboauth "golang.org/x/oauth2/github"
 )
 
+// Options configures the GitHub App integration.
+type Options struct {
+	// AppID is the ID of the GitHub App.
+	AppID string
+
+	// PrivateKey is the private key of the app, used to authenticate to GitHub.
+	PrivateKey *rsa.PrivateKey
+
+	// AppSlug is the URL-friendly name of the app, used to build the installation URL.
+	AppSlug string
+
+	// ClientID and ClientSecret are the OAuth credentials of the app.
+	// They are used to verify which GitHub user installed the app, when "Request user authorization during installation" is enabled.
+	ClientID     string
+	ClientSecret string
+
+	// WebhookSecret is the secret that GitHub uses to sign webhook deliveries; webhooks are rejected if it is empty.
+	WebhookSecret string
+
+	// SyncInterval is how often we list all the installations of the app, in case we missed a webhook.
+	SyncInterval time.Duration
+
+	// OrgMembershipTTL is how long we remember whether a user is a member of an organization the app is installed on.
+	// Checking membership requires the app to have read access to the organization's members.
+	OrgMembershipTTL time.Duration
+}
+
+// InitDefaults sets the default options.
+func (o *Options) InitDefaults() {
+	o.SyncInterval = time.Hour
+	o.OrgMembershipTTL = 5 * time.Minute
+}
+
 type Component struct {
 	kube *kubeclient.Client
 
 	githubAppID string
 
 	appPrivateKey *rsa.PrivateKey
+
+	appSlug string
+
+	// oauthConfig is used to verify the user in the setup flow; it is nil if the app's OAuth credentials are not configured.
+	oauthConfig *oauth2.Config
+
+	webhookSecret []byte
+
+	syncInterval time.Duration
+
+	installations *kubeclient.Informer[*pb.AppInstallation]
+
+	// appGitHubClient acts as the app itself, for managing installations
+	appGitHubClient *github.Client
+
+	// tokenSources caches the token sources for installations, by tokenScopeKey
+	tokenSourcesMutex sync.Mutex
+	tokenSources      map[string]*userTokenSource
+	// nextTokenSourceSweep is when we next look for idle token sources
+	nextTokenSourceSweep time.Time
+	// rateLimiters tracks the rate limits, which are shared by all the tokens for an installation
+	rateLimiters map[string]*rateLimiter
+
+	// responses holds GET responses for conditional requests, across all tokens
+	responses *responseCache
+
+	// orgMembers caches whether users are members of the organizations the app is installed on
+	orgMembers *orgMembershipCache
 }
 
-func New(kube *kubeclient.Client, githubAppID string, appPrivateKey *rsa.PrivateKey) (*Component, error) {
-	return &Component{
+// indexGithubAccount indexes installations by the ids of the GitHub accounts that can use them:
+// the account the app is installed on, and the authorized users.
+const indexGithubAccount = "githubAccount"
+
+// indexAccountType indexes installations by the type of the account they are on (User or Organization).
+const indexAccountType = "accountType"
+
+func New(kube *kubeclient.Client, opt Options) (*Component, error) {
+	var defaults Options
+	defaults.InitDefaults()
+	if opt.SyncInterval == 0 {
+		opt.SyncInterval = defaults.SyncInterval
+	}
+	if opt.OrgMembershipTTL == 0 {
+		opt.OrgMembershipTTL = defaults.OrgMembershipTTL
+	}
+	if opt.AppID == "" {
+		return nil, fmt.Errorf("github app id is required")
+	}
+	if opt.PrivateKey == nil {
+		return nil, fmt.Errorf("github app private key is required")
+	}
+
+	c := &Component{
 		kube:          kube,
-		githubAppID:   githubAppID,
-		appPrivateKey: appPrivateKey,
-	}, nil
+		githubAppID:   opt.AppID,
+		appPrivateKey: opt.PrivateKey,
+		appSlug:       opt.AppSlug,
+		webhookSecret: []byte(opt.WebhookSecret),
+		syncInterval:  opt.SyncInterval,
+		installations: kubeclient.CachedClient(kube, &pb.AppInstallation{}),
+		tokenSources:  make(map[string]*userTokenSource),
+		rateLimiters:  make(map[string]*rateLimiter),
+		responses:     newResponseCache(maxCachedBytes),
+		orgMembers:    newOrgMembershipCache(opt.OrgMembershipTTL),
+	}
+	c.appGitHubClient = c.newGitHubClient(&appTokenSource{
+		appPrivateKey: c.appPrivateKey,
+		githubAppID:   c.githubAppID,
+	}, "app", "app")
+	if opt.ClientID != "" {
+		c.oauthConfig = &oauth2.Config{
+			ClientID:     opt.ClientID,
+			ClientSecret: opt.ClientSecret,
+			Endpoint:     githuboauth.Endpoint,
+		}
+	}
+
+	c.installations.AddIndex(indexGithubAccount, func(installation *pb.AppInstallation) []string {
+		values := []string{strconv.FormatInt(installation.GetSpec().GetAccount().GetId(), 10)}
+		for _, user := range installation.GetSpec().GetAuthorizedUsers() {
+			values = append(values, strconv.FormatInt(user.GetId(), 10))
+		}
+		return values
+	})
+	c.installations.AddIndex(indexAccountType, func(installation *pb.AppInstallation) []string {
+		return []string{installation.GetSpec().GetAccount().GetType()}
+	})
+
+	return c, nil
 }
 
 func (c *Component) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
-	mux.HandleFunc("/_ghapp/", s.ServeHTTP(c.doEntryPoint))
+	routes := s.Routes()
+	if err := routes.GET("/_ghapp/", c.doEntryPoint); err != nil {
+		return err
+	}
+	if err := routes.GET("/_ghapp/install", c.startInstall); err != nil {
+		return err
+	}
+	if err := routes.POST("/_ghapp/select", c.selectInstallation); err != nil {
+		return err
+	}
+	if err := routes.POST(webhookPath, c.serveWebhook); err != nil {
+		return err
+	}
+	// Webhooks are authenticated by their signature, not cookies
+	csrf.Exempt(s, webhookPath)
 	return nil
 }
 
-var _ components.RequestFilter = &Component{}
+var _ components.OrderedRequestFilter = &Component{}
+
+func (c *Component) FilterOrder() components.FilterOrder {
+	// We look up installations for the current user
+	return components.FilterOrder{Name: "github", After: []string{"users"}}
+}
 
 func (c *Component) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
 	ctx = context.WithValue(ctx, contextKeyRequest, &requestInfo{component: c})
@@ -40,4 +183,19 @@ func (c *Component) ProcessRequest(ctx context.Context, req *components.Request,
 }
 
 func (c *Component) AddToScope(ctx context.Context, scope *scopes.Scope) {
+	// The installations the user can use, and the one they have selected, e.g. for an account switcher
+	scope.Values["githubInstallations"] = scopes.Value{
+		Function: func() (any, error) {
+			return ListInstallations(ctx)
+		},
+	}
+	scope.Values["githubInstallation"] = scopes.Value{
+		Function: func() (any, error) {
+			requestInfo := getRequestInfo(ctx)
+			if requestInfo == nil {
+				return nil, nil
+			}
+			return requestInfo.SelectedInstallation(ctx)
+		},
+	}
 }
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/justinsb/kweb/components/github/pb"
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/kube/kubecontroller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// AddControllers adds the background tasks that keep the AppInstallation objects in sync with GitHub:
+// a periodic listing of the installations (which catches any webhooks we missed), and a controller that updates the status of each installation
+// (and deletes it if the app has been uninstalled).
+func (c *Component) AddControllers(mgr *kubecontroller.Manager) error {
+	mgr.AddPeriodic("github-installations-sync", c.syncInterval, c.SyncInstallations)
+	mgr.Add(kubecontroller.New(c.kube, &pb.AppInstallation{}, kubecontroller.Options{Name: "appinstallations"}, c.reconcileInstallation))
+	return nil
+}
+
+func (c *Component) reconcileInstallation(ctx context.Context, id types.NamespacedName, obj *pb.AppInstallation) (kubecontroller.Result, error) {
+	if obj == nil {
+		return kubecontroller.Result{}, nil
+	}
+
+	appClient, err := c.appClient(ctx)
+	if err != nil {
+		return kubecontroller.Result{}, err
+	}
+
+	installationID := obj.GetSpec().GetId()
+	installation, _, err := appClient.Apps.GetInstallation(ctx, installationID)
+	if err != nil {
+		var errorResponse *github.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusNotFound {
+			klog.Infof("github installation %d not found; deleting %v", installationID, id)
+			return kubecontroller.Result{}, c.deleteInstallation(ctx, obj)
+		}
+		return kubecontroller.Result{}, fmt.Errorf("error getting installation %d: %w", installationID, err)
+	}
+
+	if err := c.updateInstallationStatus(ctx, installation, obj); err != nil {
+		return kubecontroller.Result{}, err
+	}
+	return kubecontroller.Result{}, nil
+}
+
+// deleteInstallation deletes the AppInstallation object, unless it has been recreated since we read it.
+func (c *Component) deleteInstallation(ctx context.Context, obj *pb.AppInstallation) error {
+	uid := types.UID(obj.GetMetadata().GetUid())
+	id := types.NamespacedName{Namespace: obj.GetMetadata().GetNamespace(), Name: obj.GetMetadata().GetName()}
+	opt := kubeclient.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	}
+	if err := c.kube.Delete(ctx, id, obj, opt); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			return nil
+		}
+		return fmt.Errorf("error deleting installation %v: %w", id, err)
+	}
+	return nil
+}
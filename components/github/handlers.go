@@ -2,25 +2,240 @@ package github
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/google/go-github/v45/github"
 	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/github/pb"
+	"github.com/justinsb/kweb/components/login/providers/loginwithgithub"
+	"github.com/justinsb/kweb/components/users"
+	userapi "github.com/justinsb/kweb/components/users/pb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 )
 
-func (p *Component) doEntryPoint(ctx context.Context, req *components.Request) (components.Response, error) {
-	err := req.ParseForm()
+// startInstall sends the user to GitHub to install the app, remembering a state value that we check when GitHub redirects back.
+func (c *Component) startInstall(ctx context.Context, req *components.Request) (components.Response, error) {
+	if users.GetUser(ctx) == nil {
+		return nil, components.NewHTTPError(http.StatusUnauthorized, "you must be logged in to install the app", nil)
+	}
+	if c.appSlug == "" {
+		return nil, components.NewHTTPError(http.StatusNotFound, "app installation is not configured", fmt.Errorf("github app slug not set"))
+	}
+
+	state := &pb.SetupState{State: randomState()}
+	req.Session.Set(state)
+
+	installURL := "https://github.com/apps/" + url.PathEscape(c.appSlug) + "/installations/new?state=" + url.QueryEscape(state.State)
+	return components.RedirectResponse(installURL), nil
+}
+
+// doEntryPoint handles the redirect from GitHub after the app is installed (the "Setup URL" of the app).
+// We verify which GitHub user did the install, and link the installation to the logged-in user:
+// installations on the user's own GitHub account are found through their linked account,
+// other installations (e.g. on organizations) record the GitHub user as authorized.
+func (c *Component) doEntryPoint(ctx context.Context, req *components.Request) (components.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid form", err)
+	}
+
+	user := users.GetUser(ctx)
+	if user == nil {
+		return nil, components.NewHTTPError(http.StatusUnauthorized, "you must be logged in to install the app", nil)
+	}
+
+	// The state is only valid for one installation
+	sessionState := &pb.SetupState{}
+	stateMatches := req.Session.Get(sessionState) && sessionState.GetState() != "" && req.FormValue("state") == sessionState.GetState()
+	req.Session.Clear(&pb.SetupState{})
+
+	setupAction := req.FormValue("setup_action")
+	if setupAction == "request" {
+		// An organization owner must approve the installation; we will be told about it by a webhook
+		klog.Infof("github installation was requested by user %q", user.GetMetadata().GetName())
+		return components.RedirectResponse("/"), nil
+	}
+
+	installationID, err := strconv.ParseInt(req.FormValue("installation_id"), 10, 64)
 	if err != nil {
-		return components.ErrorResponse(http.StatusBadRequest), err
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid installation_id", err)
+	}
+
+	appClient, err := c.appClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	installation, _, err := appClient.Apps.GetInstallation(ctx, installationID)
+	if err != nil {
+		var errorResponse *github.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusNotFound {
+			return nil, components.NewHTTPError(http.StatusBadRequest, "installation not found", err)
+		}
+		return nil, fmt.Errorf("error getting installation %d: %w", installationID, err)
+	}
+
+	// Don't wait for the webhook (or the periodic sync)
+	applied, err := c.applyInstallation(ctx, installation)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.updateInstallationStatus(ctx, installation, applied); err != nil {
+		return nil, err
 	}
 
 	code := req.FormValue("code")
-	installationID := req.FormValue("installation_id")
-	setupAction := req.FormValue("setup_action")
+	if code == "" || c.oauthConfig == nil {
+		// We cannot tell who installed the app, but installations on an account the user has linked are already theirs
+		if !hasLinkedAccount(user, installation.GetAccount().GetID()) {
+			return nil, components.NewHTTPError(http.StatusForbidden, "could not verify who installed the app; please authorize the app when installing it", nil)
+		}
+		return components.RedirectResponse("/"), nil
+	}
+
+	githubUser, err := c.verifyInstallationAccess(ctx, code, installationID)
+	if err != nil {
+		return nil, err
+	}
 
-	klog.Infof("code = %q", code)
-	klog.Infof("installation_id = %q", installationID)
-	klog.Infof("setup_action = %q", setupAction)
+	if !hasLinkedAccount(user, githubUser.GetID()) {
+		// Otherwise someone could send the user a link that links the sender's GitHub account to the user
+		if !stateMatches {
+			return nil, components.NewHTTPError(http.StatusBadRequest, "installation session did not match; please install the app again", nil)
+		}
+		linkedAccount := &userapi.LinkedAccount{
+			ProviderID:       loginwithgithub.ProviderID,
+			ProviderUserID:   strconv.FormatInt(githubUser.GetID(), 10),
+			ProviderUserName: githubUser.GetLogin(),
+		}
+		if _, err := users.GetComponent(ctx).LinkAccount(ctx, user, linkedAccount); err != nil {
+			if apierrors.IsConflict(err) {
+				return nil, components.NewHTTPError(http.StatusConflict, "your GitHub account is linked to another user", err)
+			}
+			return nil, err
+		}
+	}
+
+	if installation.GetAccount().GetID() != githubUser.GetID() {
+		if err := c.authorizeUser(ctx, applied, githubUser); err != nil {
+			return nil, err
+		}
+	}
 
 	return components.RedirectResponse("/"), nil
 }
+
+// verifyInstallationAccess exchanges the code from the setup flow for a user token,
+// returning the GitHub user if they can access the installation.
+func (c *Component) verifyInstallationAccess(ctx context.Context, code string, installationID int64) (*github.User, error) {
+	token, err := c.oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, components.NewHTTPError(http.StatusBadRequest, "could not verify the installation; please try again", err)
+	}
+	userClient := github.NewClient(c.oauthConfig.Client(ctx, token))
+
+	githubUser, _, err := userClient.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("error getting github user: %w", err)
+	}
+
+	var opts github.ListOptions
+	opts.PerPage = 100
+	for {
+		installations, response, err := userClient.Apps.ListUserInstallations(ctx, &opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing installations for github user %q: %w", githubUser.GetLogin(), err)
+		}
+		for _, installation := range installations {
+			if installation.GetID() == installationID {
+				return githubUser, nil
+			}
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		opts.Page = response.NextPage
+	}
+
+	return nil, components.NewHTTPError(http.StatusForbidden, "you do not have access to the installation", fmt.Errorf("github user %q cannot access installation %d", githubUser.GetLogin(), installationID))
+}
+
+// authorizeUser records that the GitHub user can use the installation.
+func (c *Component) authorizeUser(ctx context.Context, obj *pb.AppInstallation, githubUser *github.User) error {
+	id := types.NamespacedName{Namespace: obj.GetMetadata().GetNamespace(), Name: obj.GetMetadata().GetName()}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		installation := &pb.AppInstallation{}
+		if err := c.kube.Get(ctx, id, installation); err != nil {
+			return err
+		}
+		for _, authorized := range installation.GetSpec().GetAuthorizedUsers() {
+			if authorized.GetId() == githubUser.GetID() {
+				return nil
+			}
+		}
+		installation.Spec.AuthorizedUsers = append(installation.Spec.AuthorizedUsers, &pb.GithubAccount{
+			Id:    githubUser.GetID(),
+			Login: githubUser.GetLogin(),
+		})
+		if err := c.kube.Update(ctx, installation); err != nil {
+			return err
+		}
+		klog.Infof("authorized github user %q for installation %v", githubUser.GetLogin(), id)
+		return nil
+	})
+}
+
+// selectInstallation remembers the installation that the user has chosen (e.g. from an account switcher), for GetClient.
+func (c *Component) selectInstallation(ctx context.Context, req *components.Request) (components.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid form", err)
+	}
+
+	installationID, err := strconv.ParseInt(req.FormValue("installation_id"), 10, 64)
+	if err != nil {
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid installation_id", err)
+	}
+
+	installations, err := ListInstallations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, installation := range installations {
+		if installation.GetSpec().GetId() == installationID {
+			found = true
+		}
+	}
+	if !found {
+		return nil, components.NewHTTPError(http.StatusNotFound, "installation not found", nil)
+	}
+
+	req.Session.Set(&pb.InstallationSelection{InstallationId: installationID})
+
+	return components.RedirectResponse(components.LocalRedirect(req.FormValue("redirect"))), nil
+}
+
+// hasLinkedAccount returns true if the user has linked the GitHub account.
+func hasLinkedAccount(user *userapi.User, githubAccountID int64) bool {
+	for _, account := range users.LinkedAccounts(user, loginwithgithub.ProviderID) {
+		if account.GetProviderUserID() == strconv.FormatInt(githubAccountID, 10) {
+			return true
+		}
+	}
+	return false
+}
+
+func randomState() string {
+	b := make([]byte, 32)
+	if _, err := cryptorand.Read(b); err != nil {
+		klog.Fatalf("building random state: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
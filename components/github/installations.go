@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/google/go-github/v45/github"
 	"github.com/justinsb/kweb/components/github/pb"
@@ -15,13 +16,14 @@ import (
 	"k8s.io/klog/v2"
 )
 
-func buildInstallationKey(userID string, installationID int64) types.NamespacedName {
-	return types.NamespacedName{
-		Namespace: "user-" + userID,
-		Name:      strconv.FormatInt(installationID, 10),
-	}
-}
+// fieldManager is the field manager for our writes to kube objects.
+const fieldManager = "kweb-github"
+
+// conditionReady is the condition type that reports whether an installation is usable.
+const conditionReady = "Ready"
 
+// SyncInstallations creates or updates an AppInstallation object for each installation of the app;
+// the status of each installation is reported by the appinstallations controller.
 func (c *Component) SyncInstallations(ctx context.Context) error {
 	appClient, err := c.appClient(ctx)
 	if err != nil {
@@ -38,23 +40,8 @@ func (c *Component) SyncInstallations(ctx context.Context) error {
 			return fmt.Errorf("error listing installations: %w", err)
 		}
 		for _, installation := range installations {
-			klog.Infof("github installation: %v", debug.JSON(installation))
-			githubUserID := installation.GetAccount().GetID()
-
-			kubeInstallation := &pb.AppInstallation{}
-			kube.InitObject(kubeInstallation, types.NamespacedName{Namespace: fmt.Sprintf("github-%d", githubUserID), Name: strconv.FormatInt(installation.GetID(), 10)})
-			kubeInstallation.Spec = &pb.AppInstallationSpec{
-				Id: installation.GetID(),
-				Account: &pb.GithubAccount{
-					Id:    installation.GetAccount().GetID(),
-					Login: installation.GetAccount().GetLogin(),
-				},
-			}
-			klog.Infof("installation is %v", prototext.Format(kubeInstallation))
-
-			var applied kube.Object
-			if err := c.kube.Apply(ctx, kubeInstallation, kubeclient.ApplyOptions{FieldManager: "kweb-github"}, applied); err != nil {
-				return fmt.Errorf("error applying installation object: %w", err)
+			if _, err := c.applyInstallation(ctx, installation); err != nil {
+				return err
 			}
 		}
 		if response.NextPage == 0 {
@@ -65,3 +52,67 @@ func (c *Component) SyncInstallations(ctx context.Context) error {
 
 	return nil
 }
+
+// installationKey returns the key of the AppInstallation object for an installation.
+func installationKey(installation *github.Installation) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: fmt.Sprintf("github-%d", installation.GetAccount().GetID()),
+		Name:      strconv.FormatInt(installation.GetID(), 10),
+	}
+}
+
+// applyInstallation creates or updates the AppInstallation object for an installation, returning the applied object.
+func (c *Component) applyInstallation(ctx context.Context, installation *github.Installation) (*pb.AppInstallation, error) {
+	klog.Infof("github installation: %v", debug.JSON(installation))
+
+	kubeInstallation := &pb.AppInstallation{}
+	kube.InitObject(kubeInstallation, installationKey(installation))
+	kubeInstallation.Spec = &pb.AppInstallationSpec{
+		Id: installation.GetID(),
+		Account: &pb.GithubAccount{
+			Id:    installation.GetAccount().GetID(),
+			Login: installation.GetAccount().GetLogin(),
+			Type:  installation.GetAccount().GetType(),
+		},
+		RepositorySelection: installation.GetRepositorySelection(),
+	}
+	klog.Infof("installation is %v", prototext.Format(kubeInstallation))
+
+	applied := &pb.AppInstallation{}
+	if err := c.kube.Apply(ctx, kubeInstallation, kubeclient.ApplyOptions{FieldManager: fieldManager}, applied); err != nil {
+		return nil, fmt.Errorf("error applying installation object: %w", err)
+	}
+	return applied, nil
+}
+
+// updateInstallationStatus reports the state of the installation on GitHub in the status of the kube object.
+func (c *Component) updateInstallationStatus(ctx context.Context, installation *github.Installation, obj *pb.AppInstallation) error {
+	generation := obj.GetMetadata().GetGeneration()
+
+	ready := &kube.Condition{
+		Type:               conditionReady,
+		Status:             kube.ConditionTrue,
+		Reason:             "Synced",
+		ObservedGeneration: generation,
+	}
+	if installation.SuspendedAt != nil {
+		ready.Status = kube.ConditionFalse
+		ready.Reason = "Suspended"
+		ready.Message = fmt.Sprintf("installation was suspended at %v", installation.GetSuspendedAt().Format(time.RFC3339))
+	}
+
+	status := obj.GetStatus()
+	if status == nil {
+		status = &pb.AppInstallationStatus{}
+	}
+	status.ObservedGeneration = generation
+	kube.SetCondition(&status.Conditions, ready)
+
+	update := &pb.AppInstallation{}
+	kube.InitObject(update, types.NamespacedName{Namespace: obj.GetMetadata().GetNamespace(), Name: obj.GetMetadata().GetName()})
+	update.Status = status
+	if err := c.kube.ApplyStatus(ctx, update, kubeclient.ApplyOptions{FieldManager: fieldManager}, nil); err != nil {
+		return fmt.Errorf("error updating installation status: %w", err)
+	}
+	return nil
+}
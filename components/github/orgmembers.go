@@ -0,0 +1,88 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/justinsb/kweb/components/github/pb"
+)
+
+// accountTypeOrganization is the type of GitHub accounts that are organizations.
+const accountTypeOrganization = "Organization"
+
+// orgMembershipKey identifies a GitHub user (by id) in the organization of an installation.
+type orgMembershipKey struct {
+	installationID int64
+	userID         int64
+}
+
+type orgMembership struct {
+	member  bool
+	expires time.Time
+}
+
+// orgMembershipCache remembers whether users are members of organizations, so we don't ask GitHub on every request.
+type orgMembershipCache struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[orgMembershipKey]orgMembership
+}
+
+func newOrgMembershipCache(ttl time.Duration) *orgMembershipCache {
+	return &orgMembershipCache{
+		ttl:     ttl,
+		entries: make(map[orgMembershipKey]orgMembership),
+	}
+}
+
+// get returns the cached membership, and false if it is not cached (or has expired).
+func (c *orgMembershipCache) get(key orgMembershipKey, now time.Time) (bool, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, found := c.entries[key]
+	if !found || now.After(entry.expires) {
+		return false, false
+	}
+	return entry.member, true
+}
+
+func (c *orgMembershipCache) put(key orgMembershipKey, member bool, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Remove expired entries, so the cache doesn't grow with every user we have seen
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = orgMembership{member: member, expires: now.Add(c.ttl)}
+}
+
+// isOrgMember returns true if the GitHub user is a member of the organization that the app is installed on.
+func (c *Component) isOrgMember(ctx context.Context, installation *pb.AppInstallation, userID int64, login string) (bool, error) {
+	key := orgMembershipKey{installationID: installation.GetSpec().GetId(), userID: userID}
+	now := time.Now()
+	if member, found := c.orgMembers.get(key, now); found {
+		return member, nil
+	}
+
+	client, err := c.authForInstallation(ctx, key.installationID, github.InstallationTokenOptions{})
+	if err != nil {
+		return false, err
+	}
+	org := installation.GetSpec().GetAccount().GetLogin()
+	member, _, err := client.Organizations.IsMember(ctx, org, login)
+	if err != nil {
+		// We also remember failures (e.g. if the app cannot read the members), so we don't retry on every request
+		c.orgMembers.put(key, false, now)
+		return false, fmt.Errorf("error checking if %q is a member of organization %q: %w", login, org, err)
+	}
+	c.orgMembers.put(key, member, now)
+	return member, nil
+}
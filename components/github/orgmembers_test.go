@@ -0,0 +1,34 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrgMembershipCache(t *testing.T) {
+	cache := newOrgMembershipCache(time.Minute)
+	now := time.Now()
+	key := orgMembershipKey{installationID: 1, userID: 2}
+
+	if _, found := cache.get(key, now); found {
+		t.Fatalf("expected empty cache")
+	}
+
+	cache.put(key, true, now)
+	if member, found := cache.get(key, now.Add(30*time.Second)); !found || !member {
+		t.Errorf("expected cached membership, got member=%v found=%v", member, found)
+	}
+	if _, found := cache.get(orgMembershipKey{installationID: 1, userID: 3}, now); found {
+		t.Errorf("membership should be cached per user")
+	}
+
+	if _, found := cache.get(key, now.Add(2*time.Minute)); found {
+		t.Errorf("expected membership to expire")
+	}
+
+	// Expired entries are removed when we add to the cache
+	cache.put(orgMembershipKey{installationID: 1, userID: 3}, false, now.Add(2*time.Minute))
+	if len(cache.entries) != 1 {
+		t.Errorf("expected expired entries to be removed, have %d entries", len(cache.entries))
+	}
+}
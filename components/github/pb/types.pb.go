@@ -26,9 +26,10 @@ type AppInstallation struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Typemeta *kube.TypeMeta       `protobuf:"bytes,1,opt,name=typemeta,proto3" json:"typemeta,omitempty"`
-	Metadata *kube.ObjectMeta     `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	Spec     *AppInstallationSpec `protobuf:"bytes,3,opt,name=spec,proto3" json:"spec,omitempty"`
+	Typemeta *kube.TypeMeta         `protobuf:"bytes,1,opt,name=typemeta,proto3" json:"typemeta,omitempty"`
+	Metadata *kube.ObjectMeta       `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Spec     *AppInstallationSpec   `protobuf:"bytes,3,opt,name=spec,proto3" json:"spec,omitempty"`
+	Status   *AppInstallationStatus `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
 }
 
 func (x *AppInstallation) Reset() {
@@ -84,6 +85,13 @@ func (x *AppInstallation) GetSpec() *AppInstallationSpec {
 	return nil
 }
 
+func (x *AppInstallation) GetStatus() *AppInstallationStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
 type AppInstallationSpec struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -92,6 +100,11 @@ type AppInstallationSpec struct {
 	// The capitilization isn't normal proto, but it avoids name mangling
 	Id      int64          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	Account *GithubAccount `protobuf:"bytes,3,opt,name=account,proto3" json:"account,omitempty"`
+	// Whether the app can access all the repositories of the account ("all"), or only those chosen ("selected").
+	RepositorySelection string `protobuf:"bytes,4,opt,name=repository_selection,json=repositorySelection,proto3" json:"repository_selection,omitempty"`
+	// GitHub users (other than the account itself) who have shown that they can access the installation,
+	// by authorizing the app in the setup flow; kweb users with these linked accounts can use the installation.
+	AuthorizedUsers []*GithubAccount `protobuf:"bytes,5,rep,name=authorized_users,json=authorizedUsers,proto3" json:"authorized_users,omitempty"`
 }
 
 func (x *AppInstallationSpec) Reset() {
@@ -140,6 +153,77 @@ func (x *AppInstallationSpec) GetAccount() *GithubAccount {
 	return nil
 }
 
+func (x *AppInstallationSpec) GetRepositorySelection() string {
+	if x != nil {
+		return x.RepositorySelection
+	}
+	return ""
+}
+
+func (x *AppInstallationSpec) GetAuthorizedUsers() []*GithubAccount {
+	if x != nil {
+		return x.AuthorizedUsers
+	}
+	return nil
+}
+
+type AppInstallationStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The metadata.generation of the spec that was last synced.
+	ObservedGeneration int64 `protobuf:"varint,1,opt,name=observed_generation,json=observedGeneration,proto3" json:"observed_generation,omitempty"`
+	// The state of the installation; Ready is True if the installation is active on GitHub.
+	Conditions []*kube.Condition `protobuf:"bytes,2,rep,name=conditions,proto3" json:"conditions,omitempty"`
+}
+
+func (x *AppInstallationStatus) Reset() {
+	*x = AppInstallationStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_github_pb_types_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppInstallationStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppInstallationStatus) ProtoMessage() {}
+
+func (x *AppInstallationStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_components_github_pb_types_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppInstallationStatus.ProtoReflect.Descriptor instead.
+func (*AppInstallationStatus) Descriptor() ([]byte, []int) {
+	return file_components_github_pb_types_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AppInstallationStatus) GetObservedGeneration() int64 {
+	if x != nil {
+		return x.ObservedGeneration
+	}
+	return 0
+}
+
+func (x *AppInstallationStatus) GetConditions() []*kube.Condition {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
 type GithubAccount struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -147,12 +231,14 @@ type GithubAccount struct {
 
 	Id    int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	Login string `protobuf:"bytes,2,opt,name=login,proto3" json:"login,omitempty"`
+	// The type of the account: "User" or "Organization".
+	Type string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
 }
 
 func (x *GithubAccount) Reset() {
 	*x = GithubAccount{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_components_github_pb_types_proto_msgTypes[2]
+		mi := &file_components_github_pb_types_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -165,7 +251,7 @@ func (x *GithubAccount) String() string {
 func (*GithubAccount) ProtoMessage() {}
 
 func (x *GithubAccount) ProtoReflect() protoreflect.Message {
-	mi := &file_components_github_pb_types_proto_msgTypes[2]
+	mi := &file_components_github_pb_types_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -178,7 +264,7 @@ func (x *GithubAccount) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GithubAccount.ProtoReflect.Descriptor instead.
 func (*GithubAccount) Descriptor() ([]byte, []int) {
-	return file_components_github_pb_types_proto_rawDescGZIP(), []int{2}
+	return file_components_github_pb_types_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *GithubAccount) GetId() int64 {
@@ -195,6 +281,109 @@ func (x *GithubAccount) GetLogin() string {
 	return ""
 }
 
+func (x *GithubAccount) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+// SetupState is stored in the session while the user installs the app, and checked when GitHub redirects back to us.
+type SetupState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	State string `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+}
+
+func (x *SetupState) Reset() {
+	*x = SetupState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_github_pb_types_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetupState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetupState) ProtoMessage() {}
+
+func (x *SetupState) ProtoReflect() protoreflect.Message {
+	mi := &file_components_github_pb_types_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetupState.ProtoReflect.Descriptor instead.
+func (*SetupState) Descriptor() ([]byte, []int) {
+	return file_components_github_pb_types_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SetupState) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+// InstallationSelection is stored in the session, to remember which installation the user has chosen.
+type InstallationSelection struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstallationId int64 `protobuf:"varint,1,opt,name=installation_id,json=installationId,proto3" json:"installation_id,omitempty"`
+}
+
+func (x *InstallationSelection) Reset() {
+	*x = InstallationSelection{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_github_pb_types_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InstallationSelection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallationSelection) ProtoMessage() {}
+
+func (x *InstallationSelection) ProtoReflect() protoreflect.Message {
+	mi := &file_components_github_pb_types_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallationSelection.ProtoReflect.Descriptor instead.
+func (*InstallationSelection) Descriptor() ([]byte, []int) {
+	return file_components_github_pb_types_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *InstallationSelection) GetInstallationId() int64 {
+	if x != nil {
+		return x.InstallationId
+	}
+	return 0
+}
+
 var File_components_github_pb_types_proto protoreflect.FileDescriptor
 
 var file_components_github_pb_types_proto_rawDesc = []byte{
@@ -202,7 +391,7 @@ var file_components_github_pb_types_proto_rawDesc = []byte{
 	0x68, 0x75, 0x62, 0x2f, 0x70, 0x62, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x12, 0x02, 0x70, 0x62, 0x1a, 0x1a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
 	0x74, 0x73, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x22, 0xaf, 0x01, 0x0a, 0x0f, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c,
+	0x74, 0x6f, 0x22, 0x8f, 0x03, 0x0a, 0x0f, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c,
 	0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x08, 0x74, 0x79, 0x70, 0x65, 0x6d, 0x65,
 	0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x2e,
 	0x54, 0x79, 0x70, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x52, 0x08, 0x74, 0x79, 0x70, 0x65, 0x6d, 0x65,
@@ -211,27 +400,63 @@ var file_components_github_pb_types_proto_rawDesc = []byte{
 	0x63, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
 	0x12, 0x2b, 0x0a, 0x04, 0x73, 0x70, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
 	0x2e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x52, 0x04, 0x73, 0x70, 0x65, 0x63, 0x3a, 0x15, 0x8a,
-	0xb5, 0x18, 0x11, 0x0a, 0x0f, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x52, 0x0a, 0x13, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74, 0x61,
-	0x6c, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x12, 0x0e, 0x0a, 0x02, 0x69,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2b, 0x0a, 0x07, 0x61,
-	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70,
-	0x62, 0x2e, 0x47, 0x69, 0x74, 0x68, 0x75, 0x62, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52,
-	0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x35, 0x0a, 0x0d, 0x47, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x6f, 0x67,
-	0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x42,
-	0x89, 0x01, 0x0a, 0x06, 0x63, 0x6f, 0x6d, 0x2e, 0x70, 0x62, 0x42, 0x0a, 0x54, 0x79, 0x70, 0x65,
-	0x73, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x73, 0x74, 0x69, 0x6e, 0x73, 0x62, 0x2f, 0x6b, 0x77,
-	0x65, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x67, 0x68,
-	0x61, 0x70, 0x70, 0x2f, 0x70, 0x62, 0xa2, 0x02, 0x03, 0x50, 0x58, 0x58, 0xaa, 0x02, 0x02, 0x50,
-	0x62, 0xca, 0x02, 0x02, 0x50, 0x62, 0xe2, 0x02, 0x0e, 0x50, 0x62, 0x5c, 0x47, 0x50, 0x42, 0x4d,
-	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x02, 0x50, 0x62, 0x82, 0xb5, 0x18, 0x1b,
-	0x0a, 0x0f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x6b, 0x77, 0x65, 0x62, 0x2e, 0x64, 0x65,
-	0x76, 0x12, 0x08, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x52, 0x04, 0x73, 0x70, 0x65, 0x63, 0x12, 0x31, 0x0a,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x3a, 0xc1, 0x01, 0x8a, 0xb5, 0x18, 0xbc, 0x01, 0x0a, 0x0f, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73,
+	0x74, 0x61, 0x6c, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x26, 0x0a, 0x07, 0x41, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x06, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x1a, 0x13, 0x2e, 0x73,
+	0x70, 0x65, 0x63, 0x2e, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x2e, 0x6c, 0x6f, 0x67, 0x69,
+	0x6e, 0x1a, 0x3e, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x06, 0x73, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x1a, 0x2d, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x64,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5b, 0x3f, 0x28, 0x40, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x3d,
+	0x3d, 0x22, 0x52, 0x65, 0x61, 0x64, 0x79, 0x22, 0x29, 0x5d, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x1a, 0x41, 0x28, 0x01, 0x0a, 0x06, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x06, 0x73,
+	0x74, 0x72, 0x69, 0x6e, 0x67, 0x1a, 0x2d, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x63,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5b, 0x3f, 0x28, 0x40, 0x2e, 0x74, 0x79,
+	0x70, 0x65, 0x3d, 0x3d, 0x22, 0x52, 0x65, 0x61, 0x64, 0x79, 0x22, 0x29, 0x5d, 0x2e, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x22, 0xc3, 0x01, 0x0a, 0x13, 0x41, 0x70, 0x70, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6c, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2b, 0x0a, 0x07,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x70, 0x62, 0x2e, 0x47, 0x69, 0x74, 0x68, 0x75, 0x62, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x52, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x14, 0x72, 0x65, 0x70,
+	0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x5f, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74,
+	0x6f, 0x72, 0x79, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3c, 0x0a, 0x10,
+	0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x64, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x73,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x0f, 0x61, 0x75, 0x74, 0x68, 0x6f,
+	0x72, 0x69, 0x7a, 0x65, 0x64, 0x55, 0x73, 0x65, 0x72, 0x73, 0x22, 0x79, 0x0a, 0x15, 0x41, 0x70,
+	0x70, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x2f, 0x0a, 0x13, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x5f,
+	0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x12, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x2e,
+	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x49, 0x0a, 0x0d, 0x47, 0x69, 0x74, 0x68, 0x75, 0x62, 0x41,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x22, 0x22, 0x0a, 0x0a, 0x53, 0x65, 0x74, 0x75, 0x70, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x22, 0x40, 0x0a, 0x15, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x27, 0x0a,
+	0x0f, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x42, 0x89, 0x01, 0x0a, 0x06, 0x63, 0x6f, 0x6d, 0x2e, 0x70,
+	0x62, 0x42, 0x0a, 0x54, 0x79, 0x70, 0x65, 0x73, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a,
+	0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x73, 0x74,
+	0x69, 0x6e, 0x73, 0x62, 0x2f, 0x6b, 0x77, 0x65, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x73, 0x2f, 0x67, 0x68, 0x61, 0x70, 0x70, 0x2f, 0x70, 0x62, 0xa2, 0x02, 0x03,
+	0x50, 0x58, 0x58, 0xaa, 0x02, 0x02, 0x50, 0x62, 0xca, 0x02, 0x02, 0x50, 0x62, 0xe2, 0x02, 0x0e,
+	0x50, 0x62, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02,
+	0x02, 0x50, 0x62, 0x82, 0xb5, 0x18, 0x1b, 0x0a, 0x0f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x6b, 0x77, 0x65, 0x62, 0x2e, 0x64, 0x65, 0x76, 0x12, 0x08, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68,
+	0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -246,24 +471,31 @@ func file_components_github_pb_types_proto_rawDescGZIP() []byte {
 	return file_components_github_pb_types_proto_rawDescData
 }
 
-var file_components_github_pb_types_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_components_github_pb_types_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_components_github_pb_types_proto_goTypes = []interface{}{
-	(*AppInstallation)(nil),     // 0: pb.AppInstallation
-	(*AppInstallationSpec)(nil), // 1: pb.AppInstallationSpec
-	(*GithubAccount)(nil),       // 2: pb.GithubAccount
-	(*kube.TypeMeta)(nil),       // 3: kube.TypeMeta
-	(*kube.ObjectMeta)(nil),     // 4: kube.ObjectMeta
+	(*AppInstallation)(nil),       // 0: pb.AppInstallation
+	(*AppInstallationSpec)(nil),   // 1: pb.AppInstallationSpec
+	(*AppInstallationStatus)(nil), // 2: pb.AppInstallationStatus
+	(*GithubAccount)(nil),         // 3: pb.GithubAccount
+	(*SetupState)(nil),            // 4: pb.SetupState
+	(*InstallationSelection)(nil), // 5: pb.InstallationSelection
+	(*kube.TypeMeta)(nil),         // 6: kube.TypeMeta
+	(*kube.ObjectMeta)(nil),       // 7: kube.ObjectMeta
+	(*kube.Condition)(nil),        // 8: kube.Condition
 }
 var file_components_github_pb_types_proto_depIdxs = []int32{
-	3, // 0: pb.AppInstallation.typemeta:type_name -> kube.TypeMeta
-	4, // 1: pb.AppInstallation.metadata:type_name -> kube.ObjectMeta
+	6, // 0: pb.AppInstallation.typemeta:type_name -> kube.TypeMeta
+	7, // 1: pb.AppInstallation.metadata:type_name -> kube.ObjectMeta
 	1, // 2: pb.AppInstallation.spec:type_name -> pb.AppInstallationSpec
-	2, // 3: pb.AppInstallationSpec.account:type_name -> pb.GithubAccount
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	2, // 3: pb.AppInstallation.status:type_name -> pb.AppInstallationStatus
+	3, // 4: pb.AppInstallationSpec.account:type_name -> pb.GithubAccount
+	3, // 5: pb.AppInstallationSpec.authorized_users:type_name -> pb.GithubAccount
+	8, // 6: pb.AppInstallationStatus.conditions:type_name -> kube.Condition
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_components_github_pb_types_proto_init() }
@@ -297,6 +529,18 @@ func file_components_github_pb_types_proto_init() {
 			}
 		}
 		file_components_github_pb_types_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppInstallationStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_github_pb_types_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GithubAccount); i {
 			case 0:
 				return &v.state
@@ -308,6 +552,30 @@ func file_components_github_pb_types_proto_init() {
 				return nil
 			}
 		}
+		file_components_github_pb_types_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetupState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_github_pb_types_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InstallationSelection); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -315,7 +583,7 @@ func file_components_github_pb_types_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_components_github_pb_types_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -2,14 +2,20 @@ package github
 
 import (
 	"context"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-github/v45/github"
+	"github.com/justinsb/kweb/components"
 	"github.com/justinsb/kweb/components/github/pb"
-	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/login/providers/loginwithgithub"
 	"github.com/justinsb/kweb/components/users"
+	userapi "github.com/justinsb/kweb/components/users/pb"
 	"k8s.io/klog/v2"
 )
 
+// GetClient returns a client for the installation the current user has selected (or their first installation),
+// or nil if the user is not logged in or has no installations.
 func GetClient(ctx context.Context, options github.InstallationTokenOptions) (*Client, error) {
 	requestInfo := getRequestInfo(ctx)
 	if requestInfo == nil {
@@ -18,6 +24,45 @@ func GetClient(ctx context.Context, options github.InstallationTokenOptions) (*C
 	return requestInfo.GetClient(ctx, options)
 }
 
+// ListInstallations returns the installations that the current user can use.
+func ListInstallations(ctx context.Context) ([]*pb.AppInstallation, error) {
+	requestInfo := getRequestInfo(ctx)
+	if requestInfo == nil {
+		return nil, nil
+	}
+	return requestInfo.ListInstallations(ctx)
+}
+
+// GetClientForInstallation returns a client for the installation, or nil if the current user cannot use it.
+func GetClientForInstallation(ctx context.Context, installationID int64, options github.InstallationTokenOptions) (*Client, error) {
+	requestInfo := getRequestInfo(ctx)
+	if requestInfo == nil {
+		return nil, nil
+	}
+	return requestInfo.GetClientForInstallation(ctx, installationID, options)
+}
+
+// GetClientForAccount returns a client for the installation on the GitHub account (user or organization) with the login,
+// or nil if the current user cannot use such an installation.
+func GetClientForAccount(ctx context.Context, login string, options github.InstallationTokenOptions) (*Client, error) {
+	requestInfo := getRequestInfo(ctx)
+	if requestInfo == nil {
+		return nil, nil
+	}
+	return requestInfo.GetClientForAccount(ctx, login, options)
+}
+
+// GetClientForRepository returns a client with a token limited to the repository owner/repo and the permissions
+// (or all the permissions of the installation if permissions is nil).
+// It returns nil if the current user cannot use an installation on the owner.
+func GetClientForRepository(ctx context.Context, owner string, repo string, permissions *github.InstallationPermissions) (*Client, error) {
+	options := github.InstallationTokenOptions{
+		Repositories: []string{repo},
+		Permissions:  permissions,
+	}
+	return GetClientForAccount(ctx, owner, options)
+}
+
 var contextKeyRequest = &requestInfo{}
 
 func getRequestInfo(ctx context.Context) *requestInfo {
@@ -30,33 +75,144 @@ func getRequestInfo(ctx context.Context) *requestInfo {
 
 type requestInfo struct {
 	component *Component
+
+	// installations caches the installations of the current user for the request
+	installations []*pb.AppInstallation
 }
 
-func (r *requestInfo) GetClient(ctx context.Context, options github.InstallationTokenOptions) (*Client, error) {
-	user := users.GetUser(ctx)
-	if user == nil {
-		return nil, nil
+func (r *requestInfo) ListInstallations(ctx context.Context) ([]*pb.AppInstallation, error) {
+	if r.installations == nil {
+		user := users.GetUser(ctx)
+		if user == nil {
+			return nil, nil
+		}
+		installations, err := r.component.installationsForUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		r.installations = installations
 	}
+	return r.installations, nil
+}
 
-	ns := user.GetMetadata().GetNamespace()
-	typed := kubeclient.TypedClient(r.component.kube, &pb.AppInstallation{})
-	installations, err := typed.List(ctx, ns)
+// SelectedInstallation returns the installation the user has selected, or their first installation if they have not selected one.
+// It returns nil if the user has no installations.
+func (r *requestInfo) SelectedInstallation(ctx context.Context) (*pb.AppInstallation, error) {
+	installations, err := r.ListInstallations(ctx)
 	if err != nil {
 		return nil, err
 	}
-
 	if len(installations) == 0 {
 		return nil, nil
 	}
 
-	if len(installations) > 1 {
-		klog.Infof("using first installation, but multiple installations found for user %q", ns)
+	selection := &pb.InstallationSelection{}
+	if req := components.GetRequest(ctx); req.Session != nil && req.Session.Get(selection) {
+		for _, installation := range installations {
+			if installation.GetSpec().GetId() == selection.GetInstallationId() {
+				return installation, nil
+			}
+		}
 	}
-	installation := installations[0]
+	return installations[0], nil
+}
+
+func (r *requestInfo) GetClient(ctx context.Context, options github.InstallationTokenOptions) (*Client, error) {
+	installation, err := r.SelectedInstallation(ctx)
+	if err != nil || installation == nil {
+		return nil, err
+	}
+	return r.component.authForInstallation(ctx, installation.GetSpec().GetId(), options)
+}
+
+func (r *requestInfo) GetClientForInstallation(ctx context.Context, installationID int64, options github.InstallationTokenOptions) (*Client, error) {
+	installations, err := r.ListInstallations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, installation := range installations {
+		if installation.GetSpec().GetId() == installationID {
+			return r.component.authForInstallation(ctx, installationID, options)
+		}
+	}
+	return nil, nil
+}
 
-	client, err := r.component.authForInstallation(ctx, installation.GetSpec().GetId(), options)
+func (r *requestInfo) GetClientForAccount(ctx context.Context, login string, options github.InstallationTokenOptions) (*Client, error) {
+	installations, err := r.ListInstallations(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return client, nil
+	for _, installation := range installations {
+		// GitHub logins are case-insensitive
+		if strings.EqualFold(installation.GetSpec().GetAccount().GetLogin(), login) {
+			return r.component.authForInstallation(ctx, installation.GetSpec().GetId(), options)
+		}
+	}
+	return nil, nil
+}
+
+// installationsForUser returns the installations that the user can use, through their linked GitHub accounts:
+// installations on their own account, followed by installations on organizations they are a member of,
+// or that they have been authorized for.
+func (c *Component) installationsForUser(ctx context.Context, user *userapi.User) ([]*pb.AppInstallation, error) {
+	var own, authorized []*pb.AppInstallation
+	seen := make(map[int64]bool)
+	var accounts []*userapi.LinkedAccount
+	var accountIDs []int64
+	for _, account := range users.LinkedAccounts(user, loginwithgithub.ProviderID) {
+		accountID, err := strconv.ParseInt(account.GetProviderUserID(), 10, 64)
+		if err != nil {
+			klog.Warningf("ignoring linked github account with invalid id %q", account.GetProviderUserID())
+			continue
+		}
+		accounts = append(accounts, account)
+		accountIDs = append(accountIDs, accountID)
+
+		installations, err := c.installations.ByIndex(ctx, indexGithubAccount, account.GetProviderUserID())
+		if err != nil {
+			return nil, err
+		}
+		for _, installation := range installations {
+			id := installation.GetSpec().GetId()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if installation.GetSpec().GetAccount().GetId() == accountID {
+				own = append(own, installation)
+			} else {
+				authorized = append(authorized, installation)
+			}
+		}
+	}
+
+	if len(accounts) == 0 {
+		return own, nil
+	}
+
+	// Members of an organization can use the installation on the organization
+	orgInstallations, err := c.installations.ByIndex(ctx, indexAccountType, accountTypeOrganization)
+	if err != nil {
+		return nil, err
+	}
+	for _, installation := range orgInstallations {
+		if seen[installation.GetSpec().GetId()] {
+			continue
+		}
+		for i, account := range accounts {
+			member, err := c.isOrgMember(ctx, installation, accountIDs[i], account.GetProviderUserName())
+			if err != nil {
+				// The app may not have permission to read the members of the organization
+				klog.Warningf("ignoring github installation %d: %v", installation.GetSpec().GetId(), err)
+				break
+			}
+			if member {
+				seen[installation.GetSpec().GetId()] = true
+				authorized = append(authorized, installation)
+				break
+			}
+		}
+	}
+	return append(own, authorized...), nil
 }
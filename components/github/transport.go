@@ -0,0 +1,320 @@
+package github
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var (
+	rateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_ratelimit_remaining",
+		Help: "Requests remaining in the current GitHub rate limit window.",
+	}, []string{"installation", "resource"})
+	rateLimitLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_ratelimit_limit",
+		Help: "Requests allowed in each GitHub rate limit window.",
+	}, []string{"installation", "resource"})
+	rateLimitReset = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_ratelimit_reset_timestamp_seconds",
+		Help: "Time at which the current GitHub rate limit window resets.",
+	}, []string{"installation", "resource"})
+	rateLimitWaits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_ratelimit_waits_total",
+		Help: "Requests that were delayed or rejected because GitHub asked us to slow down.",
+	}, []string{"installation"})
+	responseCacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_response_cache_hits_total",
+		Help: "GET requests answered from the response cache, because GitHub reported them unchanged.",
+	}, []string{"installation"})
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitRemaining, rateLimitLimit, rateLimitReset, rateLimitWaits, responseCacheHits)
+}
+
+const (
+	// maxRateLimitWait is the longest we will delay a request because of a rate limit; beyond this we fail the request.
+	maxRateLimitWait = time.Minute
+
+	// writeInterval is the minimum interval between mutating requests for an installation, as recommended by GitHub
+	// to avoid secondary rate limits.
+	writeInterval = time.Second
+
+	// maxCachedBytes bounds the total size of the GET responses we keep for conditional requests.
+	maxCachedBytes = 64 << 20
+
+	// maxCachedResponseSize is the largest response body that we cache.
+	maxCachedResponseSize = 1 << 20
+)
+
+// transport wraps the requests to the GitHub API with the credentials of one token scope (the app, or an installation with token options).
+// It tracks the rate limit, delays requests when GitHub asks us to slow down, and revalidates GET responses with ETags,
+// so that unchanged responses do not count against the rate limit.
+type transport struct {
+	base http.RoundTripper
+
+	// installation labels the metrics, and is "app" for requests made as the app
+	installation string
+	// scope identifies the credentials, as responses may differ between tokens
+	scope string
+
+	limiter *rateLimiter
+	cache   *responseCache
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context(), req.Method); err != nil {
+		rateLimitWaits.WithLabelValues(t.installation).Inc()
+		return nil, err
+	}
+
+	var cached *cachedResponse
+	cacheKey := ""
+	if req.Method == http.MethodGet && req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+		cacheKey = t.scope + " " + req.Header.Get("Accept") + " " + req.URL.String()
+		cached = t.cache.get(cacheKey)
+		if cached != nil {
+			req = req.Clone(req.Context())
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+
+	response, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.observeRateLimit(response)
+
+	if cached != nil && response.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		responseCacheHits.WithLabelValues(t.installation).Inc()
+		return cached.toResponse(req, response), nil
+	}
+
+	if cacheKey != "" && response.StatusCode == http.StatusOK {
+		etag := response.Header.Get("ETag")
+		lastModified := response.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			body, err := io.ReadAll(io.LimitReader(response.Body, maxCachedResponseSize+1))
+			if err != nil {
+				response.Body.Close()
+				return nil, err
+			}
+			if len(body) > maxCachedResponseSize {
+				response.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), response.Body), Closer: response.Body}
+				return response, nil
+			}
+			response.Body.Close()
+			response.Body = io.NopCloser(bytes.NewReader(body))
+			t.cache.put(cacheKey, &cachedResponse{
+				etag:         etag,
+				lastModified: lastModified,
+				header:       response.Header.Clone(),
+				body:         body,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// observeRateLimit records the rate limit headers of the response.
+func (t *transport) observeRateLimit(response *http.Response) {
+	resource := response.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	remaining, remainingErr := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining"))
+	limit, limitErr := strconv.Atoi(response.Header.Get("X-RateLimit-Limit"))
+	reset, resetErr := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if remainingErr == nil {
+		rateLimitRemaining.WithLabelValues(t.installation, resource).Set(float64(remaining))
+	}
+	if limitErr == nil {
+		rateLimitLimit.WithLabelValues(t.installation, resource).Set(float64(limit))
+	}
+	if resetErr == nil {
+		rateLimitReset.WithLabelValues(t.installation, resource).Set(float64(reset))
+	}
+
+	if response.StatusCode != http.StatusForbidden && response.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	// Secondary rate limits tell us how long to wait; for the primary rate limit we wait until the window resets
+	if retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+		t.limiter.blockUntil(time.Now().Add(time.Duration(retryAfter) * time.Second))
+	} else if remainingErr == nil && remaining == 0 && resetErr == nil {
+		t.limiter.blockUntil(time.Unix(reset, 0))
+	}
+}
+
+// rateLimiter delays the requests for an installation (which share a rate limit) when GitHub asks us to slow down.
+type rateLimiter struct {
+	installation string
+
+	mutex sync.Mutex
+	// blockedUntil is the time until which GitHub has asked us not to make requests
+	blockedUntil time.Time
+	// nextWrite is the earliest time for the next mutating request
+	nextWrite time.Time
+}
+
+func (l *rateLimiter) blockUntil(t time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if t.After(l.blockedUntil) {
+		klog.Warningf("github rate limit reached for %s; pausing requests until %v", l.installation, t)
+		l.blockedUntil = t
+	}
+}
+
+// wait waits until we can make a request, returning an error if we would have to wait too long.
+func (l *rateLimiter) wait(ctx context.Context, method string) error {
+	l.mutex.Lock()
+	now := time.Now()
+	start := now
+	if l.blockedUntil.After(start) {
+		start = l.blockedUntil
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		if l.nextWrite.After(start) {
+			start = l.nextWrite
+		}
+		l.nextWrite = start.Add(writeInterval)
+	}
+	l.mutex.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	if delay > maxRateLimitWait {
+		return fmt.Errorf("github rate limit exceeded for %s; requests are paused until %v", l.installation, start)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// responseCache holds recent GET responses, so that we can make conditional requests.
+// The least recently used responses are evicted when the cache holds more than maxBytes.
+type responseCache struct {
+	maxBytes int
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the entries, most recently used first
+	lru *list.List
+	// bytes is the total size of the entries
+	bytes int
+}
+
+type cachedResponse struct {
+	key          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+func newResponseCache(maxBytes int) *responseCache {
+	return &responseCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e := c.entries[key]
+	if e == nil {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedResponse)
+}
+
+func (c *responseCache) put(key string, response *cachedResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	response.key = key
+	if e := c.entries[key]; e != nil {
+		c.bytes -= e.Value.(*cachedResponse).size()
+		e.Value = response
+		c.lru.MoveToFront(e)
+	} else {
+		c.entries[key] = c.lru.PushFront(response)
+	}
+	c.bytes += response.size()
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		evicted := oldest.Value.(*cachedResponse)
+		c.lru.Remove(oldest)
+		delete(c.entries, evicted.key)
+		c.bytes -= evicted.size()
+	}
+}
+
+// size approximates the memory used by the cached response.
+func (r *cachedResponse) size() int {
+	n := len(r.key) + len(r.etag) + len(r.lastModified) + len(r.body)
+	for k, values := range r.header {
+		n += len(k)
+		for _, v := range values {
+			n += len(v)
+		}
+	}
+	return n
+}
+
+// toResponse builds the response for a request that GitHub reported as not modified.
+func (r *cachedResponse) toResponse(req *http.Request, notModified *http.Response) *http.Response {
+	header := r.header.Clone()
+	// The rate limit headers are current in the 304 response
+	for k, values := range notModified.Header {
+		header[k] = values
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,364 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+func newTestTransport(scope string, cache *responseCache) *transport {
+	return &transport{
+		base:         http.DefaultTransport,
+		installation: "test",
+		scope:        scope,
+		limiter:      &rateLimiter{installation: "test"},
+		cache:        cache,
+	}
+}
+
+func get(t *testing.T, rt http.RoundTripper, url string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("error building request: %v", err)
+	}
+	response, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("error from GET %s: %v", url, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("error reading body: %v", err)
+	}
+	return response, string(body)
+}
+
+func TestConditionalRequests(t *testing.T) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(100-int(n)))
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/last-modified":
+			if r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"path":"`+r.URL.Path+`"}`)
+	}))
+	defer server.Close()
+
+	cache := newResponseCache(maxCachedBytes)
+	rt := newTestTransport("scope-a", cache)
+
+	for _, path := range []string{"/etag", "/last-modified"} {
+		first, firstBody := get(t, rt, server.URL+path)
+		if first.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status %d for %s", first.StatusCode, path)
+		}
+
+		// The second request is revalidated, and the 304 is rewritten to the cached response
+		second, secondBody := get(t, rt, server.URL+path)
+		if second.StatusCode != http.StatusOK {
+			t.Errorf("expected cached response to have status 200, got %d", second.StatusCode)
+		}
+		if secondBody != firstBody {
+			t.Errorf("expected cached body %q, got %q", firstBody, secondBody)
+		}
+		if second.ContentLength != int64(len(firstBody)) {
+			t.Errorf("unexpected content length %d", second.ContentLength)
+		}
+		if got := second.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected cached headers to be returned, got content type %q", got)
+		}
+		// ... but the rate limit headers come from the 304
+		if second.Header.Get("X-RateLimit-Remaining") == first.Header.Get("X-RateLimit-Remaining") {
+			t.Errorf("expected rate limit headers from the 304 response")
+		}
+	}
+	if notModified != 2 {
+		t.Errorf("expected 2 conditional requests to be answered with 304, got %d", notModified)
+	}
+
+	// Responses are not shared with other token scopes
+	other := newTestTransport("scope-b", cache)
+	get(t, other, server.URL+"/etag")
+	if notModified != 2 {
+		t.Errorf("expected a request with another scope not to be conditional")
+	}
+}
+
+func TestResponseCacheEvictsByBytes(t *testing.T) {
+	body := []byte(strings.Repeat("x", 40))
+	entry := func() *cachedResponse {
+		return &cachedResponse{etag: "e", body: body}
+	}
+	// Room for two entries (including their keys), but not three
+	cache := newResponseCache(2 * (len(body) + 2))
+
+	cache.put("a", entry())
+	cache.put("b", entry())
+	if cache.get("a") == nil || cache.get("b") == nil {
+		t.Fatalf("expected both entries to be cached")
+	}
+
+	// "a" is now the least recently used
+	cache.put("c", entry())
+	if cache.get("a") != nil {
+		t.Errorf("expected least recently used entry to be evicted")
+	}
+	if cache.get("b") == nil || cache.get("c") == nil {
+		t.Errorf("expected recent entries to be kept")
+	}
+
+	// Replacing an entry does not count it twice
+	cache.put("c", entry())
+	if cache.get("b") == nil {
+		t.Errorf("expected replacing an entry not to evict others")
+	}
+	if cache.bytes > cache.maxBytes {
+		t.Errorf("cache holds %d bytes, more than %d", cache.bytes, cache.maxBytes)
+	}
+
+	// An entry larger than the cache is not kept
+	cache.put("big", &cachedResponse{body: make([]byte, 1000)})
+	if cache.get("big") != nil {
+		t.Errorf("expected oversized entry not to be cached")
+	}
+	if cache.bytes != 0 || cache.lru.Len() != 0 || len(cache.entries) != 0 {
+		t.Errorf("expected cache to be empty, got %d bytes in %d entries", cache.bytes, cache.lru.Len())
+	}
+}
+
+func TestRateLimitBlocksRequests(t *testing.T) {
+	grid := []struct {
+		name   string
+		status int
+		header map[string]string
+	}{
+		{
+			name:   "secondary rate limit",
+			status: http.StatusForbidden,
+			header: map[string]string{"Retry-After": "120"},
+		},
+		{
+			name:   "too many requests",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "120"},
+		},
+		{
+			name:   "primary rate limit",
+			status: http.StatusForbidden,
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10),
+			},
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				for k, v := range g.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(g.status)
+			}))
+			defer server.Close()
+
+			rt := newTestTransport("scope", newResponseCache(maxCachedBytes))
+			get(t, rt, server.URL)
+
+			// We would have to wait longer than maxRateLimitWait, so the request fails without reaching GitHub
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			if _, err := rt.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "rate limit exceeded") {
+				t.Errorf("expected rate limit error, got %v", err)
+			}
+			if requests != 1 {
+				t.Errorf("expected 1 request to reach the server, got %d", requests)
+			}
+		})
+	}
+}
+
+func TestRetryAfterDelaysRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	rt := newTestTransport("scope", newResponseCache(maxCachedBytes))
+	get(t, rt, server.URL)
+
+	// The request waits for Retry-After, so it is cancelled by a shorter deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if _, err := rt.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected request to be delayed until the deadline, got %v", err)
+	}
+
+	start := time.Now()
+	response, _ := get(t, rt, server.URL)
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status %d", response.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("expected request to wait for Retry-After, but it took %v", elapsed)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", requests)
+	}
+}
+
+func TestWriteSpacing(t *testing.T) {
+	ctx := context.Background()
+	limiter := &rateLimiter{installation: "test"}
+
+	if err := limiter.wait(ctx, http.MethodPost); err != nil {
+		t.Fatalf("unexpected error for first write: %v", err)
+	}
+
+	// Reads are not delayed by writes
+	readCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(readCtx, http.MethodGet); err != nil {
+		t.Errorf("expected read not to be delayed, got %v", err)
+	}
+
+	// A second write waits for writeInterval
+	writeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(writeCtx, http.MethodPatch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected write to be delayed, got %v", err)
+	}
+}
+
+func TestTokenScopeKey(t *testing.T) {
+	grid := []struct {
+		name string
+		a, b github.InstallationTokenOptions
+		same bool
+	}{
+		{
+			name: "repository order",
+			a:    github.InstallationTokenOptions{Repositories: []string{"a", "b"}},
+			b:    github.InstallationTokenOptions{Repositories: []string{"b", "a"}},
+			same: true,
+		},
+		{
+			name: "repository id order",
+			a:    github.InstallationTokenOptions{RepositoryIDs: []int64{2, 10, 1}},
+			b:    github.InstallationTokenOptions{RepositoryIDs: []int64{1, 2, 10}},
+			same: true,
+		},
+		{
+			name: "different repositories",
+			a:    github.InstallationTokenOptions{Repositories: []string{"a"}},
+			b:    github.InstallationTokenOptions{Repositories: []string{"b"}},
+			same: false,
+		},
+		{
+			name: "different permissions",
+			a:    github.InstallationTokenOptions{Permissions: &github.InstallationPermissions{Contents: github.String("read")}},
+			b:    github.InstallationTokenOptions{Permissions: &github.InstallationPermissions{Contents: github.String("write")}},
+			same: false,
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			a, err := tokenScopeKey(1, g.a)
+			if err != nil {
+				t.Fatalf("error building key: %v", err)
+			}
+			b, err := tokenScopeKey(1, g.b)
+			if err != nil {
+				t.Fatalf("error building key: %v", err)
+			}
+			if (a == b) != g.same {
+				t.Errorf("keys %q and %q: got same=%v, want %v", a, b, a == b, g.same)
+			}
+		})
+	}
+
+	// The caller's options are not modified
+	options := github.InstallationTokenOptions{Repositories: []string{"b", "a"}}
+	if _, err := tokenScopeKey(1, options); err != nil {
+		t.Fatalf("error building key: %v", err)
+	}
+	if options.Repositories[0] != "b" {
+		t.Errorf("tokenScopeKey sorted the caller's repositories")
+	}
+
+	// Keys are per installation
+	a, _ := tokenScopeKey(1, github.InstallationTokenOptions{})
+	b, _ := tokenScopeKey(2, github.InstallationTokenOptions{})
+	if a == b {
+		t.Errorf("expected keys for different installations to differ")
+	}
+}
+
+func TestIdleTokenSourcesAreEvicted(t *testing.T) {
+	c := &Component{
+		tokenSources: make(map[string]*userTokenSource),
+		rateLimiters: make(map[string]*rateLimiter),
+		responses:    newResponseCache(maxCachedBytes),
+	}
+	now := time.Now()
+	// Neither source has minted a token
+	c.tokenSources["idle"] = &userTokenSource{lastUsed: now.Add(-2 * tokenSourceIdleTimeout)}
+	c.tokenSources["recent"] = &userTokenSource{lastUsed: now.Add(-time.Minute)}
+
+	c.evictIdleTokenSources(now)
+	if _, found := c.tokenSources["idle"]; found {
+		t.Errorf("expected idle token source to be evicted")
+	}
+	if _, found := c.tokenSources["recent"]; !found {
+		t.Errorf("expected recently used token source to be kept")
+	}
+
+	// Sweeps also happen when the token source is cached
+	ctx := context.Background()
+	if _, err := c.authForInstallation(ctx, 1, github.InstallationTokenOptions{}); err != nil {
+		t.Fatalf("error getting client: %v", err)
+	}
+	c.tokenSources["recent"].lastUsed = now.Add(-2 * tokenSourceIdleTimeout)
+	c.nextTokenSourceSweep = time.Time{}
+	if _, err := c.authForInstallation(ctx, 1, github.InstallationTokenOptions{}); err != nil {
+		t.Fatalf("error getting client: %v", err)
+	}
+	if _, found := c.tokenSources["recent"]; found {
+		t.Errorf("expected token source to be evicted by a sweep on a cache hit")
+	}
+	if len(c.tokenSources) != 1 {
+		t.Errorf("expected only the token source in use to be kept, got %d", len(c.tokenSources))
+	}
+}
@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/github/pb"
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+// webhookPath is the path that GitHub delivers webhooks to; it should be configured as the webhook URL of the app.
+const webhookPath = "/_ghapp/webhook"
+
+// maxWebhookPayload is the maximum size of a webhook delivery; GitHub caps payloads at 25MB.
+const maxWebhookPayload = 25 << 20
+
+// serveWebhook handles a webhook delivery from GitHub, keeping the AppInstallation objects up to date.
+func (c *Component) serveWebhook(ctx context.Context, req *components.Request) (components.Response, error) {
+	if len(c.webhookSecret) == 0 {
+		klog.Warningf("rejecting github webhook: no webhook secret is configured")
+		return nil, components.NewHTTPError(http.StatusNotFound, "github webhooks are not configured", nil)
+	}
+
+	signature := req.Header.Get(github.SHA256SignatureHeader)
+	if signature == "" {
+		return nil, components.NewHTTPError(http.StatusUnauthorized, "missing webhook signature", nil)
+	}
+	body := http.MaxBytesReader(nil, req.Body, maxWebhookPayload)
+	payload, err := github.ValidatePayloadFromBody(req.Header.Get("Content-Type"), body, signature, c.webhookSecret)
+	if err != nil {
+		return nil, components.NewHTTPError(http.StatusUnauthorized, "invalid webhook signature", err)
+	}
+
+	eventType := github.WebHookType(req.Request)
+	deliveryID := github.DeliveryID(req.Request)
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid webhook payload", err)
+	}
+
+	klog.Infof("github webhook %q (delivery %s)", eventType, deliveryID)
+
+	switch event := event.(type) {
+	case *github.InstallationEvent:
+		if err := c.onInstallationEvent(ctx, event.GetAction(), event.GetInstallation()); err != nil {
+			return nil, err
+		}
+	case *github.InstallationRepositoriesEvent:
+		if err := c.onInstallationEvent(ctx, event.GetAction(), event.GetInstallation()); err != nil {
+			return nil, err
+		}
+	default:
+		klog.V(2).Infof("ignoring github webhook %q", eventType)
+	}
+
+	return &components.SimpleResponse{StatusCode: http.StatusNoContent}, nil
+}
+
+// onInstallationEvent applies or deletes the AppInstallation object for the installation in a webhook.
+func (c *Component) onInstallationEvent(ctx context.Context, action string, installation *github.Installation) error {
+	if installation.GetID() == 0 {
+		return components.NewHTTPError(http.StatusBadRequest, "webhook did not include installation", nil)
+	}
+
+	if action == "deleted" {
+		id := installationKey(installation)
+		klog.Infof("github installation %d was deleted; deleting %v", installation.GetID(), id)
+		if err := c.kube.Delete(ctx, id, &pb.AppInstallation{}, kubeclient.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error deleting installation %v: %w", id, err)
+		}
+		return nil
+	}
+
+	applied, err := c.applyInstallation(ctx, installation)
+	if err != nil {
+		return err
+	}
+	// The event tells us the current state (e.g. if the installation was suspended), which may not change the spec
+	return c.updateInstallationStatus(ctx, installation, applied)
+}
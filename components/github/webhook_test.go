@@ -0,0 +1,163 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/github/pb"
+	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/kube/kubeclient/fakekube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+func newTestComponent(t *testing.T) *Component {
+	t.Helper()
+	s, err := fakekube.Start()
+	if err != nil {
+		t.Fatalf("error starting fakekube: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	kube, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatalf("error building client: %v", err)
+	}
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	c, err := New(kube, Options{
+		AppID:         "1",
+		PrivateKey:    privateKey,
+		WebhookSecret: testWebhookSecret,
+	})
+	if err != nil {
+		t.Fatalf("error building component: %v", err)
+	}
+	return c
+}
+
+func sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook sends the event to the webhook handler, returning the status code.
+func deliverWebhook(t *testing.T, c *Component, eventType string, payload string, signature string) int {
+	t.Helper()
+	r := httptest.NewRequest("POST", webhookPath, bytes.NewReader([]byte(payload)))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-GitHub-Event", eventType)
+	r.Header.Set("X-GitHub-Delivery", "delivery-1")
+	if signature != "" {
+		r.Header.Set("X-Hub-Signature-256", signature)
+	}
+	req := &components.Request{Request: r, PathParameters: make(map[string]string)}
+
+	response, err := c.serveWebhook(context.Background(), req)
+	if err != nil {
+		var httpError *components.HTTPError
+		if !errors.As(err, &httpError) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return httpError.Status
+	}
+	return response.(*components.SimpleResponse).StatusCode
+}
+
+const installationPayload = `{
+  "action": "%s",
+  "installation": {
+    "id": 42,
+    "account": {"id": 7, "login": "example-org", "type": "Organization"},
+    "repository_selection": "all"
+  }
+}`
+
+func installationEvent(action string) string {
+	return fmt.Sprintf(installationPayload, action)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	c := newTestComponent(t)
+	payload := installationEvent("created")
+
+	grid := []struct {
+		name      string
+		signature string
+		want      int
+	}{
+		{name: "missing signature", signature: "", want: http.StatusUnauthorized},
+		{name: "wrong secret", signature: sign([]byte(payload), "wrong"), want: http.StatusUnauthorized},
+		{name: "malformed signature", signature: "sha256=zz", want: http.StatusUnauthorized},
+		{name: "signature of other payload", signature: sign([]byte(installationEvent("deleted")), testWebhookSecret), want: http.StatusUnauthorized},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			if got := deliverWebhook(t, c, "installation", payload, g.signature); got != g.want {
+				t.Errorf("got status %d, want %d", got, g.want)
+			}
+		})
+	}
+
+	// None of the rejected deliveries were applied
+	key := types.NamespacedName{Namespace: "github-7", Name: "42"}
+	if err := c.kube.Get(context.Background(), key, &pb.AppInstallation{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected installation not to be created, got %v", err)
+	}
+}
+
+func TestWebhookInstallationEvents(t *testing.T) {
+	ctx := context.Background()
+	c := newTestComponent(t)
+	key := types.NamespacedName{Namespace: "github-7", Name: "42"}
+
+	payload := installationEvent("created")
+	if got := deliverWebhook(t, c, "installation", payload, sign([]byte(payload), testWebhookSecret)); got != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", got, http.StatusNoContent)
+	}
+
+	installation := &pb.AppInstallation{}
+	if err := c.kube.Get(ctx, key, installation); err != nil {
+		t.Fatalf("error getting installation: %v", err)
+	}
+	spec := installation.GetSpec()
+	if spec.GetId() != 42 || spec.GetAccount().GetId() != 7 || spec.GetAccount().GetLogin() != "example-org" {
+		t.Errorf("unexpected installation spec %v", spec)
+	}
+	if spec.GetAccount().GetType() != accountTypeOrganization {
+		t.Errorf("unexpected account type %q", spec.GetAccount().GetType())
+	}
+	ready := kube.FindCondition(installation.GetStatus().GetConditions(), conditionReady)
+	if ready == nil || ready.GetStatus() != kube.ConditionTrue {
+		t.Errorf("expected installation to be Ready, got %v", ready)
+	}
+
+	payload = installationEvent("deleted")
+	if got := deliverWebhook(t, c, "installation", payload, sign([]byte(payload), testWebhookSecret)); got != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", got, http.StatusNoContent)
+	}
+	if err := c.kube.Get(ctx, key, &pb.AppInstallation{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected installation to be deleted, got %v", err)
+	}
+
+	// Deleting an installation we don't know about is not an error (e.g. a redelivery)
+	if got := deliverWebhook(t, c, "installation", payload, sign([]byte(payload), testWebhookSecret)); got != http.StatusNoContent {
+		t.Errorf("got status %d for a repeated delete, want %d", got, http.StatusNoContent)
+	}
+}
@@ -21,6 +21,10 @@ type Session interface {
 	Clear(_ proto.Message)
 	Set(value proto.Message)
 	Get(dest proto.Message) bool
+
+	// RegenerateID moves the session to a new ID (when the response is sent), removing the old one.
+	// It should be called when the privileges of the session change, to prevent session fixation.
+	RegenerateID()
 }
 
 var contextKeyRequest = &Request{}
@@ -36,6 +40,13 @@ type Component interface {
 	AddToScope(ctx context.Context, scope *scopes.Scope)
 }
 
+// Runnable is implemented by components that run background tasks.
+type Runnable interface {
+	// Start launches the background tasks, which should stop when ctx is cancelled.
+	// Start should not block.
+	Start(ctx context.Context) error
+}
+
 type RequestFilterChain func(ctx context.Context, req *Request) (Response, error)
 type RequestFilterFunction func(ctx context.Context, req *Request, next RequestFilterChain) (Response, error)
 type RequestFilter interface {
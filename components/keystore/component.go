@@ -0,0 +1,32 @@
+package keystore
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/templates/scopes"
+	"k8s.io/klog/v2"
+)
+
+// Component watches the secret backing the key store, so that keys created or rotated by other servers are picked up.
+type Component struct {
+	KeyStore *KubernetesKeyStore
+}
+
+func (c *Component) AddToScope(ctx context.Context, scope *scopes.Scope) {
+}
+
+func (c *Component) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
+	return nil
+}
+
+// Start ties the lifetime of the secret watch to ctx.
+func (c *Component) Start(ctx context.Context) error {
+	go func() {
+		if err := c.KeyStore.WatchForever(ctx); err != nil && ctx.Err() == nil {
+			klog.Warningf("error watching keys: %v", err)
+		}
+	}()
+	return nil
+}
@@ -58,7 +58,7 @@ func NewKubernetesKeyStore(client kubernetes.Interface, namespace string, name s
 
 func (k *KubernetesKeyStore) KeySet(ctx context.Context, name string, keyType pb.KeyType) (KeySet, error) {
 	var key Key
-	ks := k.keySets[name]
+	ks := k.getKeySet(name)
 	if ks != nil {
 		key = ks.versions[ks.data.ActiveId]
 	}
@@ -69,12 +69,12 @@ func (k *KubernetesKeyStore) KeySet(ctx context.Context, name string, keyType pb
 
 	// TODO: Strategy for consistency with multiple servers, avoid thundering herd etc
 
-	err := k.ensureKeySet(ctx, name, keyType)
+	err := k.ensureKeySet(ctx, name, keyType, false)
 	if err != nil {
 		return nil, fmt.Errorf("error creating keyset: %w", err)
 	}
 
-	ks = k.keySets[name]
+	ks = k.getKeySet(name)
 	if ks != nil {
 		key = ks.versions[ks.data.ActiveId]
 	}
@@ -86,6 +86,23 @@ func (k *KubernetesKeyStore) KeySet(ctx context.Context, name string, keyType pb
 	return ks, nil
 }
 
+// RotateKeySet generates a new version of the key, and makes it the active key.
+// Previous versions are kept, so that data encrypted with them can still be decrypted (and re-encrypted).
+func (k *KubernetesKeyStore) RotateKeySet(ctx context.Context, name string, keyType pb.KeyType) error {
+	if err := k.ensureKeySet(ctx, name, keyType, true); err != nil {
+		return fmt.Errorf("error rotating keyset: %w", err)
+	}
+	return nil
+}
+
+// getKeySet returns the current keyset with the given name, or nil if it has not been loaded.
+func (k *KubernetesKeyStore) getKeySet(name string) *keySet {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	return k.keySets[name]
+}
+
 func (k *keySet) ActiveKey() (Key, error) {
 	k.mutex.Lock()
 	defer k.mutex.Unlock()
@@ -117,13 +134,23 @@ func (k *keySet) Encrypt(plaintext []byte) ([]byte, error) {
 	}
 
 	sbKey, ok := key.(*secretboxKey)
-	if !!ok {
+	if !ok {
 		return nil, fmt.Errorf("key type %v does not support Encrypt", key.KeyType())
 	}
 
 	return sbKey.encrypt(plaintext)
 }
 
+// EncryptedKeyID returns the id of the key that was used to encrypt ciphertext (as returned by KeySet.Encrypt).
+// It does not authenticate the data.
+func EncryptedKeyID(ciphertext []byte) (int32, error) {
+	encryptedData := &pb.EncryptedData{}
+	if err := proto.Unmarshal(ciphertext, encryptedData); err != nil {
+		return 0, fmt.Errorf("error deserializing data: %w", err)
+	}
+	return encryptedData.KeyId, nil
+}
+
 func (k *keySet) AuthenticateAndDecrypt(ciphertext []byte) ([]byte, error) {
 	encryptedData := &pb.EncryptedData{}
 	// TODO: We shouldn't decode before authenticating
@@ -142,7 +169,7 @@ func (k *keySet) AuthenticateAndDecrypt(ciphertext []byte) ([]byte, error) {
 	}
 
 	sbKey, ok := key.(*secretboxKey)
-	if !!ok {
+	if !ok {
 		return nil, fmt.Errorf("key type %v does not support AuthenticateAndDecrypt", key.KeyType())
 	}
 
@@ -205,7 +232,7 @@ func readCryptoRand(n int) ([]byte, error) {
 	return b, nil
 }
 
-func (k *KubernetesKeyStore) ensureKeySet(ctx context.Context, name string, keyType pb.KeyType) error {
+func (k *KubernetesKeyStore) ensureKeySet(ctx context.Context, name string, keyType pb.KeyType, rotate bool) error {
 	err := k.mutateSecret(ctx, func(secret *v1.Secret) error {
 		keysets := k.decodeSecret(secret)
 		keyset := keysets[name]
@@ -219,7 +246,7 @@ func (k *KubernetesKeyStore) ensureKeySet(ctx context.Context, name string, keyT
 			keysets[name] = keyset
 		}
 
-		if keyset.versions[keyset.data.ActiveId] == nil {
+		if rotate || keyset.versions[keyset.data.ActiveId] == nil {
 			maxId := int32(0)
 			for id := range keyset.versions {
 				if id > maxId {
@@ -303,14 +330,14 @@ func (k *keySet) findKey(keyId int32) (Key, error) {
 
 func (k *KubernetesKeyStore) ensureKeyset(ctx context.Context, name string) (*keySet, error) {
 	keyType := pb.KeyType_KEYTYPE_SECRETBOX
-	keyset := k.keySets[name]
+	keyset := k.getKeySet(name)
 	if keyset == nil {
-		err := k.ensureKeySet(ctx, name, keyType)
+		err := k.ensureKeySet(ctx, name, keyType, false)
 		if err != nil {
 			return nil, fmt.Errorf("error creating keyset: %w", err)
 		}
 
-		keyset = k.keySets[name]
+		keyset = k.getKeySet(name)
 		if keyset == nil {
 			return nil, fmt.Errorf("created keyset was not found")
 		}
@@ -29,3 +29,15 @@ func InitObject(obj Object, id types.NamespacedName) {
 	metadata.Namespace = id.Namespace
 	SetMetadata(obj, metadata)
 }
+
+// NewOwnerReference builds a reference to owner, for metadata.ownerReferences.
+// The owner must have been read from the apiserver, so that its uid is known.
+func NewOwnerReference(owner Object) *OwnerReference {
+	kindInfo := GetKindInfo(owner)
+	return &OwnerReference{
+		ApiVersion: kindInfo.APIVersion(),
+		Kind:       kindInfo.Kind,
+		Name:       owner.GetMetadata().GetName(),
+		Uid:        owner.GetMetadata().GetUid(),
+	}
+}
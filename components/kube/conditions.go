@@ -0,0 +1,46 @@
+package kube
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Values for Condition.Status.
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
+// FindCondition returns the condition of the given type, or nil if there is none.
+func FindCondition(conditions []*Condition, conditionType string) *Condition {
+	for _, condition := range conditions {
+		if condition.GetType() == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or updates the condition with the same type.
+// The lastTransitionTime is set to now if the status changes, and otherwise preserved.
+func SetCondition(conditions *[]*Condition, condition *Condition) {
+	existing := FindCondition(*conditions, condition.GetType())
+	if existing == nil {
+		if condition.LastTransitionTime == nil {
+			condition.LastTransitionTime = timestamppb.Now()
+		}
+		*conditions = append(*conditions, condition)
+		return
+	}
+
+	if existing.GetStatus() != condition.GetStatus() {
+		existing.Status = condition.GetStatus()
+		existing.LastTransitionTime = condition.LastTransitionTime
+		if existing.LastTransitionTime == nil {
+			existing.LastTransitionTime = timestamppb.Now()
+		}
+	}
+	existing.Reason = condition.GetReason()
+	existing.Message = condition.GetMessage()
+	existing.ObservedGeneration = condition.GetObservedGeneration()
+}
@@ -0,0 +1,70 @@
+package kubeclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/justinsb/kweb/components/kube"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Cache holds the shared informers for a client.
+// Informers are started on first read; they run until the context passed to Start is done.
+type Cache struct {
+	client *Client
+
+	mutex sync.Mutex
+	ctx   context.Context
+	// informers holds an *Informer[T] for each kind
+	informers map[schema.GroupVersionResource]any
+}
+
+// Cached returns the cache of objects for the client.
+func (c *Client) Cached() *Cache {
+	c.cacheOnce.Do(func() {
+		c.cache = &Cache{
+			client:    c,
+			informers: make(map[schema.GroupVersionResource]any),
+		}
+	})
+	return c.cache
+}
+
+// Start sets the lifetime of informers that are started after this call.
+// If Start is not called, informers run for the lifetime of the process.
+func (c *Cache) Start(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ctx = ctx
+	return nil
+}
+
+// context returns the context for starting informers.
+func (c *Cache) context() context.Context {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// CachedClient returns the shared informer for objects of the same kind as obj.
+func CachedClient[T kube.Object](client *Client, obj T) *Informer[T] {
+	c := client.Cached()
+	kindInfo := kube.GetKindInfo(obj)
+	gvr := kindInfo.GroupVersionResource()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	existing, found := c.informers[gvr]
+	if found {
+		return existing.(*Informer[T])
+	}
+	i := newInformer(c, TypedClient(client, obj))
+	c.informers[gvr] = i
+	return i
+}
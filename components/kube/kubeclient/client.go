@@ -1,29 +1,24 @@
 package kubeclient
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"reflect"
 	"strconv"
-	"strings"
+	"sync"
 
 	"github.com/justinsb/kweb/components"
 	"github.com/justinsb/kweb/components/kube"
 	"github.com/justinsb/kweb/components/kube/kubejson"
-	oauthsessionsapi "github.com/justinsb/kweb/components/oauthsessions/api"
 	kubesessionstorageapi "github.com/justinsb/kweb/components/sessions/kubesessionstorage/api"
 	"github.com/justinsb/kweb/templates/scopes"
 	"google.golang.org/protobuf/proto"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
-	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -38,10 +33,19 @@ func (c *Component) RegisterHandlers(s *components.Server, mux *http.ServeMux) e
 	return nil
 }
 
+// Start ties the lifetime of the shared informers to ctx.
+func (c *Component) Start(ctx context.Context) error {
+	return c.Client.Cached().Start(ctx)
+}
+
 type Client struct {
 	dynamic    dynamic.Interface
 	uncached   client.Client
 	restConfig *rest.Config
+	httpClient *http.Client
+
+	cacheOnce sync.Once
+	cache     *Cache
 }
 
 func New(restConfig *rest.Config, scheme *runtime.Scheme) (*Client, error) {
@@ -50,9 +54,6 @@ func New(restConfig *rest.Config, scheme *runtime.Scheme) (*Client, error) {
 		return nil, err
 	}
 
-	if err := oauthsessionsapi.AllKinds.AddToScheme(scheme); err != nil {
-		return nil, err
-	}
 	if err := kubesessionstorageapi.AllKinds.AddToScheme(scheme); err != nil {
 		return nil, err
 	}
@@ -64,13 +65,25 @@ func New(restConfig *rest.Config, scheme *runtime.Scheme) (*Client, error) {
 		return nil, err
 	}
 
+	httpClient, err := rest.HTTPClientFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Client{
 		dynamic:    dynamicClient,
 		uncached:   uncached,
 		restConfig: restConfig,
+		httpClient: httpClient,
 	}, nil
 }
 
+// NewForClient builds a Client that wraps an existing controller-runtime client, for tests.
+// Only Uncached is usable on the returned Client.
+func NewForClient(uncached client.Client) *Client {
+	return &Client{uncached: uncached}
+}
+
 func Get(ctx context.Context) *Client {
 	return components.MustGetComponent[*Component](ctx).Client
 }
@@ -79,6 +92,11 @@ func (c *Client) Dynamic() dynamic.Interface {
 	return c.dynamic
 }
 
+// RESTConfig returns the configuration used to connect to the kubernetes apiserver.
+func (c *Client) RESTConfig() *rest.Config {
+	return c.restConfig
+}
+
 func (c *Client) Uncached() client.Client {
 	return c.uncached
 }
@@ -86,59 +104,18 @@ func (c *Client) Uncached() client.Client {
 func (c *Client) Get(ctx context.Context, id types.NamespacedName, obj proto.Message) error {
 	kindInfo := kube.GetKindInfo(obj)
 
-	httpClient, err := rest.HTTPClientFor(c.restConfig)
-	if err != nil {
-		return err
-	}
-
 	if id.Name == "" {
 		return fmt.Errorf("name is required")
 	}
 
-	var path []string
-	if c.restConfig.APIPath != "" {
-		path = append(path, c.restConfig.APIPath)
-	}
-	if kindInfo.Group == "" {
-		path = append(path, "api")
-	} else {
-		path = append(path, "apis", kindInfo.Group)
-	}
-	path = append(path, kindInfo.Version)
-
-	if id.Namespace != "" {
-		path = append(path, "namespaces", id.Namespace)
-	}
-	path = append(path, kindInfo.Resource)
-
-	path = append(path, id.Name)
-
-	url := c.restConfig.Host + "/" + strings.Join(path, "/")
-	klog.Infof("GET url is %v", url)
-
-	httpRequest, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("error building request: %w", err)
-	}
-	response, err := httpClient.Do(httpRequest)
-	if err != nil {
-		return fmt.Errorf("error from request: %w", err)
-	}
-	defer response.Body.Close()
-
-	b, err := io.ReadAll(response.Body)
+	b, err := c.do(ctx, request{
+		Method:   "GET",
+		URL:      c.buildURL(kindInfo, id.Namespace, id.Name, nil),
+		KindInfo: kindInfo,
+		Name:     id.Name,
+	})
 	if err != nil {
-		return fmt.Errorf("error reading response: %w", err)
-	}
-
-	klog.Infof("response is %v", string(b))
-
-	if response.StatusCode != 200 {
-		switch response.StatusCode {
-		case 404:
-			return apierrors.NewNotFound(kindInfo.GroupResource(), id.Name)
-		}
-		return fmt.Errorf("unexpected response %v", response.Status)
+		return err
 	}
 
 	parser := kubejson.UnmarshalOptions{}
@@ -149,162 +126,110 @@ func (c *Client) Get(ctx context.Context, id types.NamespacedName, obj proto.Mes
 	return nil
 }
 
-func TypedClient[T proto.Message](client *Client, obj T) *ResourceClient[T] {
+func (c *Client) Create(ctx context.Context, obj kube.Object) error {
+	metadata := obj.GetMetadata()
+
 	kindInfo := kube.GetKindInfo(obj)
 
-	return &ResourceClient[T]{
-		client:   client,
-		kindInfo: kindInfo,
-		proto:    obj,
+	if metadata.Name == "" {
+		return fmt.Errorf("name is required")
 	}
-}
-
-type ResourceClient[T any] struct {
-	client   *Client
-	kindInfo *kube.KindInfo
-	proto    proto.Message
-}
-
-func (c *ResourceClient[T]) List(ctx context.Context, namespace string) ([]T, error) {
-	restConfig := c.client.restConfig
-	kindInfo := c.kindInfo
 
-	httpClient, err := rest.HTTPClientFor(restConfig)
+	body, err := kubejson.Marshal(obj)
 	if err != nil {
-		return nil, err
-	}
-
-	var path []string
-	if restConfig.APIPath != "" {
-		path = append(path, restConfig.APIPath)
-	}
-	if kindInfo.Group == "" {
-		path = append(path, "api")
-	} else {
-		path = append(path, "apis", kindInfo.Group)
-	}
-	path = append(path, kindInfo.Version)
-
-	if namespace != "" {
-		path = append(path, "namespaces", namespace)
+		return fmt.Errorf("failed to marshal to JSON: %w", err)
 	}
-	path = append(path, kindInfo.Resource)
-
-	// path = append(path, id.Name)
 
-	url := restConfig.Host + "/" + strings.Join(path, "/")
-	klog.Infof("GET url is %v", url)
-
-	httpRequest, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error building request: %w", err)
-	}
-	response, err := httpClient.Do(httpRequest)
+	b, err := c.do(ctx, request{
+		Method:         "POST",
+		URL:            c.buildURL(kindInfo, metadata.Namespace, "", nil),
+		ContentType:    runtime.ContentTypeJSON,
+		Body:           body,
+		KindInfo:       kindInfo,
+		Name:           metadata.Name,
+		ExpectedStatus: []int{201},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error from request: %w", err)
+		return err
 	}
-	defer response.Body.Close()
 
-	b, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+	parser := kubejson.UnmarshalOptions{}
+	if err := parser.Unmarshal(b, obj); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
 	}
 
-	klog.Infof("response is %v", string(b))
-
-	if response.StatusCode != 200 {
-		switch response.StatusCode {
-		case 404:
-			return nil, apierrors.NewNotFound(kindInfo.GroupResource(), "")
-		}
-		return nil, fmt.Errorf("unexpected response %v", response.Status)
-	}
+	return nil
+}
 
-	elemType := reflect.TypeOf(c.proto)
-	listType := reflect.SliceOf(elemType)
-	listVal := reflect.New(listType).Elem()
-	list := listVal.Interface().([]T)
-	parser := kubejson.UnmarshalOptions{}
-	meta, err := parser.UnmarshalKubeList(b, c.proto, func(m proto.Message) {
-		list = append(list, m.(T))
-	})
-	if err != nil {
-		return nil, fmt.Errorf("error parsing response: %w", err)
-	}
-	klog.Infof("meta is %#v", meta)
+// Update replaces the object.
+// The update is conditional on metadata.resourceVersion: if the object has been changed since it was read,
+// the update fails with a Conflict error (check with apierrors.IsConflict).
+func (c *Client) Update(ctx context.Context, obj kube.Object) error {
+	return c.update(ctx, obj, "")
+}
 
-	return list, nil
+// UpdateStatus replaces the status of the object, through the status subresource.
+// Other changes to the object are ignored; as with Update, the write is conditional on metadata.resourceVersion.
+func (c *Client) UpdateStatus(ctx context.Context, obj kube.Object) error {
+	return c.update(ctx, obj, "status")
 }
 
-func (c *Client) Create(ctx context.Context, obj kube.Object) error {
+func (c *Client) update(ctx context.Context, obj kube.Object, subresource string) error {
 	metadata := obj.GetMetadata()
 
 	kindInfo := kube.GetKindInfo(obj)
 
-	httpClient, err := rest.HTTPClientFor(c.restConfig)
-	if err != nil {
-		return err
-	}
-
-	if metadata.Name == "" {
+	if metadata.GetName() == "" {
 		return fmt.Errorf("name is required")
 	}
 
-	var path []string
-	if c.restConfig.APIPath != "" {
-		path = append(path, c.restConfig.APIPath)
-	}
-	if kindInfo.Group == "" {
-		path = append(path, "api")
-	} else {
-		path = append(path, "apis", kindInfo.Group)
-	}
-	path = append(path, kindInfo.Version)
-
-	if metadata.Namespace != "" {
-		path = append(path, "namespaces", metadata.Namespace)
-	}
-	path = append(path, kindInfo.Resource)
-
-	url := c.restConfig.Host + "/" + strings.Join(path, "/")
-	klog.Infof("create url is %v", url)
-
 	body, err := kubejson.Marshal(obj)
 	if err != nil {
 		return fmt.Errorf("failed to marshal to JSON: %w", err)
 	}
 
-	klog.Infof("body is %v", string(body))
-
-	httpRequest, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	b, err := c.do(ctx, request{
+		Method:      "PUT",
+		URL:         c.buildSubresourceURL(kindInfo, metadata.GetNamespace(), metadata.GetName(), subresource, nil),
+		ContentType: runtime.ContentTypeJSON,
+		Body:        body,
+		KindInfo:    kindInfo,
+		Name:        metadata.GetName(),
+	})
 	if err != nil {
-		return fmt.Errorf("error building request: %w", err)
+		return err
 	}
-	httpRequest.Header.Add("Content-Type", runtime.ContentTypeJSON)
 
-	response, err := httpClient.Do(httpRequest)
-	if err != nil {
-		return fmt.Errorf("error from request: %w", err)
+	parser := kubejson.UnmarshalOptions{}
+	if err := parser.Unmarshal(b, obj); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
 	}
-	defer response.Body.Close()
 
-	b, err := io.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response: %w", err)
-	}
+	return nil
+}
 
-	klog.Infof("response is %v", string(b))
+// Patch applies a patch (of the given type, usually types.MergePatchType) to the object, and reads the result into out.
+func (c *Client) Patch(ctx context.Context, id types.NamespacedName, patchType types.PatchType, patch []byte, out proto.Message) error {
+	kindInfo := kube.GetKindInfo(out)
 
-	if response.StatusCode != 201 {
-		switch response.StatusCode {
-		case 404:
-			return apierrors.NewNotFound(kindInfo.GroupResource(), metadata.Name)
-		}
-		return fmt.Errorf("unexpected response %v", response.Status)
+	if id.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	b, err := c.do(ctx, request{
+		Method:      "PATCH",
+		URL:         c.buildURL(kindInfo, id.Namespace, id.Name, nil),
+		ContentType: string(patchType),
+		Body:        patch,
+		KindInfo:    kindInfo,
+		Name:        id.Name,
+	})
+	if err != nil {
+		return err
 	}
 
 	parser := kubejson.UnmarshalOptions{}
-	if err := parser.Unmarshal(b, obj); err != nil {
+	if err := parser.Unmarshal(b, out); err != nil {
 		return fmt.Errorf("error parsing response: %w", err)
 	}
 
@@ -317,89 +242,98 @@ type ApplyOptions struct {
 }
 
 func (c *Client) Apply(ctx context.Context, obj kube.Object, opt ApplyOptions, out kube.Object) error {
+	return c.apply(ctx, obj, "", opt, out)
+}
+
+// ApplyStatus performs a server-side apply of the status of the object, through the status subresource.
+// Only the status is applied; the object must already exist.
+func (c *Client) ApplyStatus(ctx context.Context, obj kube.Object, opt ApplyOptions, out kube.Object) error {
+	return c.apply(ctx, obj, "status", opt, out)
+}
+
+func (c *Client) apply(ctx context.Context, obj kube.Object, subresource string, opt ApplyOptions, out kube.Object) error {
 	metadata := obj.GetMetadata()
 
 	kindInfo := kube.GetKindInfo(obj)
 
-	httpClient, err := rest.HTTPClientFor(c.restConfig)
-	if err != nil {
-		return err
-	}
-
 	if metadata.Name == "" {
 		return fmt.Errorf("name is required")
 	}
 
-	var path []string
-	if c.restConfig.APIPath != "" {
-		path = append(path, c.restConfig.APIPath)
-	}
-	if kindInfo.Group == "" {
-		path = append(path, "api")
-	} else {
-		path = append(path, "apis", kindInfo.Group)
-	}
-	path = append(path, kindInfo.Version)
-
-	if metadata.Namespace != "" {
-		path = append(path, "namespaces", metadata.Namespace)
-	}
-	path = append(path, kindInfo.Resource)
-
-	path = append(path, metadata.Name)
-
 	params := make(url.Values)
 	params.Add("fieldManager", opt.FieldManager)
 	params.Add("force", strconv.FormatBool(opt.Force))
 
-	url := c.restConfig.Host + "/" + strings.Join(path, "/")
-	url += "?" + params.Encode()
-	klog.Infof("apply url is %v", url)
-
 	body, err := kubejson.Marshal(obj)
 	if err != nil {
 		return fmt.Errorf("failed to marshal to JSON: %w", err)
 	}
 
-	klog.Infof("body is %v", string(body))
-
-	httpRequest, err := http.NewRequest("PATCH", url, bytes.NewReader(body))
+	b, err := c.do(ctx, request{
+		Method: "PATCH",
+		URL:    c.buildSubresourceURL(kindInfo, metadata.Namespace, metadata.Name, subresource, params),
+		// Note that we use application/apply-patch+yaml, even though this is json.
+		// This is because JSON is valid yaml.
+		// TODO: Can we get k8s upstream to revisit this?
+		ContentType: string(types.ApplyPatchType),
+		Body:        body,
+		KindInfo:    kindInfo,
+		Name:        metadata.Name,
+		// 200 if existed already, 201 if created
+		ExpectedStatus: []int{200, 201},
+	})
 	if err != nil {
-		return fmt.Errorf("error building request: %w", err)
+		return err
 	}
-	// Note that we use application/apply-patch+yaml, even though this is json.
-	// This is because JSON is valid yaml.
-	// TODO: Can we get k8s upstream to revisit this?
-	httpRequest.Header.Add("Content-Type", "application/apply-patch+yaml")
 
-	response, err := httpClient.Do(httpRequest)
-	if err != nil {
-		return fmt.Errorf("error from request: %w", err)
+	if out != nil {
+		parser := kubejson.UnmarshalOptions{}
+		if err := parser.Unmarshal(b, out); err != nil {
+			return fmt.Errorf("error parsing response: %w", err)
+		}
 	}
-	defer response.Body.Close()
 
-	b, err := io.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response: %w", err)
-	}
+	return nil
+}
 
-	klog.Infof("response is %v", string(b))
+// DeleteOptions controls deletion.
+type DeleteOptions struct {
+	// Preconditions, if set, must match the object or the delete fails with a Conflict error.
+	Preconditions *metav1.Preconditions
 
-	// 200 if existed already, 201 if created
-	if response.StatusCode != 200 && response.StatusCode != 201 {
-		switch response.StatusCode {
-		case 404:
-			return apierrors.NewNotFound(kindInfo.GroupResource(), metadata.Name)
-		}
-		return fmt.Errorf("unexpected response %v", response.Status)
+	// PropagationPolicy controls garbage collection of dependent objects.
+	PropagationPolicy *metav1.DeletionPropagation
+}
+
+// Delete deletes the object of the same kind as obj with the given namespace and name.
+func (c *Client) Delete(ctx context.Context, id types.NamespacedName, obj proto.Message, opt DeleteOptions) error {
+	kindInfo := kube.GetKindInfo(obj)
+
+	if id.Name == "" {
+		return fmt.Errorf("name is required")
 	}
 
-	if out != nil {
-		parser := kubejson.UnmarshalOptions{}
-		if err := parser.Unmarshal(b, out); err != nil {
-			return fmt.Errorf("error parsing response: %w", err)
-		}
+	deleteOptions := &metav1.DeleteOptions{
+		TypeMeta:          metav1.TypeMeta{APIVersion: "v1", Kind: "DeleteOptions"},
+		Preconditions:     opt.Preconditions,
+		PropagationPolicy: opt.PropagationPolicy,
+	}
+	body, err := jsonMarshal(deleteOptions)
+	if err != nil {
+		return err
 	}
 
+	// 200 if deleted, 202 if deletion is in progress (e.g. finalizers)
+	if _, err := c.do(ctx, request{
+		Method:         "DELETE",
+		URL:            c.buildURL(kindInfo, id.Namespace, id.Name, nil),
+		ContentType:    runtime.ContentTypeJSON,
+		Body:           body,
+		KindInfo:       kindInfo,
+		Name:           id.Name,
+		ExpectedStatus: []int{200, 202},
+	}); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,443 @@
+package fakekube
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// We implement a simplified version of server-side apply field ownership:
+// ownership is tracked for leaf fields (lists are treated as atomic values),
+// and a field conflicts if another manager owns it (or a parent or child of it) and the value would change.
+
+// fieldPath is the path to a field, as a list of map keys.
+type fieldPath []string
+
+func (p fieldPath) String() string {
+	var sb strings.Builder
+	for _, k := range p {
+		sb.WriteString(".")
+		sb.WriteString(k)
+	}
+	return sb.String()
+}
+
+// overlaps returns true if one path is a prefix of the other.
+func (p fieldPath) overlaps(other fieldPath) bool {
+	n := len(p)
+	if len(other) < n {
+		n = len(other)
+	}
+	for i := 0; i < n; i++ {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldSet is a set of paths, keyed by their string form.
+type fieldSet map[string]fieldPath
+
+func (s fieldSet) add(p fieldPath) {
+	s[p.String()] = append(fieldPath(nil), p...)
+}
+
+// removeOverlapping removes any paths that overlap p, returning true if any were removed.
+func (s fieldSet) removeOverlapping(p fieldPath) bool {
+	removed := false
+	for k, owned := range s {
+		if owned.overlaps(p) {
+			delete(s, k)
+			removed = true
+		}
+	}
+	return removed
+}
+
+func (s fieldSet) ownsOverlapping(p fieldPath) bool {
+	for _, owned := range s {
+		if owned.overlaps(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// sorted returns the paths in a stable order.
+func (s fieldSet) sorted() []fieldPath {
+	var keys []string
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var paths []fieldPath
+	for _, k := range keys {
+		paths = append(paths, s[k])
+	}
+	return paths
+}
+
+// isServerField returns true for fields that are set by the server, and are not owned by managers.
+func isServerField(p fieldPath) bool {
+	switch p[0] {
+	case "apiVersion", "kind":
+		return true
+	case "metadata":
+		if len(p) == 1 {
+			return true
+		}
+		switch p[1] {
+		case "name", "namespace", "generateName", "uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp", "managedFields", "selfLink":
+			return true
+		}
+	}
+	return false
+}
+
+// leafFields returns the paths of the leaf values in the object; maps are descended into, other values are leaves.
+func leafFields(value map[string]any) fieldSet {
+	fields := make(fieldSet)
+	var visit func(prefix fieldPath, v any)
+	visit = func(prefix fieldPath, v any) {
+		if m, ok := v.(map[string]any); ok && len(m) != 0 {
+			for k, child := range m {
+				visit(append(prefix, k), child)
+			}
+			return
+		}
+		if len(prefix) != 0 && !isServerField(prefix) {
+			fields.add(prefix)
+		}
+	}
+	visit(nil, value)
+	return fields
+}
+
+// valueAt returns the value at the path, and whether it was found.
+func valueAt(value map[string]any, p fieldPath) (any, bool) {
+	var v any = value
+	for _, k := range p {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		v, ok = m[k]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// removeAt removes the value at the path, if it exists.
+func removeAt(value map[string]any, p fieldPath) {
+	m := value
+	for _, k := range p[:len(p)-1] {
+		child, ok := m[k].(map[string]any)
+		if !ok {
+			return
+		}
+		m = child
+	}
+	delete(m, p[len(p)-1])
+}
+
+// mergeApplied merges the applied configuration into the object; maps are merged, other values are replaced.
+func mergeApplied(value map[string]any, applied map[string]any) {
+	for k, v := range applied {
+		if appliedMap, ok := v.(map[string]any); ok {
+			if existing, ok := value[k].(map[string]any); ok {
+				mergeApplied(existing, appliedMap)
+				continue
+			}
+		}
+		value[k] = deepCopyValue(v)
+	}
+}
+
+// managedFieldsEntry is an entry in metadata.managedFields.
+type managedFieldsEntry struct {
+	Manager    string
+	Operation  string
+	APIVersion string
+	Time       string
+	// Subresource is "status" for writes to the status subresource, and empty otherwise.
+	Subresource string
+	Fields      fieldSet
+}
+
+// toFieldsV1 encodes the set in the FieldsV1 format, e.g. {"f:spec":{"f:replicas":{}}}.
+func (s fieldSet) toFieldsV1() map[string]any {
+	out := make(map[string]any)
+	for _, p := range s {
+		m := out
+		for _, k := range p {
+			child, ok := m["f:"+k].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				m["f:"+k] = child
+			}
+			m = child
+		}
+	}
+	return out
+}
+
+// fromFieldsV1 decodes a FieldsV1 value; list items (k: and v: keys) are treated as ownership of the whole list.
+func fromFieldsV1(fieldsV1 map[string]any) fieldSet {
+	fields := make(fieldSet)
+	var visit func(prefix fieldPath, m map[string]any)
+	visit = func(prefix fieldPath, m map[string]any) {
+		hasChildren := false
+		for k, v := range m {
+			if !strings.HasPrefix(k, "f:") {
+				continue
+			}
+			hasChildren = true
+			child, _ := v.(map[string]any)
+			visit(append(prefix, strings.TrimPrefix(k, "f:")), child)
+		}
+		if !hasChildren && len(prefix) != 0 {
+			fields.add(prefix)
+		}
+	}
+	visit(nil, fieldsV1)
+	return fields
+}
+
+// getManagedFields parses metadata.managedFields.
+func getManagedFields(value map[string]any) []*managedFieldsEntry {
+	if value == nil {
+		return nil
+	}
+	list, _ := metadata(value)["managedFields"].([]any)
+	var entries []*managedFieldsEntry
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		entry := &managedFieldsEntry{}
+		entry.Manager, _ = m["manager"].(string)
+		entry.Operation, _ = m["operation"].(string)
+		entry.APIVersion, _ = m["apiVersion"].(string)
+		entry.Time, _ = m["time"].(string)
+		entry.Subresource, _ = m["subresource"].(string)
+		fieldsV1, _ := m["fieldsV1"].(map[string]any)
+		entry.Fields = fromFieldsV1(fieldsV1)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// setManagedFields writes metadata.managedFields, dropping entries that no longer own any fields.
+func setManagedFields(value map[string]any, entries []*managedFieldsEntry) {
+	var list []any
+	for _, entry := range entries {
+		if len(entry.Fields) == 0 {
+			continue
+		}
+		m := map[string]any{
+			"manager":    entry.Manager,
+			"operation":  entry.Operation,
+			"apiVersion": entry.APIVersion,
+			"time":       entry.Time,
+			"fieldsType": "FieldsV1",
+			"fieldsV1":   entry.Fields.toFieldsV1(),
+		}
+		if entry.Subresource != "" {
+			m["subresource"] = entry.Subresource
+		}
+		list = append(list, m)
+	}
+	meta := metadata(value)
+	if len(list) == 0 {
+		delete(meta, "managedFields")
+	} else {
+		meta["managedFields"] = list
+	}
+}
+
+// findEntry returns the entry for the manager, operation and subresource, adding it if it does not exist.
+func findEntry(entries []*managedFieldsEntry, manager string, operation string, subresource string, apiVersion string) ([]*managedFieldsEntry, *managedFieldsEntry) {
+	for _, entry := range entries {
+		if entry.Manager == manager && entry.Operation == operation && entry.Subresource == subresource {
+			return entries, entry
+		}
+	}
+	entry := &managedFieldsEntry{
+		Manager:     manager,
+		Operation:   operation,
+		APIVersion:  apiVersion,
+		Subresource: subresource,
+		Fields:      make(fieldSet),
+	}
+	return append(entries, entry), entry
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// recordUpdate updates the managed fields for a non-apply write: the manager takes ownership of the fields it changed.
+func recordUpdate(oldValue map[string]any, newValue map[string]any, manager string, subresource string) {
+	entries := getManagedFields(oldValue)
+
+	oldFields := leafFields(oldValue)
+	newFields := leafFields(newValue)
+
+	changed := make(fieldSet)
+	for k, p := range newFields {
+		oldV, found := valueAt(oldValue, p)
+		if _, wasLeaf := oldFields[k]; !found || !wasLeaf {
+			changed.add(p)
+			continue
+		}
+		newV, _ := valueAt(newValue, p)
+		if !reflect.DeepEqual(oldV, newV) {
+			changed.add(p)
+		}
+	}
+	removed := make(fieldSet)
+	for k, p := range oldFields {
+		if _, found := newFields[k]; !found {
+			removed.add(p)
+		}
+	}
+
+	if len(changed) != 0 || len(removed) != 0 {
+		for _, entry := range entries {
+			for _, p := range changed {
+				entry.Fields.removeOverlapping(p)
+			}
+			for _, p := range removed {
+				entry.Fields.removeOverlapping(p)
+			}
+		}
+		if len(changed) != 0 {
+			apiVersion, _ := newValue["apiVersion"].(string)
+			var entry *managedFieldsEntry
+			entries, entry = findEntry(entries, manager, string(metav1.ManagedFieldsOperationUpdate), subresource, apiVersion)
+			for _, p := range changed {
+				entry.Fields.add(p)
+			}
+			entry.Time = now()
+		}
+	}
+
+	setManagedFields(newValue, entries)
+}
+
+// apply performs a server-side apply of the configuration by the manager, returning the new object.
+// live is nil if the object does not exist.
+func apply(info *requestInfo, live map[string]any, applied map[string]any, manager string, force bool) (map[string]any, error) {
+	entries := getManagedFields(live)
+	delete(metadata(applied), "managedFields")
+	appliedFields := leafFields(applied)
+
+	// Check for conflicts with other managers
+	conflicts := make(map[*managedFieldsEntry]fieldSet)
+	for _, entry := range entries {
+		if entry.Manager == manager && entry.Operation == string(metav1.ManagedFieldsOperationApply) && entry.Subresource == info.subresource {
+			continue
+		}
+		for _, p := range appliedFields {
+			if !entry.Fields.ownsOverlapping(p) {
+				continue
+			}
+			liveV, _ := valueAt(live, p)
+			appliedV, _ := valueAt(applied, p)
+			if reflect.DeepEqual(liveV, appliedV) {
+				// Shared ownership
+				continue
+			}
+			if conflicts[entry] == nil {
+				conflicts[entry] = make(fieldSet)
+			}
+			conflicts[entry].add(p)
+		}
+	}
+
+	if len(conflicts) != 0 {
+		if !force {
+			return nil, conflictError(info, entries, conflicts)
+		}
+		for entry, fields := range conflicts {
+			for _, p := range fields {
+				entry.Fields.removeOverlapping(p)
+			}
+		}
+	}
+
+	value := deepCopy(live)
+	if value == nil {
+		value = make(map[string]any)
+	}
+
+	apiVersion, _ := applied["apiVersion"].(string)
+	var entry *managedFieldsEntry
+	entries, entry = findEntry(entries, manager, string(metav1.ManagedFieldsOperationApply), info.subresource, apiVersion)
+
+	// Fields we previously applied but no longer do are removed, unless another manager also owns them
+	for k, p := range entry.Fields {
+		if _, found := appliedFields[k]; found {
+			continue
+		}
+		ownedByOthers := false
+		for _, other := range entries {
+			if other != entry && other.Fields.ownsOverlapping(p) {
+				ownedByOthers = true
+			}
+		}
+		if !ownedByOthers {
+			removeAt(value, p)
+		}
+	}
+
+	mergeApplied(value, applied)
+
+	if !reflect.DeepEqual(entry.Fields, appliedFields) {
+		entry.Fields = appliedFields
+		entry.Time = now()
+	}
+	setManagedFields(value, entries)
+	return value, nil
+}
+
+// conflictError builds the error returned by the apiserver for apply conflicts.
+func conflictError(info *requestInfo, entries []*managedFieldsEntry, conflicts map[*managedFieldsEntry]fieldSet) error {
+	var causes []metav1.StatusCause
+	var messages []string
+	for _, entry := range entries {
+		fields := conflicts[entry]
+		for _, p := range fields.sorted() {
+			message := fmt.Sprintf("conflict with %q", entry.Manager)
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: message,
+				Field:   p.String(),
+			})
+			messages = append(messages, message+": "+p.String())
+		}
+	}
+
+	gvr := info.resource.GroupVersionResource()
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    409,
+		Reason:  metav1.StatusReasonConflict,
+		Message: fmt.Sprintf("Apply failed with %d conflict(s): %s", len(causes), strings.Join(messages, "; ")),
+		Details: &metav1.StatusDetails{
+			Name:   info.name,
+			Group:  gvr.Group,
+			Kind:   gvr.Resource,
+			Causes: causes,
+		},
+	}}
+}
@@ -0,0 +1,705 @@
+package fakekube
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// object is a stored object, held as generic JSON values.
+type object struct {
+	namespace string
+	name      string
+	value     map[string]any
+}
+
+// key is the key of an object in the store.
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// metadata returns the metadata map of the value, creating it if needed.
+func metadata(value map[string]any) map[string]any {
+	m, ok := value["metadata"].(map[string]any)
+	if !ok {
+		m = make(map[string]any)
+		value["metadata"] = m
+	}
+	return m
+}
+
+func metadataString(value map[string]any, name string) string {
+	s, _ := metadata(value)[name].(string)
+	return s
+}
+
+// deepCopy copies a JSON value.
+func deepCopy(value map[string]any) map[string]any {
+	if value == nil {
+		return nil
+	}
+	return deepCopyValue(value).(map[string]any)
+}
+
+func deepCopyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, child := range v {
+			out[k] = deepCopyValue(child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, child := range v {
+			out[i] = deepCopyValue(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// decodeBody parses the request body as a JSON (or YAML) object.
+func decodeBody(r *http.Request) (map[string]any, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("error reading body: %v", err))
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		b, err = yaml.ToJSON(b)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("error parsing yaml body: %v", err))
+		}
+	}
+	var value map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("error parsing body: %v", err))
+	}
+	if value == nil {
+		value = make(map[string]any)
+	}
+	return value, nil
+}
+
+// fieldManager returns the manager of a write, defaulting from the user agent as the apiserver does.
+func fieldManager(r *http.Request) string {
+	if manager := r.URL.Query().Get("fieldManager"); manager != "" {
+		return manager
+	}
+	userAgent := r.UserAgent()
+	if i := strings.Index(userAgent, "/"); i != -1 {
+		userAgent = userAgent[:i]
+	}
+	return userAgent
+}
+
+func (s *Server) serveResource(w http.ResponseWriter, r *http.Request, info *requestInfo) {
+	if info.subresource != "" && !(info.subresource == "status" && info.resource.StatusSubresource) {
+		writeError(w, apierrors.NewNotFound(info.resource.GroupVersionResource().GroupResource(), info.name+"/"+info.subresource))
+		return
+	}
+
+	if r.Method == http.MethodGet && info.name == "" && r.URL.Query().Get("watch") == "true" {
+		s.serveWatch(w, r, info)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var statusCode int
+	var result any
+	var err error
+
+	switch {
+	case info.subresource != "" && (r.Method == http.MethodPost || r.Method == http.MethodDelete):
+		err = apierrors.NewMethodNotSupported(info.resource.GroupVersionResource().GroupResource(), r.Method)
+
+	case r.Method == http.MethodGet && info.name == "":
+		statusCode = http.StatusOK
+		result, err = s.list(r, info)
+
+	case r.Method == http.MethodGet:
+		statusCode = http.StatusOK
+		result, err = s.get(info)
+
+	case r.Method == http.MethodPost && info.name == "":
+		statusCode = http.StatusCreated
+		result, err = s.create(r, info)
+
+	case r.Method == http.MethodPut && info.name != "":
+		statusCode = http.StatusOK
+		result, err = s.update(r, info)
+
+	case r.Method == http.MethodPatch && info.name != "":
+		var created bool
+		result, created, err = s.patch(r, info)
+		statusCode = http.StatusOK
+		if created {
+			statusCode = http.StatusCreated
+		}
+
+	case r.Method == http.MethodDelete && info.name != "":
+		statusCode = http.StatusOK
+		result, err = s.delete(r, info)
+
+	default:
+		err = apierrors.NewMethodNotSupported(info.resource.GroupVersionResource().GroupResource(), r.Method)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, statusCode, result)
+}
+
+// objectsFor returns the objects of the resource; s.mutex must be held.
+func (s *Server) objectsFor(info *requestInfo) map[string]*object {
+	return s.objects[info.resource.GroupVersionResource()]
+}
+
+func (s *Server) notFound(info *requestInfo) error {
+	return apierrors.NewNotFound(info.resource.GroupVersionResource().GroupResource(), info.name)
+}
+
+func (s *Server) get(info *requestInfo) (map[string]any, error) {
+	obj := s.objectsFor(info)[key(info.namespace, info.name)]
+	if obj == nil {
+		return nil, s.notFound(info)
+	}
+	return deepCopy(obj.value), nil
+}
+
+func (s *Server) list(r *http.Request, info *requestInfo) (map[string]any, error) {
+	query := r.URL.Query()
+
+	labelSelector, fieldSelector, err := parseSelectors(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var limit int
+	if s := query.Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid limit %q", s))
+		}
+	}
+
+	// The continue token is the key of the last object returned; keys are returned in order.
+	after := ""
+	if token := query.Get("continue"); token != "" {
+		b, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid continue token %q", token))
+		}
+		after = string(b)
+	}
+
+	var keys []string
+	for k, obj := range s.objectsFor(info) {
+		if info.namespace != "" && obj.namespace != info.namespace {
+			continue
+		}
+		if after != "" && k <= after {
+			continue
+		}
+		if !matches(obj.value, labelSelector, fieldSelector) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	listMeta := map[string]any{
+		"resourceVersion": strconv.FormatInt(s.resourceVersion, 10),
+	}
+	if limit > 0 && len(keys) > limit {
+		listMeta["remainingItemCount"] = len(keys) - limit
+		keys = keys[:limit]
+		listMeta["continue"] = base64.RawURLEncoding.EncodeToString([]byte(keys[len(keys)-1]))
+	}
+
+	items := []any{}
+	for _, k := range keys {
+		items = append(items, deepCopy(s.objectsFor(info)[k].value))
+	}
+
+	return map[string]any{
+		"apiVersion": info.resource.APIVersion(),
+		"kind":       info.resource.Kind + "List",
+		"metadata":   listMeta,
+		"items":      items,
+	}, nil
+}
+
+// parseSelectors parses the labelSelector and fieldSelector parameters.
+func parseSelectors(r *http.Request) (labels.Selector, fields.Selector, error) {
+	query := r.URL.Query()
+	labelSelector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		return nil, nil, apierrors.NewBadRequest(fmt.Sprintf("invalid labelSelector: %v", err))
+	}
+	fieldSelector, err := fields.ParseSelector(query.Get("fieldSelector"))
+	if err != nil {
+		return nil, nil, apierrors.NewBadRequest(fmt.Sprintf("invalid fieldSelector: %v", err))
+	}
+	return labelSelector, fieldSelector, nil
+}
+
+// matches returns true if the object matches the selectors; only metadata.name and metadata.namespace are supported as fields.
+func matches(value map[string]any, labelSelector labels.Selector, fieldSelector fields.Selector) bool {
+	objLabels := make(labels.Set)
+	if m, ok := metadata(value)["labels"].(map[string]any); ok {
+		for k, v := range m {
+			objLabels[k], _ = v.(string)
+		}
+	}
+	if !labelSelector.Matches(objLabels) {
+		return false
+	}
+	objFields := fields.Set{"metadata.name": metadataString(value, "name"), "metadata.namespace": metadataString(value, "namespace")}
+	return fieldSelector.Matches(objFields)
+}
+
+// checkIdentity verifies that the body is for the object in the path, and fills in the type and namespace.
+func checkIdentity(info *requestInfo, value map[string]any) error {
+	if apiVersion, _ := value["apiVersion"].(string); apiVersion != "" && apiVersion != info.resource.APIVersion() {
+		return apierrors.NewBadRequest(fmt.Sprintf("apiVersion %q does not match the path (%q)", apiVersion, info.resource.APIVersion()))
+	}
+	if kind, _ := value["kind"].(string); kind != "" && kind != info.resource.Kind {
+		return apierrors.NewBadRequest(fmt.Sprintf("kind %q does not match the path (%q)", kind, info.resource.Kind))
+	}
+	value["apiVersion"] = info.resource.APIVersion()
+	value["kind"] = info.resource.Kind
+
+	meta := metadata(value)
+	if namespace, _ := meta["namespace"].(string); namespace != "" && namespace != info.namespace {
+		return apierrors.NewBadRequest("the namespace of the object does not match the namespace of the request")
+	}
+	if info.namespace != "" {
+		meta["namespace"] = info.namespace
+	}
+	if info.name != "" {
+		if name, _ := meta["name"].(string); name != "" && name != info.name {
+			return apierrors.NewBadRequest("the name of the object does not match the name of the request")
+		}
+		meta["name"] = info.name
+	}
+	return nil
+}
+
+// nextResourceVersion allocates a resourceVersion for a change; s.mutex must be held.
+func (s *Server) nextResourceVersion() string {
+	s.resourceVersion++
+	return strconv.FormatInt(s.resourceVersion, 10)
+}
+
+// store saves the object as a new version; s.mutex must be held.
+func (s *Server) store(info *requestInfo, value map[string]any) map[string]any {
+	meta := metadata(value)
+	meta["resourceVersion"] = s.nextResourceVersion()
+	name, _ := meta["name"].(string)
+
+	var oldValue map[string]any
+	if existing := s.objectsFor(info)[key(info.namespace, name)]; existing != nil {
+		oldValue = existing.value
+	}
+	s.objectsFor(info)[key(info.namespace, name)] = &object{
+		namespace: info.namespace,
+		name:      name,
+		value:     value,
+	}
+	s.recordEvent(info.resource.GroupVersionResource(), oldValue, value, false)
+	return deepCopy(value)
+}
+
+// remove deletes the object, and any objects that it owns; s.mutex must be held.
+func (s *Server) remove(gvr schema.GroupVersionResource, obj *object) map[string]any {
+	if s.objects[gvr][key(obj.namespace, obj.name)] != obj {
+		// Already removed (e.g. by garbage collection)
+		return nil
+	}
+	delete(s.objects[gvr], key(obj.namespace, obj.name))
+
+	// The delete event carries the resourceVersion of the delete
+	deleted := deepCopy(obj.value)
+	metadata(deleted)["resourceVersion"] = s.nextResourceVersion()
+	s.recordEvent(gvr, obj.value, deleted, true)
+
+	s.removeDependents(obj)
+
+	return deleted
+}
+
+// removeDependents deletes the objects with an ownerReference to owner, as the garbage collector would
+// (with background propagation); s.mutex must be held.
+func (s *Server) removeDependents(owner *object) {
+	uid := metadataString(owner.value, "uid")
+	if uid == "" {
+		return
+	}
+
+	type dependent struct {
+		gvr schema.GroupVersionResource
+		obj *object
+	}
+	var dependents []dependent
+	for gvr, objects := range s.objects {
+		for _, obj := range objects {
+			// Owners must be in the same namespace, or cluster-scoped
+			if owner.namespace != "" && obj.namespace != owner.namespace {
+				continue
+			}
+			ownerReferences, _ := metadata(obj.value)["ownerReferences"].([]any)
+			for _, ownerReference := range ownerReferences {
+				ref, _ := ownerReference.(map[string]any)
+				if ref["uid"] == uid {
+					dependents = append(dependents, dependent{gvr: gvr, obj: obj})
+					break
+				}
+			}
+		}
+	}
+
+	for _, dependent := range dependents {
+		s.remove(dependent.gvr, dependent.obj)
+	}
+}
+
+// insert stores a new object; s.mutex must be held.
+func (s *Server) insert(info *requestInfo, value map[string]any) (map[string]any, error) {
+	meta := metadata(value)
+	name, _ := meta["name"].(string)
+	if name == "" {
+		generateName, _ := meta["generateName"].(string)
+		if generateName == "" {
+			return nil, apierrors.NewInvalid(info.resource.GroupKind(), "", field.ErrorList{
+				field.Required(field.NewPath("metadata", "name"), "name or generateName is required"),
+			})
+		}
+		name = generateName + rand.String(5)
+		meta["name"] = name
+	}
+	if s.objectsFor(info)[key(info.namespace, name)] != nil {
+		return nil, apierrors.NewAlreadyExists(info.resource.GroupVersionResource().GroupResource(), name)
+	}
+
+	meta["uid"] = string(uuid.NewUUID())
+	meta["creationTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+	meta["generation"] = json.Number("1")
+	delete(meta, "deletionTimestamp")
+	return s.store(info, value), nil
+}
+
+func (s *Server) create(r *http.Request, info *requestInfo) (map[string]any, error) {
+	value, err := decodeBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIdentity(info, value); err != nil {
+		return nil, err
+	}
+	if rv := metadataString(value, "resourceVersion"); rv != "" {
+		return nil, apierrors.NewBadRequest("resourceVersion should not be set on objects to be created")
+	}
+	if info.resource.StatusSubresource {
+		// The status can only be set through the status subresource
+		delete(value, "status")
+	}
+	recordUpdate(nil, value, fieldManager(r), "")
+	return s.insert(info, value)
+}
+
+// replace stores a new version of an existing object, preserving the fields set by the server; s.mutex must be held.
+func (s *Server) replace(info *requestInfo, existing *object, value map[string]any) map[string]any {
+	meta := metadata(value)
+	existingMeta := metadata(existing.value)
+	for _, k := range []string{"uid", "creationTimestamp", "generation", "deletionTimestamp"} {
+		if v, found := existingMeta[k]; found {
+			meta[k] = v
+		} else {
+			delete(meta, k)
+		}
+	}
+
+	if reflect.DeepEqual(withoutMetadata(existing.value), withoutMetadata(value)) && reflect.DeepEqual(existingMeta, withResourceVersion(meta, existingMeta["resourceVersion"])) {
+		// No-op writes do not change the resourceVersion
+		return deepCopy(existing.value)
+	}
+
+	if !reflect.DeepEqual(existing.value["spec"], value["spec"]) {
+		generation, _ := strconv.ParseInt(fmt.Sprint(existingMeta["generation"]), 10, 64)
+		meta["generation"] = json.Number(strconv.FormatInt(generation+1, 10))
+	}
+	return s.store(info, value)
+}
+
+// preserveStatus implements the status subresource for a write of the full object:
+// writes to the object leave the status unchanged, and writes to the status change only the status.
+func preserveStatus(info *requestInfo, existing map[string]any, value map[string]any) map[string]any {
+	if !info.resource.StatusSubresource {
+		return value
+	}
+
+	if info.subresource == "status" {
+		out := deepCopy(existing)
+		if status, found := value["status"]; found {
+			out["status"] = status
+		} else {
+			delete(out, "status")
+		}
+		return out
+	}
+
+	if status, found := existing["status"]; found {
+		value["status"] = deepCopyValue(status)
+	} else {
+		delete(value, "status")
+	}
+	return value
+}
+
+// appliedFor returns the part of an apply configuration that the request can set:
+// only the status for the status subresource, and everything except the status for the object itself.
+func appliedFor(info *requestInfo, applied map[string]any) map[string]any {
+	if !info.resource.StatusSubresource {
+		return applied
+	}
+
+	if info.subresource == "status" {
+		meta := metadata(applied)
+		out := map[string]any{
+			"apiVersion": applied["apiVersion"],
+			"kind":       applied["kind"],
+			"metadata": map[string]any{
+				"name":      meta["name"],
+				"namespace": meta["namespace"],
+			},
+		}
+		if rv, found := meta["resourceVersion"]; found {
+			metadata(out)["resourceVersion"] = rv
+		}
+		if status, found := applied["status"]; found {
+			out["status"] = status
+		}
+		return out
+	}
+
+	delete(applied, "status")
+	return applied
+}
+
+func withoutMetadata(value map[string]any) map[string]any {
+	out := make(map[string]any, len(value))
+	for k, v := range value {
+		if k != "metadata" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func withResourceVersion(meta map[string]any, resourceVersion any) map[string]any {
+	out := make(map[string]any, len(meta))
+	for k, v := range meta {
+		out[k] = v
+	}
+	out["resourceVersion"] = resourceVersion
+	return out
+}
+
+// checkResourceVersion returns a Conflict error if the resourceVersion is set and does not match the object.
+func (s *Server) checkResourceVersion(info *requestInfo, existing *object, resourceVersion string) error {
+	if resourceVersion != "" && resourceVersion != metadataString(existing.value, "resourceVersion") {
+		return apierrors.NewConflict(info.resource.GroupVersionResource().GroupResource(), info.name,
+			fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+	return nil
+}
+
+func (s *Server) update(r *http.Request, info *requestInfo) (map[string]any, error) {
+	value, err := decodeBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIdentity(info, value); err != nil {
+		return nil, err
+	}
+
+	existing := s.objectsFor(info)[key(info.namespace, info.name)]
+	if existing == nil {
+		return nil, s.notFound(info)
+	}
+
+	resourceVersion := metadataString(value, "resourceVersion")
+	if resourceVersion == "" {
+		return nil, apierrors.NewInvalid(info.resource.GroupKind(), info.name, field.ErrorList{
+			field.Required(field.NewPath("metadata", "resourceVersion"), "must be specified for an update"),
+		})
+	}
+	if err := s.checkResourceVersion(info, existing, resourceVersion); err != nil {
+		return nil, err
+	}
+	value = preserveStatus(info, existing.value, value)
+	recordUpdate(existing.value, value, fieldManager(r), info.subresource)
+	return s.replace(info, existing, value), nil
+}
+
+// patch applies a merge patch or a server-side apply, returning true if the object was created.
+func (s *Server) patch(r *http.Request, info *requestInfo) (map[string]any, bool, error) {
+	contentType := r.Header.Get("Content-Type")
+	if i := strings.Index(contentType, ";"); i != -1 {
+		contentType = contentType[:i]
+	}
+
+	patch, err := decodeBody(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing := s.objectsFor(info)[key(info.namespace, info.name)]
+
+	switch contentType {
+	case string(types.MergePatchType):
+		if existing == nil {
+			return nil, false, s.notFound(info)
+		}
+		if err := s.checkResourceVersion(info, existing, metadataString(patch, "resourceVersion")); err != nil {
+			return nil, false, err
+		}
+		value := mergePatch(deepCopy(existing.value), patch).(map[string]any)
+		if err := checkIdentity(info, value); err != nil {
+			return nil, false, err
+		}
+		value = preserveStatus(info, existing.value, value)
+		recordUpdate(existing.value, value, fieldManager(r), info.subresource)
+		return s.replace(info, existing, value), false, nil
+
+	case string(types.ApplyPatchType):
+		if err := checkIdentity(info, patch); err != nil {
+			return nil, false, err
+		}
+		manager := r.URL.Query().Get("fieldManager")
+		if manager == "" {
+			return nil, false, apierrors.NewBadRequest("fieldManager is required for apply requests")
+		}
+		force := r.URL.Query().Get("force") == "true"
+
+		patch = appliedFor(info, patch)
+
+		var live map[string]any
+		if existing == nil && info.subresource != "" {
+			return nil, false, s.notFound(info)
+		}
+		if existing != nil {
+			if err := s.checkResourceVersion(info, existing, metadataString(patch, "resourceVersion")); err != nil {
+				return nil, false, err
+			}
+			live = existing.value
+		}
+		value, err := apply(info, live, patch, manager, force)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing == nil {
+			result, err := s.insert(info, value)
+			return result, true, err
+		}
+		return s.replace(info, existing, value), false, nil
+
+	default:
+		return nil, false, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnsupportedMediaType,
+			Reason:  metav1.StatusReasonUnsupportedMediaType,
+			Message: fmt.Sprintf("the content type %q is not supported", contentType),
+		}}
+	}
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386).
+func mergePatch(target any, patch any) any {
+	patchMap, ok := patch.(map[string]any)
+	if !ok {
+		return deepCopyValue(patch)
+	}
+	targetMap, ok := target.(map[string]any)
+	if !ok {
+		targetMap = make(map[string]any)
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = mergePatch(targetMap[k], v)
+	}
+	return targetMap
+}
+
+func (s *Server) delete(r *http.Request, info *requestInfo) (map[string]any, error) {
+	existing := s.objectsFor(info)[key(info.namespace, info.name)]
+	if existing == nil {
+		return nil, s.notFound(info)
+	}
+
+	var options metav1.DeleteOptions
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil && err != io.EOF {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("error parsing DeleteOptions: %v", err))
+		}
+	}
+	if preconditions := options.Preconditions; preconditions != nil {
+		gr := info.resource.GroupVersionResource().GroupResource()
+		if uid := preconditions.UID; uid != nil && string(*uid) != metadataString(existing.value, "uid") {
+			return nil, apierrors.NewConflict(gr, info.name,
+				fmt.Errorf("precondition failed: UID in precondition: %v, UID in object meta: %v", *uid, metadataString(existing.value, "uid")))
+		}
+		if rv := preconditions.ResourceVersion; rv != nil && *rv != metadataString(existing.value, "resourceVersion") {
+			return nil, apierrors.NewConflict(gr, info.name,
+				fmt.Errorf("precondition failed: ResourceVersion in precondition: %v, ResourceVersion in object meta: %v", *rv, metadataString(existing.value, "resourceVersion")))
+		}
+	}
+
+	deleted := s.remove(info.resource.GroupVersionResource(), existing)
+
+	// Deleting a namespace deletes the objects in it
+	if info.resource.Group == "" && info.resource.Resource == "namespaces" {
+		for gvr, objects := range s.objects {
+			if resource := s.findResource(gvr); resource == nil || !resource.Namespaced {
+				continue
+			}
+			for _, obj := range objects {
+				if obj.namespace == info.name {
+					s.remove(gvr, obj)
+				}
+			}
+		}
+	}
+
+	return deleted, nil
+}
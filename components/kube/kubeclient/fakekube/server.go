@@ -0,0 +1,376 @@
+package fakekube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// Resource describes a kind served by the fake apiserver.
+type Resource struct {
+	Group    string
+	Version  string
+	Resource string
+	Kind     string
+
+	// Namespaced is true if objects of this kind live in a namespace.
+	Namespaced bool
+
+	// StatusSubresource is true if the status is written through the status subresource,
+	// and is ignored in writes to the object itself (as for CRDs with subresources.status).
+	StatusSubresource bool
+}
+
+// GroupVersionResource returns the GroupVersionResource for the resource.
+func (r *Resource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// GroupKind returns the GroupKind for the resource.
+func (r *Resource) GroupKind() schema.GroupKind {
+	return schema.GroupKind{Group: r.Group, Kind: r.Kind}
+}
+
+// APIVersion returns the apiVersion of objects of this kind.
+func (r *Resource) APIVersion() string {
+	return schema.GroupVersion{Group: r.Group, Version: r.Version}.String()
+}
+
+// DefaultResources are the kinds that a Server serves unless others are registered:
+// the built-in kinds used by kweb, and the kweb custom resources.
+var DefaultResources = []Resource{
+	{Version: "v1", Resource: "namespaces", Kind: "Namespace"},
+	{Version: "v1", Resource: "secrets", Kind: "Secret", Namespaced: true},
+	{Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespaced: true},
+	{Group: "coordination.k8s.io", Version: "v1", Resource: "leases", Kind: "Lease", Namespaced: true},
+	{Group: "kweb.dev", Version: "v1alpha1", Resource: "users", Kind: "User", Namespaced: true},
+	{Group: "kweb.dev", Version: "v1alpha1", Resource: "sessions", Kind: "Session", Namespaced: true},
+	{Group: "kweb.dev", Version: "v1alpha1", Resource: "oauthsessions", Kind: "OauthSession", Namespaced: true},
+	{Group: "github.kweb.dev", Version: "v1alpha1", Resource: "appinstallations", Kind: "AppInstallation", Namespaced: true, StatusSubresource: true},
+}
+
+// Server is an in-process kubernetes apiserver, that stores objects in memory.
+// It serves the REST paths used by kubeclient.Client, client-go and controller-runtime,
+// implementing resourceVersion checks, server-side apply field ownership and label selectors.
+// It does not implement admission, validation or finalizers.
+type Server struct {
+	listener   net.Listener
+	httpServer *http.Server
+
+	mutex     sync.Mutex
+	resources []Resource
+	// objects holds the stored objects, keyed by resource and then by namespace/name.
+	objects map[schema.GroupVersionResource]map[string]*object
+	// resourceVersion is incremented on every change, as with etcd.
+	resourceVersion int64
+
+	// events holds the recent changes, after compactedResourceVersion.
+	events                   []*event
+	compactedResourceVersion int64
+	watchers                 map[*watcher]struct{}
+
+	wg sync.WaitGroup
+}
+
+// Start starts a server listening on a random localhost port, serving the DefaultResources.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("error listening: %w", err)
+	}
+	s := &Server{
+		listener: listener,
+		objects:  make(map[schema.GroupVersionResource]map[string]*object),
+		watchers: make(map[*watcher]struct{}),
+	}
+	for _, resource := range DefaultResources {
+		s.AddResource(resource)
+	}
+	s.httpServer = &http.Server{Handler: s}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Warningf("fakekube: error from server: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the host:port on which the server is listening.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	err := s.httpServer.Close()
+	s.wg.Wait()
+	return err
+}
+
+// RESTConfig returns the configuration for clients of the server.
+func (s *Server) RESTConfig() *rest.Config {
+	return &rest.Config{
+		Host: "http://" + s.Addr(),
+	}
+}
+
+// NewClient builds a kubeclient.Client connected to the server.
+func (s *Server) NewClient(scheme *runtime.Scheme) (*kubeclient.Client, error) {
+	if scheme == nil {
+		scheme = runtime.NewScheme()
+	}
+	return kubeclient.New(s.RESTConfig(), scheme)
+}
+
+// AddResource registers a kind, so that it can be stored and is returned by discovery.
+func (s *Server) AddResource(resource Resource) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	gvr := resource.GroupVersionResource()
+	for i := range s.resources {
+		if s.resources[i].GroupVersionResource() == gvr {
+			s.resources[i] = resource
+			return
+		}
+	}
+	s.resources = append(s.resources, resource)
+	s.objects[gvr] = make(map[string]*object)
+}
+
+// findResource returns the registered resource; s.mutex must be held.
+func (s *Server) findResource(gvr schema.GroupVersionResource) *Resource {
+	for i := range s.resources {
+		if s.resources[i].GroupVersionResource() == gvr {
+			return &s.resources[i]
+		}
+	}
+	return nil
+}
+
+// requestInfo is the parsed path of a request for a resource.
+type requestInfo struct {
+	resource    *Resource
+	namespace   string
+	name        string
+	subresource string
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	klog.V(4).Infof("fakekube: %s %s", r.Method, r.URL)
+
+	var segments []string
+	for _, segment := range strings.Split(r.URL.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	if r.Method == http.MethodGet {
+		if handled := s.serveDiscovery(w, segments); handled {
+			return
+		}
+	}
+
+	var gv schema.GroupVersion
+	switch {
+	case len(segments) >= 3 && segments[0] == "api":
+		gv = schema.GroupVersion{Version: segments[1]}
+		segments = segments[2:]
+	case len(segments) >= 4 && segments[0] == "apis":
+		gv = schema.GroupVersion{Group: segments[1], Version: segments[2]}
+		segments = segments[3:]
+	default:
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+
+	s.mutex.Lock()
+	info, err := s.parseResourcePath(gv, segments)
+	s.mutex.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.serveResource(w, r, info)
+}
+
+// parseResourcePath parses the path after the group and version; s.mutex must be held.
+func (s *Server) parseResourcePath(gv schema.GroupVersion, segments []string) (*requestInfo, error) {
+	info := &requestInfo{}
+
+	// namespaces/<ns>/<resource> is a namespaced path, unless it is a subresource of a namespace
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		if resource := s.findResource(gv.WithResource(segments[2])); resource != nil && resource.Namespaced {
+			info.namespace = segments[1]
+			segments = segments[2:]
+		}
+	}
+
+	if len(segments) > 3 {
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, strings.Join(segments, "/"))
+	}
+
+	gvr := gv.WithResource(segments[0])
+	info.resource = s.findResource(gvr)
+	if info.resource == nil {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+	}
+	if len(segments) >= 2 {
+		info.name = segments[1]
+	}
+	if len(segments) >= 3 {
+		info.subresource = segments[2]
+	}
+	if info.resource.Namespaced && info.name != "" && info.namespace == "" {
+		return nil, apierrors.NewBadRequest("the namespace of the object must be specified")
+	}
+	if !info.resource.Namespaced && info.namespace != "" {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), info.name)
+	}
+	return info, nil
+}
+
+// serveDiscovery serves the discovery endpoints, returning false if the path is not a discovery path.
+func (s *Server) serveDiscovery(w http.ResponseWriter, segments []string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case len(segments) == 1 && segments[0] == "version":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"major":      "1",
+			"minor":      "28",
+			"gitVersion": "v1.28.0-fakekube",
+		})
+		return true
+
+	case len(segments) == 1 && segments[0] == "api":
+		writeJSON(w, http.StatusOK, &metav1.APIVersions{
+			TypeMeta: metav1.TypeMeta{Kind: "APIVersions"},
+			Versions: []string{"v1"},
+			ServerAddressByClientCIDRs: []metav1.ServerAddressByClientCIDR{
+				{ClientCIDR: "0.0.0.0/0", ServerAddress: s.Addr()},
+			},
+		})
+		return true
+
+	case len(segments) == 1 && segments[0] == "apis":
+		groups := make(map[string][]string)
+		for _, resource := range s.resources {
+			if resource.Group == "" {
+				continue
+			}
+			versions := groups[resource.Group]
+			found := false
+			for _, version := range versions {
+				if version == resource.Version {
+					found = true
+				}
+			}
+			if !found {
+				groups[resource.Group] = append(versions, resource.Version)
+			}
+		}
+		list := &metav1.APIGroupList{
+			TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+		}
+		for group, versions := range groups {
+			apiGroup := metav1.APIGroup{Name: group}
+			for _, version := range versions {
+				apiGroup.Versions = append(apiGroup.Versions, metav1.GroupVersionForDiscovery{
+					GroupVersion: group + "/" + version,
+					Version:      version,
+				})
+			}
+			apiGroup.PreferredVersion = apiGroup.Versions[0]
+			list.Groups = append(list.Groups, apiGroup)
+		}
+		sort.Slice(list.Groups, func(i, j int) bool { return list.Groups[i].Name < list.Groups[j].Name })
+		writeJSON(w, http.StatusOK, list)
+		return true
+
+	case len(segments) == 2 && segments[0] == "api":
+		s.writeResourceList(w, schema.GroupVersion{Version: segments[1]})
+		return true
+
+	case len(segments) == 3 && segments[0] == "apis":
+		s.writeResourceList(w, schema.GroupVersion{Group: segments[1], Version: segments[2]})
+		return true
+	}
+	return false
+}
+
+// writeResourceList writes the discovery information for a group version; s.mutex must be held.
+func (s *Server) writeResourceList(w http.ResponseWriter, gv schema.GroupVersion) {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: gv.String(),
+	}
+	for _, resource := range s.resources {
+		if resource.Group != gv.Group || resource.Version != gv.Version {
+			continue
+		}
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:         resource.Resource,
+			SingularName: strings.ToLower(resource.Kind),
+			Namespaced:   resource.Namespaced,
+			Kind:         resource.Kind,
+			Verbs:        metav1.Verbs{"create", "delete", "get", "list", "patch", "update", "watch"},
+		})
+	}
+	if len(list.APIResources) == 0 {
+		writeError(w, apierrors.NewNotFound(schema.GroupResource{Group: gv.Group}, gv.Version))
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, obj any) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		klog.Warningf("fakekube: error marshaling response: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(b)
+}
+
+// writeError writes the error as a Status object, as the apiserver does.
+func writeError(w http.ResponseWriter, err error) {
+	status := statusFor(err)
+	code := int(status.Code)
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	writeJSON(w, code, status)
+}
+
+// statusFor returns the Status object for an error.
+func statusFor(err error) *metav1.Status {
+	apiStatus, ok := err.(apierrors.APIStatus)
+	if !ok {
+		apiStatus = apierrors.NewInternalError(err)
+	}
+	status := apiStatus.Status()
+	status.Kind = "Status"
+	status.APIVersion = "v1"
+	return &status
+}
@@ -0,0 +1,269 @@
+package fakekube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+var configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func startServer(t *testing.T) dynamic.NamespaceableResourceInterface {
+	t.Helper()
+	s, err := Start()
+	if err != nil {
+		t.Fatalf("error starting server: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	client, err := dynamic.NewForConfig(s.RESTConfig())
+	if err != nil {
+		t.Fatalf("error building client: %v", err)
+	}
+	return client.Resource(configMaps)
+}
+
+func newConfigMap(name string, labels map[string]string, data map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": name, "namespace": "default"},
+		"data":       data,
+	}}
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestGetMissingObject(t *testing.T) {
+	ctx := context.Background()
+	configMaps := startServer(t)
+
+	_, err := configMaps.Namespace("default").Get(ctx, "missing", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if got := apierrors.ReasonForError(err); got != metav1.StatusReasonNotFound {
+		t.Errorf("unexpected reason %q", got)
+	}
+}
+
+func TestUpdateConflict(t *testing.T) {
+	ctx := context.Background()
+	configMaps := startServer(t).Namespace("default")
+
+	created, err := configMaps.Create(ctx, newConfigMap("cm", nil, map[string]any{"a": "1"}), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+
+	updated := created.DeepCopy()
+	unstructured.SetNestedField(updated.Object, "2", "data", "a")
+	if _, err := configMaps.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error updating: %v", err)
+	}
+
+	// created still has the original resourceVersion
+	unstructured.SetNestedField(created.Object, "3", "data", "a")
+	_, err = configMaps.Update(ctx, created, metav1.UpdateOptions{})
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("expected Conflict, got %v", err)
+	}
+	if status, ok := err.(apierrors.APIStatus); !ok || status.Status().Code != 409 {
+		t.Errorf("expected status code 409, got %v", err)
+	}
+
+	live, err := configMaps.Get(ctx, "cm", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting: %v", err)
+	}
+	if got, _, _ := unstructured.NestedString(live.Object, "data", "a"); got != "2" {
+		t.Errorf("conflicting update was applied; data.a=%q", got)
+	}
+}
+
+func applyPatch(t *testing.T, manifest string) []byte {
+	t.Helper()
+	b, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		t.Fatalf("error converting yaml: %v", err)
+	}
+	return b
+}
+
+func TestApplyFieldOwnership(t *testing.T) {
+	ctx := context.Background()
+	configMaps := startServer(t).Namespace("default")
+
+	first := applyPatch(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: "1"
+  b: "1"
+`)
+	if _, err := configMaps.Patch(ctx, "cm", types.ApplyPatchType, first, metav1.PatchOptions{FieldManager: "first"}); err != nil {
+		t.Fatalf("error applying: %v", err)
+	}
+
+	second := applyPatch(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: "2"
+`)
+	_, err := configMaps.Patch(ctx, "cm", types.ApplyPatchType, second, metav1.PatchOptions{FieldManager: "second"})
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("expected Conflict when applying a field owned by another manager, got %v", err)
+	}
+
+	force := true
+	if _, err := configMaps.Patch(ctx, "cm", types.ApplyPatchType, second, metav1.PatchOptions{FieldManager: "second", Force: &force}); err != nil {
+		t.Fatalf("error force-applying: %v", err)
+	}
+
+	// first no longer owns data.a, so dropping it from first's configuration must not remove the field
+	firstWithoutA := applyPatch(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  b: "1"
+`)
+	live, err := configMaps.Patch(ctx, "cm", types.ApplyPatchType, firstWithoutA, metav1.PatchOptions{FieldManager: "first"})
+	if err != nil {
+		t.Fatalf("error applying: %v", err)
+	}
+	data, _, _ := unstructured.NestedStringMap(live.Object, "data")
+	if data["a"] != "2" || data["b"] != "1" {
+		t.Errorf("unexpected data %v", data)
+	}
+
+	// Removing a field from the configuration of its only owner removes it
+	secondWithoutA := applyPatch(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+`)
+	live, err = configMaps.Patch(ctx, "cm", types.ApplyPatchType, secondWithoutA, metav1.PatchOptions{FieldManager: "second"})
+	if err != nil {
+		t.Fatalf("error applying: %v", err)
+	}
+	data, _, _ = unstructured.NestedStringMap(live.Object, "data")
+	if _, found := data["a"]; found || data["b"] != "1" {
+		t.Errorf("unexpected data %v", data)
+	}
+
+	managers := map[string]bool{}
+	for _, entry := range live.GetManagedFields() {
+		managers[entry.Manager] = true
+		if entry.Operation != metav1.ManagedFieldsOperationApply {
+			t.Errorf("unexpected operation %q for %q", entry.Operation, entry.Manager)
+		}
+	}
+	if !managers["first"] {
+		t.Errorf("expected managedFields entry for first, got %v", live.GetManagedFields())
+	}
+}
+
+func TestListSelectors(t *testing.T) {
+	ctx := context.Background()
+	configMaps := startServer(t).Namespace("default")
+
+	for _, obj := range []*unstructured.Unstructured{
+		newConfigMap("a", map[string]string{"app": "x"}, nil),
+		newConfigMap("b", map[string]string{"app": "y"}, nil),
+		newConfigMap("c", map[string]string{"app": "x"}, nil),
+	} {
+		if _, err := configMaps.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+	}
+
+	grid := []struct {
+		options metav1.ListOptions
+		want    []string
+	}{
+		{options: metav1.ListOptions{}, want: []string{"a", "b", "c"}},
+		{options: metav1.ListOptions{LabelSelector: "app=x"}, want: []string{"a", "c"}},
+		{options: metav1.ListOptions{LabelSelector: "app!=x"}, want: []string{"b"}},
+		{options: metav1.ListOptions{FieldSelector: "metadata.name=b"}, want: []string{"b"}},
+		{options: metav1.ListOptions{LabelSelector: "app=x", FieldSelector: "metadata.name=c"}, want: []string{"c"}},
+	}
+	for _, g := range grid {
+		list, err := configMaps.List(ctx, g.options)
+		if err != nil {
+			t.Fatalf("error listing with %+v: %v", g.options, err)
+		}
+		var got []string
+		for _, item := range list.Items {
+			got = append(got, item.GetName())
+		}
+		if len(got) != len(g.want) {
+			t.Errorf("list with %+v: got %v, want %v", g.options, got, g.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != g.want[i] {
+				t.Errorf("list with %+v: got %v, want %v", g.options, got, g.want)
+				break
+			}
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	configMaps := startServer(t).Namespace("default")
+
+	list, err := configMaps.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing: %v", err)
+	}
+	w, err := configMaps.Watch(ctx, metav1.ListOptions{LabelSelector: "app=x", ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		t.Fatalf("error watching: %v", err)
+	}
+	defer w.Stop()
+
+	if _, err := configMaps.Create(ctx, newConfigMap("ignored", map[string]string{"app": "y"}, nil), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	created, err := configMaps.Create(ctx, newConfigMap("watched", map[string]string{"app": "x"}, map[string]any{"a": "0"}), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	unstructured.SetNestedField(created.Object, "1", "data", "a")
+	if _, err := configMaps.Update(ctx, created, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error updating: %v", err)
+	}
+	if err := configMaps.Delete(ctx, "watched", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error deleting: %v", err)
+	}
+
+	for _, want := range []watch.EventType{watch.Added, watch.Modified, watch.Deleted} {
+		select {
+		case event := <-w.ResultChan():
+			obj := event.Object.(*unstructured.Unstructured)
+			if event.Type != want || obj.GetName() != "watched" {
+				t.Fatalf("got event %v for %q, want %v for %q", event.Type, obj.GetName(), want, "watched")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %v event", want)
+		}
+	}
+}
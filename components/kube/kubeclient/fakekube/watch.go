@@ -0,0 +1,244 @@
+package fakekube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+// maxEvents is the number of changes we remember, so watches can start from an older resourceVersion.
+const maxEvents = 1000
+
+// event is a change to an object.
+type event struct {
+	gvr             schema.GroupVersionResource
+	resourceVersion int64
+
+	// oldValue is the value before the change, or nil if the object was created.
+	oldValue map[string]any
+	// value is the value after the change; for a delete it is the last value.
+	value   map[string]any
+	deleted bool
+}
+
+// watcher is an open watch request.
+type watcher struct {
+	gvr           schema.GroupVersionResource
+	namespace     string
+	labelSelector labels.Selector
+	fieldSelector fields.Selector
+
+	// events is closed if the watcher falls too far behind.
+	events chan watchEvent
+}
+
+// watchEvent is the wire format of a watch event.
+type watchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object any             `json:"object"`
+}
+
+// toWatchEvent returns the event as seen by the watcher, or false if the watcher should not see it.
+// Changes that move an object into or out of the selection are sent as adds and deletes.
+func (w *watcher) toWatchEvent(e *event) (watchEvent, bool) {
+	if e.gvr != w.gvr {
+		return watchEvent{}, false
+	}
+	if w.namespace != "" && metadataString(e.value, "namespace") != w.namespace {
+		return watchEvent{}, false
+	}
+
+	oldMatches := e.oldValue != nil && matches(e.oldValue, w.labelSelector, w.fieldSelector)
+	newMatches := matches(e.value, w.labelSelector, w.fieldSelector)
+
+	switch {
+	case e.deleted && oldMatches:
+		return watchEvent{Type: watch.Deleted, Object: deepCopy(e.value)}, true
+	case e.deleted:
+		return watchEvent{}, false
+	case newMatches && oldMatches:
+		return watchEvent{Type: watch.Modified, Object: deepCopy(e.value)}, true
+	case newMatches:
+		return watchEvent{Type: watch.Added, Object: deepCopy(e.value)}, true
+	case oldMatches:
+		return watchEvent{Type: watch.Deleted, Object: deepCopy(e.value)}, true
+	}
+	return watchEvent{}, false
+}
+
+// send queues an event for the watcher, returning false if the watcher has fallen behind.
+func (w *watcher) send(event watchEvent) bool {
+	select {
+	case w.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordEvent remembers the change, and sends it to watchers; s.mutex must be held.
+func (s *Server) recordEvent(gvr schema.GroupVersionResource, oldValue map[string]any, value map[string]any, deleted bool) {
+	e := &event{
+		gvr:             gvr,
+		resourceVersion: s.resourceVersion,
+		oldValue:        oldValue,
+		value:           value,
+		deleted:         deleted,
+	}
+
+	s.events = append(s.events, e)
+	if len(s.events) > maxEvents {
+		s.compactedResourceVersion = s.events[0].resourceVersion
+		s.events = s.events[1:]
+	}
+
+	for w := range s.watchers {
+		watchEvent, ok := w.toWatchEvent(e)
+		if !ok {
+			continue
+		}
+		if !w.send(watchEvent) {
+			klog.Warningf("fakekube: watcher fell behind; closing watch")
+			delete(s.watchers, w)
+			close(w.events)
+		}
+	}
+}
+
+// serveWatch streams changes to the matching objects.
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request, info *requestInfo) {
+	query := r.URL.Query()
+
+	labelSelector, fieldSelector, err := parseSelectors(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	timeout := 30 * time.Minute
+	if s := query.Get("timeoutSeconds"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			writeError(w, apierrors.NewBadRequest(fmt.Sprintf("invalid timeoutSeconds %q", s)))
+			return
+		}
+		timeout = time.Duration(n) * time.Second
+	}
+
+	watcher := &watcher{
+		gvr:           info.resource.GroupVersionResource(),
+		namespace:     info.namespace,
+		labelSelector: labelSelector,
+		fieldSelector: fieldSelector,
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	s.mutex.Lock()
+	var initial []watchEvent
+	resourceVersion := query.Get("resourceVersion")
+	if resourceVersion == "" || resourceVersion == "0" {
+		// Start with the current state, as synthetic adds
+		var keys []string
+		for k, obj := range s.objects[watcher.gvr] {
+			if watcher.namespace != "" && obj.namespace != watcher.namespace {
+				continue
+			}
+			if matches(obj.value, labelSelector, fieldSelector) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			initial = append(initial, watchEvent{Type: watch.Added, Object: deepCopy(s.objects[watcher.gvr][k].value)})
+		}
+	} else {
+		rv, err := strconv.ParseInt(resourceVersion, 10, 64)
+		if err != nil {
+			s.mutex.Unlock()
+			writeError(w, apierrors.NewBadRequest(fmt.Sprintf("invalid resourceVersion %q", resourceVersion)))
+			return
+		}
+		if compacted := s.compactedResourceVersion; rv < compacted {
+			s.mutex.Unlock()
+			// The apiserver reports this as an error event, rather than an error response
+			writeWatchHeader(w)
+			writeWatchEvent(w, watchEvent{Type: watch.Error, Object: statusFor(apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", rv, compacted)))})
+			flusher.Flush()
+			return
+		}
+		for _, e := range s.events {
+			if e.resourceVersion <= rv {
+				continue
+			}
+			if watchEvent, ok := watcher.toWatchEvent(e); ok {
+				initial = append(initial, watchEvent)
+			}
+		}
+	}
+	watcher.events = make(chan watchEvent, len(initial)+maxEvents)
+	for _, event := range initial {
+		watcher.send(event)
+	}
+	s.watchers[watcher] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.watchers, watcher)
+	}()
+
+	writeWatchHeader(w)
+	flusher.Flush()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+			return
+		case event, ok := <-watcher.events:
+			if !ok {
+				return
+			}
+			if err := writeWatchEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeWatchHeader(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeWatchEvent(w http.ResponseWriter, event watchEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,361 @@
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/justinsb/kweb/components/kube"
+	"google.golang.org/protobuf/proto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+// IndexFunc returns the index values for an object.
+type IndexFunc[T kube.Object] func(obj T) []string
+
+// Informer keeps an in-memory copy of all the objects of a kind, by listing and then watching for changes.
+// Reads wait until the initial list has completed; they return copies, which callers may modify.
+// Because the cache is updated asynchronously, a read may not reflect a write that has just completed.
+type Informer[T kube.Object] struct {
+	cache  *Cache
+	client *ResourceClient[T]
+
+	// startOnce starts the informer on first read
+	startOnce sync.Once
+	synced    chan struct{}
+
+	mutex   sync.RWMutex
+	objects map[types.NamespacedName]T
+	// indexers holds the index functions, by index name
+	indexers map[string]IndexFunc[T]
+	// indexes maps index name to index value to the matching objects
+	indexes map[string]map[string]map[types.NamespacedName]struct{}
+	// handlers are called with the key of each object that changes
+	handlers []func(id types.NamespacedName)
+}
+
+func newInformer[T kube.Object](cache *Cache, client *ResourceClient[T]) *Informer[T] {
+	return &Informer[T]{
+		cache:    cache,
+		client:   client,
+		synced:   make(chan struct{}),
+		objects:  make(map[types.NamespacedName]T),
+		indexers: make(map[string]IndexFunc[T]),
+		indexes:  make(map[string]map[string]map[types.NamespacedName]struct{}),
+	}
+}
+
+func keyOf(obj kube.Object) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: obj.GetMetadata().GetNamespace(),
+		Name:      obj.GetMetadata().GetName(),
+	}
+}
+
+// AddIndex adds an index, which can be queried with ByIndex.
+// Indexes can be added at any time; existing objects are indexed immediately.
+func (i *Informer[T]) AddIndex(name string, fn IndexFunc[T]) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.indexers[name] = fn
+	i.indexes[name] = make(map[string]map[types.NamespacedName]struct{})
+	for key, obj := range i.objects {
+		i.addToIndex(name, fn, key, obj)
+	}
+}
+
+// AddEventHandler registers a function that is called with the key of each object that is added, changed or deleted.
+// The function is called with the informer locked, so it must not block or read from the informer.
+func (i *Informer[T]) AddEventHandler(fn func(id types.NamespacedName)) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.handlers = append(i.handlers, fn)
+}
+
+// notify calls the event handlers; i.mutex must be held.
+func (i *Informer[T]) notify(id types.NamespacedName) {
+	for _, fn := range i.handlers {
+		fn(id)
+	}
+}
+
+// addToIndex adds the object to the index; i.mutex must be held.
+func (i *Informer[T]) addToIndex(name string, fn IndexFunc[T], key types.NamespacedName, obj T) {
+	index := i.indexes[name]
+	for _, value := range fn(obj) {
+		keys := index[value]
+		if keys == nil {
+			keys = make(map[types.NamespacedName]struct{})
+			index[value] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// store adds or replaces the object; i.mutex must be held.
+func (i *Informer[T]) store(obj T) {
+	key := keyOf(obj)
+	i.unindex(key)
+	i.objects[key] = obj
+	for name, fn := range i.indexers {
+		i.addToIndex(name, fn, key, obj)
+	}
+	i.notify(key)
+}
+
+// remove removes the object; i.mutex must be held.
+func (i *Informer[T]) remove(key types.NamespacedName) {
+	if i.unindex(key) {
+		i.notify(key)
+	}
+}
+
+// unindex removes the object from objects and the indexes, returning true if it was present; i.mutex must be held.
+func (i *Informer[T]) unindex(key types.NamespacedName) bool {
+	existing, found := i.objects[key]
+	if !found {
+		return false
+	}
+	delete(i.objects, key)
+	for name, fn := range i.indexers {
+		index := i.indexes[name]
+		for _, value := range fn(existing) {
+			delete(index[value], key)
+			if len(index[value]) == 0 {
+				delete(index, value)
+			}
+		}
+	}
+	return true
+}
+
+// replace replaces all the objects, after a list; i.mutex must be held.
+func (i *Informer[T]) replace(objects []T) {
+	// Objects that are not in the new list were deleted while we were not watching
+	removed := i.objects
+	i.objects = make(map[types.NamespacedName]T)
+	for name := range i.indexes {
+		i.indexes[name] = make(map[string]map[types.NamespacedName]struct{})
+	}
+	for _, obj := range objects {
+		delete(removed, keyOf(obj))
+		i.store(obj)
+	}
+	for key := range removed {
+		i.notify(key)
+	}
+}
+
+// Observe adds an object that we have just created or updated to the cache, if the watch has not yet reported that version.
+// This means that a read following a write will see the new object.
+func (i *Informer[T]) Observe(obj T) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if existing, found := i.objects[keyOf(obj)]; found && !isNewerVersion(obj, existing) {
+		return
+	}
+	i.store(proto.Clone(obj).(T))
+}
+
+// isNewerVersion returns true if obj has a later resourceVersion than existing.
+// Kubernetes says that resourceVersions are opaque, but in practice they are increasing integers;
+// if they are not, we trust the watch to deliver the latest version.
+func isNewerVersion(obj kube.Object, existing kube.Object) bool {
+	version, err := strconv.ParseUint(obj.GetMetadata().GetResourceVersion(), 10, 64)
+	if err != nil {
+		return false
+	}
+	existingVersion, err := strconv.ParseUint(existing.GetMetadata().GetResourceVersion(), 10, 64)
+	if err != nil {
+		return false
+	}
+	return version > existingVersion
+}
+
+// start starts the informer, if it is not already running.
+func (i *Informer[T]) start() {
+	i.startOnce.Do(func() {
+		go i.run(i.cache.context())
+	})
+}
+
+func (i *Informer[T]) run(ctx context.Context) {
+	kind := i.client.kindInfo.Kind
+	backoff := time.Second
+	for {
+		err := i.listAndWatch(ctx)
+		if ctx.Err() != nil {
+			klog.V(2).Infof("stopping informer for %v", kind)
+			return
+		}
+		if err == nil {
+			backoff = time.Second
+			continue
+		}
+
+		klog.Warningf("error watching %v (will retry in %v): %v", kind, backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// listAndWatch lists the objects, and then watches for changes until the watch fails.
+// It returns nil if we should list again immediately.
+func (i *Informer[T]) listAndWatch(ctx context.Context) error {
+	var objects []T
+	resourceVersion := ""
+	opt := ListOptions{Limit: 500}
+	for {
+		page, err := i.client.ListPage(ctx, "", opt)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, page.Items...)
+		if resourceVersion == "" {
+			resourceVersion = page.ResourceVersion
+		}
+		if page.Continue == "" {
+			break
+		}
+		opt.Continue = page.Continue
+	}
+
+	i.mutex.Lock()
+	i.replace(objects)
+	i.mutex.Unlock()
+
+	select {
+	case <-i.synced:
+	default:
+		close(i.synced)
+	}
+
+	for {
+		// Watches are ended periodically by the apiserver; we jitter so that we don't all reconnect at once
+		timeout := 5*time.Minute + time.Duration(rand.Int63n(int64(5*time.Minute)))
+		err := i.client.Watch(ctx, "", ListOptions{
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+			TimeoutSeconds:      int64(timeout / time.Second),
+		}, func(event WatchEvent[T]) error {
+			resourceVersion = event.Object.GetMetadata().GetResourceVersion()
+
+			i.mutex.Lock()
+			defer i.mutex.Unlock()
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				i.store(event.Object)
+			case watch.Deleted:
+				i.remove(keyOf(event.Object))
+			}
+			return nil
+		})
+		if err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				klog.V(2).Infof("watch of %v expired; listing again", i.client.kindInfo.Kind)
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// WaitForSync starts the informer if needed, and waits until the initial list has completed.
+func (i *Informer[T]) WaitForSync(ctx context.Context) error {
+	i.start()
+
+	select {
+	case <-i.synced:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get returns a copy of the object, or a NotFound error (check with apierrors.IsNotFound) if it does not exist.
+func (i *Informer[T]) Get(ctx context.Context, id types.NamespacedName) (T, error) {
+	var zero T
+	if err := i.WaitForSync(ctx); err != nil {
+		return zero, err
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	obj, found := i.objects[id]
+	if !found {
+		return zero, apierrors.NewNotFound(i.client.kindInfo.GroupResource(), id.Name)
+	}
+	return proto.Clone(obj).(T), nil
+}
+
+// List returns copies of the objects in the namespace (or in all namespaces if namespace is empty), sorted by namespace and name.
+func (i *Informer[T]) List(ctx context.Context, namespace string) ([]T, error) {
+	if err := i.WaitForSync(ctx); err != nil {
+		return nil, err
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	var keys []types.NamespacedName
+	for key := range i.objects {
+		if namespace == "" || key.Namespace == namespace {
+			keys = append(keys, key)
+		}
+	}
+	return i.cloneSorted(keys), nil
+}
+
+// ByIndex returns copies of the objects with the given value in the named index, sorted by namespace and name.
+func (i *Informer[T]) ByIndex(ctx context.Context, indexName string, value string) ([]T, error) {
+	if err := i.WaitForSync(ctx); err != nil {
+		return nil, err
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	index, found := i.indexes[indexName]
+	if !found {
+		return nil, fmt.Errorf("index %q not registered for %v", indexName, i.client.kindInfo.Kind)
+	}
+	var keys []types.NamespacedName
+	for key := range index[value] {
+		keys = append(keys, key)
+	}
+	return i.cloneSorted(keys), nil
+}
+
+// cloneSorted returns copies of the objects; i.mutex must be held.
+func (i *Informer[T]) cloneSorted(keys []types.NamespacedName) []T {
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].Namespace != keys[b].Namespace {
+			return keys[a].Namespace < keys[b].Namespace
+		}
+		return keys[a].Name < keys[b].Name
+	})
+	objects := make([]T, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, proto.Clone(i.objects[key]).(T))
+	}
+	return objects
+}
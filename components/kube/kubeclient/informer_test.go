@@ -0,0 +1,40 @@
+package kubeclient
+
+import (
+	"testing"
+
+	"github.com/justinsb/kweb/components/kube"
+	userapi "github.com/justinsb/kweb/components/users/pb"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestObserve(t *testing.T) {
+	informer := newInformer[*userapi.User](nil, nil)
+	id := types.NamespacedName{Namespace: "default", Name: "user1"}
+
+	newUser := func(resourceVersion string, email string) *userapi.User {
+		return &userapi.User{
+			Metadata: &kube.ObjectMeta{Namespace: id.Namespace, Name: id.Name, ResourceVersion: resourceVersion},
+			Spec:     &userapi.UserSpec{Email: email},
+		}
+	}
+	cachedEmail := func() string {
+		return informer.objects[id].GetSpec().GetEmail()
+	}
+
+	informer.Observe(newUser("10", "created@example.com"))
+	if got, want := cachedEmail(), "created@example.com"; got != want {
+		t.Errorf("after create: got %q, want %q", got, want)
+	}
+
+	informer.Observe(newUser("12", "updated@example.com"))
+	if got, want := cachedEmail(), "updated@example.com"; got != want {
+		t.Errorf("after update: got %q, want %q", got, want)
+	}
+
+	// The watch may already have delivered a later version
+	informer.Observe(newUser("11", "stale@example.com"))
+	if got, want := cachedEmail(), "updated@example.com"; got != want {
+		t.Errorf("after stale observe: got %q, want %q", got, want)
+	}
+}
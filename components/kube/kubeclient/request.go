@@ -0,0 +1,150 @@
+package kubeclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/justinsb/kweb/components/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// buildURL returns the URL for the resource, or for the named object if name is set.
+func (c *Client) buildURL(kindInfo *kube.KindInfo, namespace string, name string, params url.Values) string {
+	return c.buildSubresourceURL(kindInfo, namespace, name, "", params)
+}
+
+// buildSubresourceURL returns the URL for a subresource (e.g. status) of the named object, or for the object if subresource is empty.
+func (c *Client) buildSubresourceURL(kindInfo *kube.KindInfo, namespace string, name string, subresource string, params url.Values) string {
+	var path []string
+	if c.restConfig.APIPath != "" {
+		path = append(path, strings.Trim(c.restConfig.APIPath, "/"))
+	}
+	if kindInfo.Group == "" {
+		path = append(path, "api")
+	} else {
+		path = append(path, "apis", kindInfo.Group)
+	}
+	path = append(path, kindInfo.Version)
+
+	if namespace != "" {
+		path = append(path, "namespaces", namespace)
+	}
+	path = append(path, kindInfo.Resource)
+
+	if name != "" {
+		path = append(path, name)
+	}
+	if subresource != "" {
+		path = append(path, subresource)
+	}
+
+	u := strings.TrimSuffix(c.restConfig.Host, "/") + "/" + strings.Join(path, "/")
+	if len(params) != 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+// request is a request to the apiserver.
+type request struct {
+	Method      string
+	URL         string
+	ContentType string
+	Body        []byte
+
+	// KindInfo and Name are used to build errors.
+	KindInfo *kube.KindInfo
+	Name     string
+
+	// ExpectedStatus are the status codes for success; the default is 200.
+	ExpectedStatus []int
+}
+
+// do sends the request, returning the response body.
+// Error responses are returned as errors, as with errorFromResponse.
+func (c *Client) do(ctx context.Context, req request) ([]byte, error) {
+	response, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	klog.V(4).Infof("response is %v", string(b))
+
+	expectedStatus := req.ExpectedStatus
+	if len(expectedStatus) == 0 {
+		expectedStatus = []int{200}
+	}
+	for _, statusCode := range expectedStatus {
+		if response.StatusCode == statusCode {
+			return b, nil
+		}
+	}
+	return nil, errorFromResponse(response, b, req.KindInfo, req.Name)
+}
+
+// send sends the request, returning the response without checking the status.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	klog.V(2).Infof("%s %v", req.Method, req.URL)
+
+	var body io.Reader
+	if req.Body != nil {
+		klog.V(4).Infof("body is %v", string(req.Body))
+		body = bytes.NewReader(req.Body)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, req.Method, req.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("error building request: %w", err)
+	}
+	if req.ContentType != "" {
+		httpRequest.Header.Add("Content-Type", req.ContentType)
+	}
+	httpRequest.Header.Add("Accept", "application/json")
+
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("error from request: %w", err)
+	}
+	return response, nil
+}
+
+// errorFromResponse converts an error response to an error.
+// The apiserver returns a Status object, which we return as an apierrors.StatusError so callers can use apierrors.IsNotFound etc.
+func errorFromResponse(response *http.Response, body []byte, kindInfo *kube.KindInfo, name string) error {
+	status := &metav1.Status{}
+	if err := json.Unmarshal(body, status); err == nil && status.Kind == "Status" {
+		if status.Code == 0 {
+			status.Code = int32(response.StatusCode)
+		}
+		return &apierrors.StatusError{ErrStatus: *status}
+	}
+
+	switch response.StatusCode {
+	case 404:
+		return apierrors.NewNotFound(kindInfo.GroupResource(), name)
+	}
+	return fmt.Errorf("unexpected response %v", response.Status)
+}
+
+// jsonMarshal marshals a (non-proto) object for a request body.
+func jsonMarshal(obj any) ([]byte, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal to JSON: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,203 @@
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/kube/kubejson"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TypedClient[T kube.Object](client *Client, obj T) *ResourceClient[T] {
+	kindInfo := kube.GetKindInfo(obj)
+
+	return &ResourceClient[T]{
+		client:   client,
+		kindInfo: kindInfo,
+		proto:    obj,
+	}
+}
+
+// ResourceClient reads and writes objects of a single kind, decoded as proto type T.
+type ResourceClient[T kube.Object] struct {
+	client   *Client
+	kindInfo *kube.KindInfo
+	proto    proto.Message
+}
+
+// ListOptions filters and paginates lists and watches.
+type ListOptions struct {
+	// LabelSelector restricts the objects by label, e.g. "app=foo,tier!=frontend".
+	LabelSelector string
+
+	// FieldSelector restricts the objects by field; most kinds only support metadata.name and metadata.namespace.
+	FieldSelector string
+
+	// Limit is the maximum number of objects to return in a page; the default is no limit.
+	Limit int64
+
+	// Continue is the token returned by the previous page.
+	Continue string
+
+	// ResourceVersion is the version to list or watch from.
+	ResourceVersion string
+
+	// AllowWatchBookmarks requests bookmark events from a watch.
+	AllowWatchBookmarks bool
+
+	// TimeoutSeconds limits the duration of a watch.
+	TimeoutSeconds int64
+}
+
+func (o *ListOptions) params() url.Values {
+	params := make(url.Values)
+	if o.LabelSelector != "" {
+		params.Set("labelSelector", o.LabelSelector)
+	}
+	if o.FieldSelector != "" {
+		params.Set("fieldSelector", o.FieldSelector)
+	}
+	if o.Limit != 0 {
+		params.Set("limit", strconv.FormatInt(o.Limit, 10))
+	}
+	if o.Continue != "" {
+		params.Set("continue", o.Continue)
+	}
+	if o.ResourceVersion != "" {
+		params.Set("resourceVersion", o.ResourceVersion)
+	}
+	if o.AllowWatchBookmarks {
+		params.Set("allowWatchBookmarks", "true")
+	}
+	if o.TimeoutSeconds != 0 {
+		params.Set("timeoutSeconds", strconv.FormatInt(o.TimeoutSeconds, 10))
+	}
+	return params
+}
+
+// ListResult is a page of a list.
+type ListResult[T kube.Object] struct {
+	Items []T
+
+	// ResourceVersion is the version of the list, from which a watch can be started.
+	ResourceVersion string
+
+	// Continue is the token for the next page; it is empty on the last page.
+	Continue string
+}
+
+// newObject returns a new, empty object.
+func (c *ResourceClient[T]) newObject() T {
+	return c.proto.ProtoReflect().New().Interface().(T)
+}
+
+// Get reads an object, returning a NotFound error (check with apierrors.IsNotFound) if it does not exist.
+func (c *ResourceClient[T]) Get(ctx context.Context, id types.NamespacedName) (T, error) {
+	obj := c.newObject()
+	if err := c.client.Get(ctx, id, obj); err != nil {
+		var zero T
+		return zero, err
+	}
+	return obj, nil
+}
+
+// Create creates the object, updating it with the values set by the server.
+func (c *ResourceClient[T]) Create(ctx context.Context, obj T) error {
+	return c.client.Create(ctx, obj)
+}
+
+// Update replaces the object, updating it with the values set by the server.
+// The update fails with a Conflict error if the object has been changed since metadata.resourceVersion.
+func (c *ResourceClient[T]) Update(ctx context.Context, obj T) error {
+	return c.client.Update(ctx, obj)
+}
+
+// UpdateStatus replaces the status of the object, updating it with the values set by the server.
+func (c *ResourceClient[T]) UpdateStatus(ctx context.Context, obj T) error {
+	return c.client.UpdateStatus(ctx, obj)
+}
+
+// Patch applies a patch to an object, returning the patched object.
+func (c *ResourceClient[T]) Patch(ctx context.Context, id types.NamespacedName, patchType types.PatchType, patch []byte) (T, error) {
+	obj := c.newObject()
+	if err := c.client.Patch(ctx, id, patchType, patch, obj); err != nil {
+		var zero T
+		return zero, err
+	}
+	return obj, nil
+}
+
+// Apply performs a server-side apply of the object, returning the applied object.
+func (c *ResourceClient[T]) Apply(ctx context.Context, obj T, opt ApplyOptions) (T, error) {
+	out := c.newObject()
+	if err := c.client.Apply(ctx, obj, opt, out); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// ApplyStatus performs a server-side apply of the status of the object, returning the object.
+func (c *ResourceClient[T]) ApplyStatus(ctx context.Context, obj T, opt ApplyOptions) (T, error) {
+	out := c.newObject()
+	if err := c.client.ApplyStatus(ctx, obj, opt, out); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// Delete deletes an object.
+func (c *ResourceClient[T]) Delete(ctx context.Context, id types.NamespacedName, opt DeleteOptions) error {
+	return c.client.Delete(ctx, id, c.proto, opt)
+}
+
+// List returns all the objects in the namespace (or in all namespaces if namespace is empty).
+func (c *ResourceClient[T]) List(ctx context.Context, namespace string) ([]T, error) {
+	return c.ListAll(ctx, namespace, ListOptions{})
+}
+
+// ListAll returns all the matching objects, reading every page if opt.Limit is set.
+func (c *ResourceClient[T]) ListAll(ctx context.Context, namespace string, opt ListOptions) ([]T, error) {
+	var items []T
+	for {
+		page, err := c.ListPage(ctx, namespace, opt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if page.Continue == "" {
+			return items, nil
+		}
+		opt.Continue = page.Continue
+	}
+}
+
+// ListPage returns a page of the matching objects; pass the returned Continue token in opt.Continue to get the next page.
+func (c *ResourceClient[T]) ListPage(ctx context.Context, namespace string, opt ListOptions) (*ListResult[T], error) {
+	b, err := c.client.do(ctx, request{
+		Method:   "GET",
+		URL:      c.client.buildURL(c.kindInfo, namespace, "", opt.params()),
+		KindInfo: c.kindInfo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListResult[T]{}
+	parser := kubejson.UnmarshalOptions{}
+	meta, err := parser.UnmarshalKubeList(b, c.proto, func(m proto.Message) {
+		result.Items = append(result.Items, m.(T))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	result.ResourceVersion = meta.ResourceVersion
+	result.Continue = meta.Continue
+
+	return result, nil
+}
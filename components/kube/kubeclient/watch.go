@@ -0,0 +1,93 @@
+package kubeclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/kube/kubejson"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// WatchEvent is a change to an object.
+type WatchEvent[T kube.Object] struct {
+	// Type is Added, Modified, Deleted or Bookmark.
+	Type watch.EventType
+
+	// Object is the object after the change (the last version for a delete).
+	// For a bookmark, only metadata.resourceVersion is set.
+	Object T
+}
+
+// watchEventJSON is the wire format of a watch event.
+type watchEventJSON struct {
+	Type   watch.EventType `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// Watch streams changes to the matching objects, calling fn for each event.
+// It returns when the server ends the watch (returning nil), when ctx is done, or when fn returns an error.
+// If opt.ResourceVersion is too old the watch fails with an Expired error (apierrors.IsResourceExpired);
+// callers should then list again to get a current resourceVersion.
+func (c *ResourceClient[T]) Watch(ctx context.Context, namespace string, opt ListOptions, fn func(event WatchEvent[T]) error) error {
+	params := opt.params()
+	params.Set("watch", "true")
+
+	response, err := c.client.send(ctx, request{
+		Method:   "GET",
+		URL:      c.client.buildURL(c.kindInfo, namespace, "", params),
+		KindInfo: c.kindInfo,
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		b, err := io.ReadAll(response.Body)
+		if err != nil {
+			return fmt.Errorf("error reading response: %w", err)
+		}
+		return errorFromResponse(response, b, c.kindInfo, "")
+	}
+
+	parser := kubejson.UnmarshalOptions{}
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var event watchEventJSON
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("error reading watch event: %w", err)
+		}
+
+		switch event.Type {
+		case watch.Error:
+			status := &metav1.Status{}
+			if err := json.Unmarshal(event.Object, status); err != nil {
+				return fmt.Errorf("error parsing watch error: %w", err)
+			}
+			return &apierrors.StatusError{ErrStatus: *status}
+
+		case watch.Added, watch.Modified, watch.Deleted, watch.Bookmark:
+			obj := c.newObject()
+			if err := parser.Unmarshal(event.Object, obj); err != nil {
+				return fmt.Errorf("error parsing watch event: %w", err)
+			}
+			if err := fn(WatchEvent[T]{Type: event.Type, Object: obj}); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unknown watch event type %q", event.Type)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package kubecontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// Result controls what happens after a successful reconcile.
+type Result struct {
+	// RequeueAfter, if set, reconciles the object again after this interval.
+	RequeueAfter time.Duration
+}
+
+// ReconcileFunc reconciles the object with the given id.
+// obj is the current object from the cache, or nil if the object no longer exists.
+// Errors are logged and the object is retried with exponential backoff.
+type ReconcileFunc[T kube.Object] func(ctx context.Context, id types.NamespacedName, obj T) (Result, error)
+
+// Options configures a controller.
+type Options struct {
+	// Name identifies the controller in logs.
+	Name string
+
+	// Workers is the number of objects that are reconciled concurrently; defaults to 1.
+	Workers int
+
+	// ResyncPeriod is how often every object is reconciled, even if it has not changed; defaults to 10 minutes.
+	// A negative value disables periodic resync.
+	ResyncPeriod time.Duration
+}
+
+// InitDefaults sets the default options.
+func (o *Options) InitDefaults() {
+	o.Workers = 1
+	o.ResyncPeriod = 10 * time.Minute
+}
+
+// Controller reconciles objects of a kind when they change.
+// Changes are observed through the shared informer for the kind, and queued;
+// the queue is only processed while the controller is running (i.e. while we are the leader).
+type Controller[T kube.Object] struct {
+	options   Options
+	informer  *kubeclient.Informer[T]
+	reconcile ReconcileFunc[T]
+
+	mutex sync.Mutex
+	// queue is the queue of the current run, or nil if we are not running.
+	queue workqueue.RateLimitingInterface
+}
+
+// New builds a controller for objects of the same kind as obj.
+// It must be added to a Manager to be run.
+func New[T kube.Object](client *kubeclient.Client, obj T, opt Options, reconcile ReconcileFunc[T]) *Controller[T] {
+	var defaults Options
+	defaults.InitDefaults()
+	if opt.Name == "" {
+		opt.Name = kube.GetKindInfo(obj).Resource
+	}
+	if opt.Workers == 0 {
+		opt.Workers = defaults.Workers
+	}
+	if opt.ResyncPeriod == 0 {
+		opt.ResyncPeriod = defaults.ResyncPeriod
+	}
+
+	c := &Controller[T]{
+		options:   opt,
+		informer:  kubeclient.CachedClient(client, obj),
+		reconcile: reconcile,
+	}
+	c.informer.AddEventHandler(c.enqueue)
+	return c
+}
+
+// Name returns the name of the controller.
+func (c *Controller[T]) Name() string {
+	return c.options.Name
+}
+
+// Informer returns the shared informer that drives the controller; reconcilers can use it for cached reads.
+func (c *Controller[T]) Informer() *kubeclient.Informer[T] {
+	return c.informer
+}
+
+// enqueue queues the object for reconciliation, if we are running.
+func (c *Controller[T]) enqueue(id types.NamespacedName) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.queue != nil {
+		c.queue.Add(id)
+	}
+}
+
+// resync queues all the objects.
+func (c *Controller[T]) resync(ctx context.Context) {
+	objects, err := c.informer.List(ctx, "")
+	if err != nil {
+		if ctx.Err() == nil {
+			klog.Warningf("%s: error listing objects: %v", c.options.Name, err)
+		}
+		return
+	}
+	for _, obj := range objects {
+		c.enqueue(types.NamespacedName{Namespace: obj.GetMetadata().GetNamespace(), Name: obj.GetMetadata().GetName()})
+	}
+}
+
+// Run reconciles objects until ctx is cancelled.
+func (c *Controller[T]) Run(ctx context.Context) {
+	queue := workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(), workqueue.RateLimitingQueueConfig{
+		Name: c.options.Name,
+	})
+
+	c.mutex.Lock()
+	c.queue = queue
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		c.queue = nil
+		c.mutex.Unlock()
+	}()
+
+	klog.Infof("%s: starting controller", c.options.Name)
+
+	// Changes made while we were not running were not queued, so we start with a full resync
+	if err := c.informer.WaitForSync(ctx); err != nil {
+		queue.ShutDown()
+		return
+	}
+	go func() {
+		if c.options.ResyncPeriod < 0 {
+			c.resync(ctx)
+			return
+		}
+		wait.JitterUntilWithContext(ctx, c.resync, c.options.ResyncPeriod, 0.1, true)
+	}()
+
+	var wg sync.WaitGroup
+	for n := 0; n < c.options.Workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.processNextItem(ctx, queue) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	queue.ShutDown()
+	wg.Wait()
+	klog.Infof("%s: stopped controller", c.options.Name)
+}
+
+// processNextItem reconciles the next object in the queue, returning false when the queue is shut down.
+func (c *Controller[T]) processNextItem(ctx context.Context, queue workqueue.RateLimitingInterface) bool {
+	item, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(item)
+
+	id := item.(types.NamespacedName)
+	result, err := c.reconcileObject(ctx, id)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		klog.Warningf("%s: error reconciling %v (will retry): %v", c.options.Name, id, err)
+		queue.AddRateLimited(id)
+		return true
+	}
+
+	queue.Forget(id)
+	if result.RequeueAfter > 0 {
+		queue.AddAfter(id, result.RequeueAfter)
+	}
+	return true
+}
+
+func (c *Controller[T]) reconcileObject(ctx context.Context, id types.NamespacedName) (Result, error) {
+	obj, err := c.informer.Get(ctx, id)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return Result{}, fmt.Errorf("error getting object: %w", err)
+		}
+		var zero T
+		obj = zero
+	}
+	return c.reconcile(ctx, id, obj)
+}
@@ -0,0 +1,168 @@
+package kubecontroller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/kube/kubeclient/fakekube"
+	userapi "github.com/justinsb/kweb/components/users/pb"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileCall records a call to the reconcile function; email is empty if obj was nil.
+type reconcileCall struct {
+	id      types.NamespacedName
+	deleted bool
+	email   string
+}
+
+func newTestClient(t *testing.T) *kubeclient.Client {
+	t.Helper()
+	s, err := fakekube.Start()
+	if err != nil {
+		t.Fatalf("error starting fakekube: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	client, err := s.NewClient(nil)
+	if err != nil {
+		t.Fatalf("error building client: %v", err)
+	}
+	return client
+}
+
+// startController runs a controller for users, where reconcile fails failures[name] times for each object before it succeeds.
+// It returns a channel of the reconcile calls.
+func startController(t *testing.T, client *kubeclient.Client, failures map[string]int) <-chan reconcileCall {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := client.Cached().Start(ctx); err != nil {
+		t.Fatalf("error starting cache: %v", err)
+	}
+
+	var mutex sync.Mutex
+	calls := make(chan reconcileCall, 100)
+	c := New(client, &userapi.User{}, Options{Name: "test", ResyncPeriod: -1}, func(ctx context.Context, id types.NamespacedName, obj *userapi.User) (Result, error) {
+		calls <- reconcileCall{id: id, deleted: obj == nil, email: obj.GetSpec().GetEmail()}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if failures[id.Name] > 0 {
+			failures[id.Name]--
+			return Result{}, errors.New("injected failure")
+		}
+		return Result{}, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return calls
+}
+
+// waitForCall waits for a reconcile call that matches, ignoring others (such as the initial resync).
+func waitForCall(t *testing.T, calls <-chan reconcileCall, match func(call reconcileCall) bool) reconcileCall {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case call := <-calls:
+			if match(call) {
+				return call
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for reconcile")
+		}
+	}
+}
+
+func newUser(name string, email string) *userapi.User {
+	return &userapi.User{
+		Metadata: &kube.ObjectMeta{Namespace: "default", Name: name},
+		Spec:     &userapi.UserSpec{Email: email},
+	}
+}
+
+func TestReconcileOnChange(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	// Objects that exist before we start are reconciled by the initial resync
+	existing := newUser("existing", "existing@example.com")
+	if err := client.Create(ctx, existing); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	calls := startController(t, client, nil)
+	waitForCall(t, calls, func(call reconcileCall) bool {
+		return call.id.Name == "existing" && call.email == "existing@example.com"
+	})
+
+	user := newUser("user1", "created@example.com")
+	if err := client.Create(ctx, user); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	call := waitForCall(t, calls, func(call reconcileCall) bool { return call.id.Name == "user1" })
+	if call.id.Namespace != "default" || call.email != "created@example.com" {
+		t.Errorf("unexpected reconcile after create: %+v", call)
+	}
+
+	live := &userapi.User{}
+	if err := client.Get(ctx, call.id, live); err != nil {
+		t.Fatalf("error getting user: %v", err)
+	}
+	live.Spec.Email = "updated@example.com"
+	if err := client.Update(ctx, live); err != nil {
+		t.Fatalf("error updating user: %v", err)
+	}
+	waitForCall(t, calls, func(call reconcileCall) bool {
+		return call.id.Name == "user1" && call.email == "updated@example.com"
+	})
+}
+
+func TestReconcileRetriesErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	calls := startController(t, client, map[string]int{"flaky": 2})
+
+	if err := client.Create(ctx, newUser("flaky", "flaky@example.com")); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	// Two failures, and then the retry that succeeds
+	for i := 0; i < 3; i++ {
+		waitForCall(t, calls, func(call reconcileCall) bool { return call.id.Name == "flaky" })
+	}
+}
+
+func TestReconcileDeleted(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	calls := startController(t, client, nil)
+
+	user := newUser("doomed", "doomed@example.com")
+	if err := client.Create(ctx, user); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	waitForCall(t, calls, func(call reconcileCall) bool { return call.id.Name == "doomed" && !call.deleted })
+
+	id := types.NamespacedName{Namespace: "default", Name: "doomed"}
+	if err := client.Delete(ctx, id, &userapi.User{}, kubeclient.DeleteOptions{}); err != nil {
+		t.Fatalf("error deleting user: %v", err)
+	}
+	call := waitForCall(t, calls, func(call reconcileCall) bool { return call.id.Name == "doomed" && call.deleted })
+	if call.id != id {
+		t.Errorf("unexpected id %v for deleted object", call.id)
+	}
+}
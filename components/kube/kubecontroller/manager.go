@@ -0,0 +1,139 @@
+package kubecontroller
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/kube/leaderelection"
+	"github.com/justinsb/kweb/templates/scopes"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// Runnable is a background task that runs only on the leader.
+type Runnable interface {
+	// Name identifies the task in logs.
+	Name() string
+
+	// Run runs the task until ctx is cancelled, which happens when we lose the lease or shut down.
+	Run(ctx context.Context)
+}
+
+// ManagerOptions configures a Manager.
+type ManagerOptions struct {
+	// LeaderElection configures the lease; all the controllers in a process share a single lease.
+	LeaderElection leaderelection.Options
+
+	// DisableLeaderElection runs the controllers without a lease; only use this with a single replica.
+	DisableLeaderElection bool
+}
+
+// InitDefaults sets the default options.
+func (o *ManagerOptions) InitDefaults(appName string) {
+	o.LeaderElection.InitDefaults()
+	o.LeaderElection.Namespace = appName
+	o.LeaderElection.Name = appName + "-controllers"
+}
+
+// Manager runs controllers and periodic tasks in the background, on whichever replica holds the lease.
+type Manager struct {
+	kube    *kubeclient.Client
+	options ManagerOptions
+
+	mutex     sync.Mutex
+	runnables []Runnable
+}
+
+var _ components.Component = &Manager{}
+var _ components.Runnable = &Manager{}
+
+// NewManager builds a Manager; controllers should be added before the manager is started.
+func NewManager(kube *kubeclient.Client, opt ManagerOptions) *Manager {
+	return &Manager{
+		kube:    kube,
+		options: opt,
+	}
+}
+
+// Add adds a controller (or other task) to the manager.
+func (m *Manager) Add(runnable Runnable) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.runnables = append(m.runnables, runnable)
+}
+
+// AddPeriodic adds a task that is called every interval (with jitter), starting when we become the leader.
+// Errors are logged, and the task is retried at the next interval.
+func (m *Manager) AddPeriodic(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	m.Add(&periodicTask{name: name, interval: interval, fn: fn})
+}
+
+func (m *Manager) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
+	return nil
+}
+
+func (m *Manager) AddToScope(ctx context.Context, scope *scopes.Scope) {
+}
+
+// Start runs the controllers in the background; it does not block.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mutex.Lock()
+	runnables := append([]Runnable(nil), m.runnables...)
+	m.mutex.Unlock()
+
+	if len(runnables) == 0 {
+		return nil
+	}
+
+	if m.options.DisableLeaderElection {
+		go m.run(ctx, runnables)
+		return nil
+	}
+
+	go func() {
+		if err := leaderelection.Run(ctx, m.kube.RESTConfig(), m.options.LeaderElection, func(ctx context.Context) {
+			m.run(ctx, runnables)
+		}); err != nil {
+			klog.Warningf("error running controllers: %v", err)
+		}
+	}()
+	return nil
+}
+
+// run runs the tasks until ctx is cancelled.
+func (m *Manager) run(ctx context.Context, runnables []Runnable) {
+	var wg sync.WaitGroup
+	for _, runnable := range runnables {
+		runnable := runnable
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runnable.Run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// periodicTask is a Runnable that calls a function periodically.
+type periodicTask struct {
+	name     string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+}
+
+func (t *periodicTask) Name() string {
+	return t.name
+}
+
+func (t *periodicTask) Run(ctx context.Context) {
+	wait.JitterUntilWithContext(ctx, func(ctx context.Context) {
+		if err := t.fn(ctx); err != nil {
+			klog.Warningf("%s: error running periodic task: %v", t.name, err)
+		}
+	}, t.interval, 0.1, true)
+}
@@ -0,0 +1,180 @@
+package kubecrypt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/justinsb/kweb/components/keystore"
+	"github.com/justinsb/kweb/components/kube"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// encryptedPrefix marks encrypted values; values without the prefix were stored before the field was marked sensitive.
+const encryptedPrefix = "enc:v1:"
+
+// Encrypt encrypts the string fields of obj marked with the (kube.sensitive) option, in place.
+// Fields that are already encrypted are left unchanged.
+func Encrypt(obj proto.Message, keys keystore.KeySet) error {
+	return visitSensitive(obj.ProtoReflect(), func(value string) (string, error) {
+		if IsEncrypted(value) {
+			return value, nil
+		}
+		return encrypt(value, keys)
+	})
+}
+
+// Decrypt decrypts the string fields of obj marked with the (kube.sensitive) option, in place.
+// Fields that are not encrypted are left unchanged.
+func Decrypt(obj proto.Message, keys keystore.KeySet) error {
+	return visitSensitive(obj.ProtoReflect(), func(value string) (string, error) {
+		if !IsEncrypted(value) {
+			return value, nil
+		}
+		return decrypt(value, keys)
+	})
+}
+
+// Reencrypt encrypts the sensitive fields of obj that are not encrypted with the active key: fields encrypted
+// with a previous version of the key, and fields that are not encrypted.
+// It returns true if any field was changed.
+func Reencrypt(obj proto.Message, keys keystore.KeySet) (bool, error) {
+	activeKey, err := keys.ActiveKey()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	err = visitSensitive(obj.ProtoReflect(), func(value string) (string, error) {
+		if IsEncrypted(value) {
+			keyID, err := encryptedKeyID(value)
+			if err != nil {
+				return "", err
+			}
+			if keyID == activeKey.KeyID() {
+				return value, nil
+			}
+			value, err = decrypt(value, keys)
+			if err != nil {
+				return "", err
+			}
+		}
+		changed = true
+		return encrypt(value, keys)
+	})
+	return changed, err
+}
+
+// IsEncrypted returns true if the value was encrypted by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+func encrypt(plaintext string, keys keystore.KeySet) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	ciphertext, err := keys.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("error encrypting field: %w", err)
+	}
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+func decodeEncrypted(value string) ([]byte, error) {
+	ciphertext, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding encrypted field: %w", err)
+	}
+	return ciphertext, nil
+}
+
+func decrypt(value string, keys keystore.KeySet) (string, error) {
+	ciphertext, err := decodeEncrypted(value)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := keys.AuthenticateAndDecrypt(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func encryptedKeyID(value string) (int32, error) {
+	ciphertext, err := decodeEncrypted(value)
+	if err != nil {
+		return 0, err
+	}
+	return keystore.EncryptedKeyID(ciphertext)
+}
+
+// visitSensitive calls fn for each value of the sensitive string fields of msg (including nested messages), replacing the value with the result.
+func visitSensitive(msg protoreflect.Message, fn func(value string) (string, error)) error {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if !msg.Has(field) {
+			continue
+		}
+
+		sensitive := kube.IsSensitive(field)
+		if sensitive && field.Kind() != protoreflect.StringKind && !(field.IsMap() && field.MapValue().Kind() == protoreflect.StringKind) {
+			return fmt.Errorf("field %v is marked sensitive, but only string fields are supported", field.FullName())
+		}
+
+		switch {
+		case field.IsMap():
+			m := msg.Mutable(field).Map()
+			// We collect the entries first, because we must not change the map while ranging over it
+			var keys []protoreflect.MapKey
+			m.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				keys = append(keys, k)
+				return true
+			})
+			for _, k := range keys {
+				if sensitive {
+					s, err := fn(m.Get(k).String())
+					if err != nil {
+						return err
+					}
+					m.Set(k, protoreflect.ValueOfString(s))
+				} else if field.MapValue().Message() != nil {
+					if err := visitSensitive(m.Get(k).Message(), fn); err != nil {
+						return err
+					}
+				}
+			}
+
+		case field.IsList():
+			l := msg.Mutable(field).List()
+			for j := 0; j < l.Len(); j++ {
+				if sensitive {
+					s, err := fn(l.Get(j).String())
+					if err != nil {
+						return err
+					}
+					l.Set(j, protoreflect.ValueOfString(s))
+				} else if field.Message() != nil {
+					if err := visitSensitive(l.Get(j).Message(), fn); err != nil {
+						return err
+					}
+				}
+			}
+
+		case sensitive:
+			s, err := fn(msg.Get(field).String())
+			if err != nil {
+				return err
+			}
+			msg.Set(field, protoreflect.ValueOfString(s))
+
+		case field.Message() != nil:
+			if err := visitSensitive(msg.Mutable(field).Message(), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
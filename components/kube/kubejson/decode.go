@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/justinsb/kweb/components/kube/kubejson/internal/encoding/json"
+	"github.com/justinsb/kweb/components/kube/kubejson/internal/genid"
 	"github.com/justinsb/kweb/components/kube/kubejson/internal/set"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
@@ -109,9 +110,9 @@ func (d decoder) syntaxError(pos int, f string, x ...interface{}) error {
 
 // unmarshalMessage unmarshals a message into the given protoreflect.Message.
 func (d decoder) unmarshalMessage(m protoreflect.Message, skipTypeURL bool) error {
-	// if unmarshal := wellKnownTypeUnmarshaler(m.Descriptor().FullName()); unmarshal != nil {
-	// 	return unmarshal(d, m)
-	// }
+	if unmarshal := wellKnownTypeUnmarshaler(m.Descriptor().FullName()); unmarshal != nil {
+		return unmarshal(d, m)
+	}
 
 	tok, err := d.Read()
 	if err != nil {
@@ -145,13 +146,13 @@ func (d decoder) unmarshalMessage(m protoreflect.Message, skipTypeURL bool) erro
 		}
 
 		name := tok.Name()
-		// // Unmarshaling a non-custom embedded message in Any will contain the
-		// // JSON field "@type" which should be skipped because it is not a field
-		// // of the embedded message, but simply an artifact of the Any format.
-		// if skipTypeURL && name == "@type" {
-		// 	d.Read()
-		// 	continue
-		// }
+		// Unmarshaling a non-custom embedded message in Any will contain the
+		// JSON field "@type" which should be skipped because it is not a field
+		// of the embedded message, but simply an artifact of the Any format.
+		if skipTypeURL && name == "@type" {
+			d.Read()
+			continue
+		}
 
 		// Get the FieldDescriptor.
 		var fd protoreflect.FieldDescriptor
@@ -195,8 +196,8 @@ func (d decoder) unmarshalMessage(m protoreflect.Message, skipTypeURL bool) erro
 				continue
 			}
 
-			// TODO: Handle these fields properly (there is google.protobuf.Timestamp)
-			if name == "creationTimestamp" || name == "generation" || name == "managedFields" {
+			// managedFields are only needed for server-side apply; selfLink is deprecated
+			if name == "managedFields" || name == "selfLink" {
 				if err := d.skipJSONValue(); err != nil {
 					return err
 				}
@@ -221,7 +222,7 @@ func (d decoder) unmarshalMessage(m protoreflect.Message, skipTypeURL bool) erro
 
 		// No need to set values for JSON null unless the field type is
 		// google.protobuf.Value or google.protobuf.NullValue.
-		if tok, _ := d.Peek(); tok.Kind() == json.Null { //&& !isKnownValue(fd) && !isNullValue(fd) {
+		if tok, _ := d.Peek(); tok.Kind() == json.Null && !isKnownValue(fd) && !isNullValue(fd) {
 			d.Read()
 			continue
 		}
@@ -255,15 +256,15 @@ func (d decoder) unmarshalMessage(m protoreflect.Message, skipTypeURL bool) erro
 	}
 }
 
-// func isKnownValue(fd protoreflect.FieldDescriptor) bool {
-// 	md := fd.Message()
-// 	return md != nil && md.FullName() == genid.Value_message_fullname
-// }
+func isKnownValue(fd protoreflect.FieldDescriptor) bool {
+	md := fd.Message()
+	return md != nil && md.FullName() == genid.Value_message_fullname
+}
 
-// func isNullValue(fd protoreflect.FieldDescriptor) bool {
-// 	ed := fd.Enum()
-// 	return ed != nil && ed.FullName() == genid.NullValue_enum_fullname
-// }
+func isNullValue(fd protoreflect.FieldDescriptor) bool {
+	ed := fd.Enum()
+	return ed != nil && ed.FullName() == genid.NullValue_enum_fullname
+}
 
 // unmarshalSingular unmarshals to the non-repeated field specified
 // by the given FieldDescriptor.
@@ -505,9 +506,9 @@ func unmarshalEnum(tok json.Token, fd protoreflect.FieldDescriptor) (protoreflec
 
 	case json.Null:
 		// This is only valid for google.protobuf.NullValue.
-		// if isNullValue(fd) {
-		// 	return protoreflect.ValueOfEnum(0), true
-		// }
+		if isNullValue(fd) {
+			return protoreflect.ValueOfEnum(0), true
+		}
 	}
 
 	return protoreflect.Value{}, false
@@ -0,0 +1,524 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: components/kube/kubejson/internal/testpb/argocd.proto
+
+package testpb
+
+import (
+	kube "github.com/justinsb/kweb/components/kube"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Application struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Typemeta *kube.TypeMeta   `protobuf:"bytes,1,opt,name=typemeta,proto3" json:"typemeta,omitempty"`
+	Metadata *kube.ObjectMeta `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Spec     *ApplicationSpec `protobuf:"bytes,3,opt,name=spec,proto3" json:"spec,omitempty"`
+}
+
+func (x *Application) Reset() {
+	*x = Application{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Application) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Application) ProtoMessage() {}
+
+func (x *Application) ProtoReflect() protoreflect.Message {
+	mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Application.ProtoReflect.Descriptor instead.
+func (*Application) Descriptor() ([]byte, []int) {
+	return file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Application) GetTypemeta() *kube.TypeMeta {
+	if x != nil {
+		return x.Typemeta
+	}
+	return nil
+}
+
+func (x *Application) GetMetadata() *kube.ObjectMeta {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Application) GetSpec() *ApplicationSpec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+type ApplicationSpec struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Project     string                  `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Source      *ApplicationSource      `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Destination *ApplicationDestination `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+}
+
+func (x *ApplicationSpec) Reset() {
+	*x = ApplicationSpec{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ApplicationSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplicationSpec) ProtoMessage() {}
+
+func (x *ApplicationSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplicationSpec.ProtoReflect.Descriptor instead.
+func (*ApplicationSpec) Descriptor() ([]byte, []int) {
+	return file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ApplicationSpec) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetSource() *ApplicationSource {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *ApplicationSpec) GetDestination() *ApplicationDestination {
+	if x != nil {
+		return x.Destination
+	}
+	return nil
+}
+
+type ApplicationSource struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RepoURL        string                 `protobuf:"bytes,1,opt,name=repoURL,proto3" json:"repoURL,omitempty"`
+	Chart          string                 `protobuf:"bytes,2,opt,name=chart,proto3" json:"chart,omitempty"`
+	TargetRevision string                 `protobuf:"bytes,3,opt,name=target_revision,json=targetRevision,proto3" json:"target_revision,omitempty"`
+	Helm           *ApplicationSourceHelm `protobuf:"bytes,4,opt,name=helm,proto3" json:"helm,omitempty"`
+}
+
+func (x *ApplicationSource) Reset() {
+	*x = ApplicationSource{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ApplicationSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplicationSource) ProtoMessage() {}
+
+func (x *ApplicationSource) ProtoReflect() protoreflect.Message {
+	mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplicationSource.ProtoReflect.Descriptor instead.
+func (*ApplicationSource) Descriptor() ([]byte, []int) {
+	return file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ApplicationSource) GetRepoURL() string {
+	if x != nil {
+		return x.RepoURL
+	}
+	return ""
+}
+
+func (x *ApplicationSource) GetChart() string {
+	if x != nil {
+		return x.Chart
+	}
+	return ""
+}
+
+func (x *ApplicationSource) GetTargetRevision() string {
+	if x != nil {
+		return x.TargetRevision
+	}
+	return ""
+}
+
+func (x *ApplicationSource) GetHelm() *ApplicationSourceHelm {
+	if x != nil {
+		return x.Helm
+	}
+	return nil
+}
+
+type ApplicationSourceHelm struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReleaseName string `protobuf:"bytes,1,opt,name=release_name,json=releaseName,proto3" json:"release_name,omitempty"`
+	// values_object is free-form helm values (x-kubernetes-preserve-unknown-fields).
+	ValuesObject *structpb.Struct `protobuf:"bytes,2,opt,name=values_object,json=valuesObject,proto3" json:"values_object,omitempty"`
+}
+
+func (x *ApplicationSourceHelm) Reset() {
+	*x = ApplicationSourceHelm{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ApplicationSourceHelm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplicationSourceHelm) ProtoMessage() {}
+
+func (x *ApplicationSourceHelm) ProtoReflect() protoreflect.Message {
+	mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplicationSourceHelm.ProtoReflect.Descriptor instead.
+func (*ApplicationSourceHelm) Descriptor() ([]byte, []int) {
+	return file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ApplicationSourceHelm) GetReleaseName() string {
+	if x != nil {
+		return x.ReleaseName
+	}
+	return ""
+}
+
+func (x *ApplicationSourceHelm) GetValuesObject() *structpb.Struct {
+	if x != nil {
+		return x.ValuesObject
+	}
+	return nil
+}
+
+type ApplicationDestination struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Server    string `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+}
+
+func (x *ApplicationDestination) Reset() {
+	*x = ApplicationDestination{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ApplicationDestination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplicationDestination) ProtoMessage() {}
+
+func (x *ApplicationDestination) ProtoReflect() protoreflect.Message {
+	mi := &file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplicationDestination.ProtoReflect.Descriptor instead.
+func (*ApplicationDestination) Descriptor() ([]byte, []int) {
+	return file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ApplicationDestination) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *ApplicationDestination) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+var File_components_kube_kubejson_internal_testpb_argocd_proto protoreflect.FileDescriptor
+
+var file_components_kube_kubejson_internal_testpb_argocd_proto_rawDesc = []byte{
+	0x0a, 0x35, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x6b, 0x75, 0x62,
+	0x65, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x6a, 0x73, 0x6f, 0x6e, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x2f, 0x61, 0x72, 0x67, 0x6f, 0x63,
+	0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x1a,
+	0x1a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x6b, 0x75, 0x62, 0x65,
+	0x2f, 0x6b, 0x75, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1c, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72,
+	0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa7, 0x01, 0x0a, 0x0b, 0x41, 0x70,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x08, 0x74, 0x79, 0x70,
+	0x65, 0x6d, 0x65, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6b, 0x75,
+	0x62, 0x65, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x52, 0x08, 0x74, 0x79, 0x70,
+	0x65, 0x6d, 0x65, 0x74, 0x61, 0x12, 0x2c, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x2e, 0x4f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x12, 0x2b, 0x0a, 0x04, 0x73, 0x70, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x52, 0x04, 0x73, 0x70, 0x65, 0x63,
+	0x3a, 0x11, 0x8a, 0xb5, 0x18, 0x0d, 0x0a, 0x0b, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0xa0, 0x01, 0x0a, 0x0f, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65,
+	0x63, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63,
+	0x74, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x12, 0x40, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x74, 0x65, 0x73, 0x74,
+	0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x65,
+	0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x9f, 0x01, 0x0a, 0x11, 0x41, 0x70, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x72, 0x65, 0x70, 0x6f, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72,
+	0x65, 0x70, 0x6f, 0x55, 0x52, 0x4c, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x68, 0x61, 0x72, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x68, 0x61, 0x72, 0x74, 0x12, 0x27, 0x0a, 0x0f,
+	0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x52, 0x65, 0x76,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x31, 0x0a, 0x04, 0x68, 0x65, 0x6c, 0x6d, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x48, 0x65,
+	0x6c, 0x6d, 0x52, 0x04, 0x68, 0x65, 0x6c, 0x6d, 0x22, 0x78, 0x0a, 0x15, 0x41, 0x70, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x48, 0x65, 0x6c,
+	0x6d, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x3c, 0x0a, 0x0d, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x5f, 0x6f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74,
+	0x72, 0x75, 0x63, 0x74, 0x52, 0x0c, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x4f, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x22, 0x4e, 0x0a, 0x16, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x42, 0xaf, 0x01, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x70,
+	0x62, 0x42, 0x0b, 0x41, 0x72, 0x67, 0x6f, 0x63, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01,
+	0x5a, 0x41, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x73,
+	0x74, 0x69, 0x6e, 0x73, 0x62, 0x2f, 0x6b, 0x77, 0x65, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x6a,
+	0x73, 0x6f, 0x6e, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73,
+	0x74, 0x70, 0x62, 0xa2, 0x02, 0x03, 0x54, 0x58, 0x58, 0xaa, 0x02, 0x06, 0x54, 0x65, 0x73, 0x74,
+	0x70, 0x62, 0xca, 0x02, 0x06, 0x54, 0x65, 0x73, 0x74, 0x70, 0x62, 0xe2, 0x02, 0x12, 0x54, 0x65,
+	0x73, 0x74, 0x70, 0x62, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0xea, 0x02, 0x06, 0x54, 0x65, 0x73, 0x74, 0x70, 0x62, 0x82, 0xb5, 0x18, 0x17, 0x0a, 0x0b, 0x61,
+	0x72, 0x67, 0x6f, 0x70, 0x72, 0x6f, 0x6a, 0x2e, 0x69, 0x6f, 0x12, 0x08, 0x76, 0x31, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescOnce sync.Once
+	file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescData = file_components_kube_kubejson_internal_testpb_argocd_proto_rawDesc
+)
+
+func file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescGZIP() []byte {
+	file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescOnce.Do(func() {
+		file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescData = protoimpl.X.CompressGZIP(file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescData)
+	})
+	return file_components_kube_kubejson_internal_testpb_argocd_proto_rawDescData
+}
+
+var file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_components_kube_kubejson_internal_testpb_argocd_proto_goTypes = []interface{}{
+	(*Application)(nil),            // 0: testpb.Application
+	(*ApplicationSpec)(nil),        // 1: testpb.ApplicationSpec
+	(*ApplicationSource)(nil),      // 2: testpb.ApplicationSource
+	(*ApplicationSourceHelm)(nil),  // 3: testpb.ApplicationSourceHelm
+	(*ApplicationDestination)(nil), // 4: testpb.ApplicationDestination
+	(*kube.TypeMeta)(nil),          // 5: kube.TypeMeta
+	(*kube.ObjectMeta)(nil),        // 6: kube.ObjectMeta
+	(*structpb.Struct)(nil),        // 7: google.protobuf.Struct
+}
+var file_components_kube_kubejson_internal_testpb_argocd_proto_depIdxs = []int32{
+	5, // 0: testpb.Application.typemeta:type_name -> kube.TypeMeta
+	6, // 1: testpb.Application.metadata:type_name -> kube.ObjectMeta
+	1, // 2: testpb.Application.spec:type_name -> testpb.ApplicationSpec
+	2, // 3: testpb.ApplicationSpec.source:type_name -> testpb.ApplicationSource
+	4, // 4: testpb.ApplicationSpec.destination:type_name -> testpb.ApplicationDestination
+	3, // 5: testpb.ApplicationSource.helm:type_name -> testpb.ApplicationSourceHelm
+	7, // 6: testpb.ApplicationSourceHelm.values_object:type_name -> google.protobuf.Struct
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_components_kube_kubejson_internal_testpb_argocd_proto_init() }
+func file_components_kube_kubejson_internal_testpb_argocd_proto_init() {
+	if File_components_kube_kubejson_internal_testpb_argocd_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Application); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ApplicationSpec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ApplicationSource); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ApplicationSourceHelm); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ApplicationDestination); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_components_kube_kubejson_internal_testpb_argocd_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_components_kube_kubejson_internal_testpb_argocd_proto_goTypes,
+		DependencyIndexes: file_components_kube_kubejson_internal_testpb_argocd_proto_depIdxs,
+		MessageInfos:      file_components_kube_kubejson_internal_testpb_argocd_proto_msgTypes,
+	}.Build()
+	File_components_kube_kubejson_internal_testpb_argocd_proto = out.File
+	file_components_kube_kubejson_internal_testpb_argocd_proto_rawDesc = nil
+	file_components_kube_kubejson_internal_testpb_argocd_proto_goTypes = nil
+	file_components_kube_kubejson_internal_testpb_argocd_proto_depIdxs = nil
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 
 	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/kube/kubejson/internal/genid"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 // Based on https://github.com/protocolbuffers/protobuf-go/blob/master/encoding/protojson/encode.go
@@ -71,12 +73,12 @@ type marshalOptions struct {
 	// //  ╚═══════╧════════════════════════════╝
 	// EmitUnpopulated bool
 
-	// // Resolver is used for looking up types when expanding google.protobuf.Any
-	// // messages. If nil, this defaults to using protoregistry.GlobalTypes.
-	// Resolver interface {
-	// 	protoregistry.ExtensionTypeResolver
-	// 	protoregistry.MessageTypeResolver
-	// }
+	// Resolver is used for looking up types when expanding google.protobuf.Any
+	// messages. If nil, this defaults to using protoregistry.GlobalTypes.
+	Resolver interface {
+		protoregistry.ExtensionTypeResolver
+		protoregistry.MessageTypeResolver
+	}
 }
 
 // Format formats the message as a string.
@@ -106,9 +108,9 @@ func (o marshalOptions) marshal(m proto.Message) ([]byte, error) {
 	if o.Multiline && o.Indent == "" {
 		o.Indent = defaultIndent
 	}
-	// if o.Resolver == nil {
-	// 	o.Resolver = protoregistry.GlobalTypes
-	// }
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
 
 	internalEnc, err := NewEncoder(o.Indent)
 	if err != nil {
@@ -193,13 +195,23 @@ func (e encoder) marshalMessage(m protoreflect.Message, typeURL string) error {
 	// 	return errors.New("no support for proto1 MessageSets")
 	// }
 
-	// if marshal := wellKnownTypeMarshaler(m.Descriptor().FullName()); marshal != nil {
-	// 	return marshal(e, m)
-	// }
+	if marshal := wellKnownTypeMarshaler(m.Descriptor().FullName()); marshal != nil {
+		return marshal(e, m)
+	}
 
 	e.StartObject()
 	defer e.EndObject()
 
+	if typeURL != "" {
+		// The type of an embedded message in an Any
+		if err := e.WriteName("@type"); err != nil {
+			return err
+		}
+		if err := e.WriteString(typeURL); err != nil {
+			return err
+		}
+	}
+
 	if obj, ok := m.Interface().(kube.Object); ok {
 		kindInfo := kube.GetKindInfo(obj)
 
@@ -279,13 +291,16 @@ func (e encoder) marshalSingular(val protoreflect.Value, fd protoreflect.FieldDe
 	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
 		e.WriteUint(val.Uint())
 
-	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind,
-		protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind:
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
 		// 64-bit integers are written out as JSON string in protojson.
 		// e.WriteString(val.String())
 		// But here we want compatability with kube, so we write them as ints
 		e.WriteInt(val.Int())
 
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// As for signed 64-bit integers, we write these as numbers for kube.
+		e.WriteUint(val.Uint())
+
 	case protoreflect.FloatKind:
 		// Encoder.WriteFloat handles the special numbers NaN and infinites.
 		e.WriteFloat(val.Float(), 32)
@@ -298,16 +313,16 @@ func (e encoder) marshalSingular(val protoreflect.Value, fd protoreflect.FieldDe
 		e.WriteString(base64.StdEncoding.EncodeToString(val.Bytes()))
 
 	case protoreflect.EnumKind:
-		// if fd.Enum().FullName() == genid.NullValue_enum_fullname {
-		// 	e.WriteNull()
-		// } else {
-		desc := fd.Enum().Values().ByNumber(val.Enum())
-		// if e.opts.UseEnumNumbers || desc == nil {
-		// 	e.WriteInt(int64(val.Enum()))
-		// } else {
-		e.WriteString(string(desc.Name()))
-		// }
-		// }
+		if fd.Enum().FullName() == genid.NullValue_enum_fullname {
+			e.WriteNull()
+		} else {
+			desc := fd.Enum().Values().ByNumber(val.Enum())
+			if desc == nil {
+				e.WriteInt(int64(val.Enum()))
+			} else {
+				e.WriteString(string(desc.Name()))
+			}
+		}
 
 	case protoreflect.MessageKind, protoreflect.GroupKind:
 		if err := e.marshalMessage(val.Message(), ""); err != nil {
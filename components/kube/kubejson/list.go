@@ -5,7 +5,6 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
-	"k8s.io/klog/v2"
 )
 
 func (o UnmarshalOptions) UnmarshalKubeList(b []byte, m proto.Message, callback func(m proto.Message)) (*KubeListObjectMeta, error) {
@@ -43,6 +42,13 @@ func (o UnmarshalOptions) unmarshalKubeList(b []byte, m proto.Message, callback
 type KubeListObjectMeta struct {
 	APIVersion string
 	Kind       string
+
+	// ResourceVersion is the resourceVersion of the list, from which a watch can be started.
+	ResourceVersion string
+	// Continue is the token for the next page of a paginated list; it is empty on the last page.
+	Continue string
+	// RemainingItemCount is the number of items after this page, if the server reports it.
+	RemainingItemCount *int64
 }
 
 // unmarshalMessage unmarshals a message into the given protoreflect.Message.
@@ -90,8 +96,7 @@ func (d decoder) unmarshalKubeListObject(m protoreflect.Message, callback func(m
 			meta.Kind = kind
 
 		case "metadata":
-			klog.Warningf("skipping metadata on list response")
-			if err := d.skipJSONValue(); err != nil {
+			if err := d.unmarshalKubeListMeta(meta); err != nil {
 				return nil, err
 			}
 
@@ -154,6 +159,63 @@ func (d decoder) unmarshalKubeListObject(m protoreflect.Message, callback func(m
 	}
 }
 
+// unmarshalKubeListMeta reads the metadata (ListMeta) of a list.
+func (d decoder) unmarshalKubeListMeta(meta *KubeListObjectMeta) error {
+	tok, err := d.Read()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != json.ObjectOpen {
+		return d.unexpectedTokenError(tok)
+	}
+
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		default:
+			return d.unexpectedTokenError(tok)
+		case json.ObjectClose:
+			return nil
+		case json.Name:
+			// Continue below.
+		}
+
+		switch tok.Name() {
+		case "resourceVersion":
+			meta.ResourceVersion, err = d.readString()
+			if err != nil {
+				return err
+			}
+
+		case "continue":
+			meta.Continue, err = d.readString()
+			if err != nil {
+				return err
+			}
+
+		case "remainingItemCount":
+			tok, err := d.Read()
+			if err != nil {
+				return err
+			}
+			n, ok := tok.Int(64)
+			if !ok {
+				return d.newError(tok.Pos(), "invalid value for remainingItemCount: %v", tok.RawString())
+			}
+			meta.RemainingItemCount = &n
+
+		default:
+			// selfLink etc
+			if err := d.skipJSONValue(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (d decoder) readString() (string, error) {
 	tok, err := d.Read()
 	if err != nil {
@@ -0,0 +1,203 @@
+package kubejson
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/justinsb/kweb/components/kube/kubejson/internal/testpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// goldenTests are objects captured from real clusters (testdata/<name>.json),
+// except for widget, which covers the well-known types that kubernetes objects don't use.
+// The fields we don't model are discarded; testdata/<name>.golden.json is what we expect to write back.
+var goldenTests = []struct {
+	name string
+	obj  proto.Message
+}{
+	{name: "deployment", obj: &testpb.Deployment{}},
+	{name: "certificate", obj: &testpb.Certificate{}},
+	{name: "application", obj: &testpb.Application{}},
+	{name: "widget", obj: &testpb.Widget{}},
+}
+
+func TestGoldenRoundTrip(t *testing.T) {
+	for _, tc := range goldenTests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := readTestData(t, tc.name+".json")
+			goldenPath := filepath.Join("testdata", tc.name+".golden.json")
+
+			obj := tc.obj.ProtoReflect().New().Interface()
+			if err := (UnmarshalOptions{DiscardUnknown: true}).Unmarshal(input, obj); err != nil {
+				t.Fatalf("unmarshal %s.json: %v", tc.name, err)
+			}
+			got, err := Marshal(obj)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			if *update {
+				var out bytes.Buffer
+				if err := json.Indent(&out, got, "", "  "); err != nil {
+					t.Fatalf("indenting output: %v", err)
+				}
+				out.WriteString("\n")
+				if err := os.WriteFile(goldenPath, out.Bytes(), 0644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+			}
+
+			golden := readTestData(t, tc.name+".golden.json")
+			assertJSONEqual(t, golden, got)
+
+			// The golden output contains only fields we understand, so it must parse strictly and be stable.
+			reparsed := tc.obj.ProtoReflect().New().Interface()
+			if err := Unmarshal(golden, reparsed); err != nil {
+				t.Fatalf("unmarshal %s.golden.json: %v", tc.name, err)
+			}
+			if !proto.Equal(obj, reparsed) {
+				t.Errorf("object changed on round trip:\n  first: %v\n second: %v", obj, reparsed)
+			}
+			again, err := Marshal(reparsed)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			assertJSONEqual(t, golden, again)
+		})
+	}
+}
+
+func TestGoldenValues(t *testing.T) {
+	deployment := &testpb.Deployment{}
+	unmarshalTestData(t, "deployment.json", deployment)
+	if got, want := deployment.GetMetadata().GetCreationTimestamp().AsTime(), time.Date(2024, 5, 14, 9, 12, 44, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("creationTimestamp: got %v, want %v", got, want)
+	}
+	if got := deployment.GetSpec().GetReplicas(); got == nil || got.GetValue() != 0 {
+		t.Errorf("replicas: got %v, want explicit 0", got)
+	}
+	if got := deployment.GetSpec().GetPaused(); got == nil || got.GetValue() {
+		t.Errorf("paused: got %v, want explicit false", got)
+	}
+	if got, want := deployment.GetMetadata().GetResourceVersion(), "48213"; got != want {
+		t.Errorf("resourceVersion: got %q, want %q", got, want)
+	}
+
+	certificate := &testpb.Certificate{}
+	unmarshalTestData(t, "certificate.json", certificate)
+	if got, want := certificate.GetSpec().GetDuration().AsDuration(), 2160*time.Hour; got != want {
+		t.Errorf("duration: got %v, want %v", got, want)
+	}
+	if got, want := certificate.GetSpec().GetRenewBefore().AsDuration(), 360*time.Hour; got != want {
+		t.Errorf("renewBefore: got %v, want %v", got, want)
+	}
+	ownerRefs := certificate.GetMetadata().GetOwnerReferences()
+	if len(ownerRefs) != 1 || !ownerRefs[0].GetController() || !ownerRefs[0].GetBlockOwnerDeletion() || ownerRefs[0].GetApiVersion() != "networking.k8s.io/v1" {
+		t.Errorf("unexpected ownerReferences %v", ownerRefs)
+	}
+
+	application := &testpb.Application{}
+	unmarshalTestData(t, "application.json", application)
+	controller := application.GetSpec().GetSource().GetHelm().GetValuesObject().GetFields()["controller"].GetStructValue()
+	if got, want := controller.GetFields()["replicaCount"].GetNumberValue(), 2.0; got != want {
+		t.Errorf("valuesObject.controller.replicaCount: got %v, want %v", got, want)
+	}
+
+	widget := &testpb.Widget{}
+	unmarshalTestData(t, "widget.json", widget)
+	if got, want := widget.GetSpec().GetInt64Value().GetValue(), int64(-9007199254740993); got != want {
+		t.Errorf("int64Value: got %d, want %d", got, want)
+	}
+	if got, want := widget.GetSpec().GetUint64Value().GetValue(), uint64(18446744073709551615); got != want {
+		t.Errorf("uint64Value: got %d, want %d", got, want)
+	}
+	if got := widget.GetSpec().GetStringValue(); got == nil || got.GetValue() != "" {
+		t.Errorf("stringValue: got %v, want explicit empty string", got)
+	}
+	if got, want := widget.GetSpec().GetUpdateMask().GetPaths(), []string{"metadata.labels", "spec.int64_value", "spec.update_mask"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("updateMask: got %v, want %v", got, want)
+	}
+	extensions := widget.GetSpec().GetExtensions()
+	if len(extensions) != 2 {
+		t.Fatalf("got %d extensions, want 2", len(extensions))
+	}
+	issuerRef := &testpb.IssuerRef{}
+	if err := extensions[0].UnmarshalTo(issuerRef); err != nil || issuerRef.GetName() != "selfsigned" {
+		t.Errorf("extensions[0]: got %v (err %v), want IssuerRef selfsigned", issuerRef, err)
+	}
+	duration := &durationpb.Duration{}
+	if err := extensions[1].UnmarshalTo(duration); err != nil || duration.AsDuration() != 90*time.Minute {
+		t.Errorf("extensions[1]: got %v (err %v), want 1h30m", duration, err)
+	}
+	if got, want := widget.GetMetadata().GetDeletionGracePeriodSeconds(), int64(0); widget.GetMetadata().DeletionGracePeriodSeconds == nil || got != want {
+		t.Errorf("deletionGracePeriodSeconds: got %v, want explicit %d", widget.GetMetadata().DeletionGracePeriodSeconds, want)
+	}
+}
+
+func TestDurationAcceptsProtoJSON(t *testing.T) {
+	certificate := &testpb.Certificate{}
+	if err := (UnmarshalOptions{DiscardUnknown: true}).Unmarshal([]byte(`{"spec": {"duration": "5400s"}}`), certificate); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got, want := certificate.GetSpec().GetDuration().AsDuration(), 90*time.Minute; got != want {
+		t.Errorf("duration: got %v, want %v", got, want)
+	}
+
+	b, err := Marshal(certificate)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	assertJSONEqual(t, []byte(`{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "spec": {"duration": "1h30m0s"}}`), b)
+}
+
+func readTestData(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading testdata: %v", err)
+	}
+	return b
+}
+
+func unmarshalTestData(t *testing.T, name string, obj proto.Message) {
+	t.Helper()
+	if err := (UnmarshalOptions{DiscardUnknown: true}).Unmarshal(readTestData(t, name), obj); err != nil {
+		t.Fatalf("unmarshal %s: %v", name, err)
+	}
+}
+
+// assertJSONEqual compares the JSON values, ignoring formatting and the order of keys.
+// Numbers are compared as written, so we don't lose precision on 64 bit values.
+func assertJSONEqual(t *testing.T, want, got []byte) {
+	t.Helper()
+	wantValue, err := decodeJSON(want)
+	if err != nil {
+		t.Fatalf("parsing expected json: %v", err)
+	}
+	gotValue, err := decodeJSON(got)
+	if err != nil {
+		t.Fatalf("parsing output %s: %v", got, err)
+	}
+	if !reflect.DeepEqual(wantValue, gotValue) {
+		t.Errorf("unexpected json:\n  got: %s\n want: %s", got, want)
+	}
+}
+
+func decodeJSON(b []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,117 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// Options configures leader election.
+type Options struct {
+	// Namespace and Name identify the Lease object used as the lock.
+	Namespace string
+	Name      string
+
+	// Identity identifies this process; defaults to the hostname with a random suffix.
+	Identity string
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// InitDefaults sets the default durations, which match the kubernetes controllers.
+func (o *Options) InitDefaults() {
+	o.LeaseDuration = 15 * time.Second
+	o.RenewDeadline = 10 * time.Second
+	o.RetryPeriod = 2 * time.Second
+}
+
+func (o *Options) applyDefaults() error {
+	if o.Namespace == "" || o.Name == "" {
+		return fmt.Errorf("leader election namespace and name must be set")
+	}
+	var defaults Options
+	defaults.InitDefaults()
+	if o.LeaseDuration == 0 {
+		o.LeaseDuration = defaults.LeaseDuration
+	}
+	if o.RenewDeadline == 0 {
+		o.RenewDeadline = defaults.RenewDeadline
+	}
+	if o.RetryPeriod == 0 {
+		o.RetryPeriod = defaults.RetryPeriod
+	}
+	if o.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("error getting hostname: %w", err)
+		}
+		o.Identity = hostname + "_" + rand.String(8)
+	}
+	return nil
+}
+
+// Run campaigns for the lease, and calls fn whenever we become the leader.
+// The context passed to fn is cancelled when we lose the lease.
+// Run blocks until ctx is cancelled.
+func Run(ctx context.Context, restConfig *rest.Config, opt Options, fn func(ctx context.Context)) error {
+	if err := opt.applyDefaults(); err != nil {
+		return err
+	}
+
+	coordinationClient, err := coordinationv1client.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("error building coordination client: %w", err)
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: opt.Namespace,
+			Name:      opt.Name,
+		},
+		Client: coordinationClient,
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: opt.Identity,
+		},
+	}
+
+	for {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   opt.LeaseDuration,
+			RenewDeadline:   opt.RenewDeadline,
+			RetryPeriod:     opt.RetryPeriod,
+			ReleaseOnCancel: true,
+			Name:            opt.Name,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					klog.Infof("%s: acquired lease %s/%s", opt.Identity, opt.Namespace, opt.Name)
+					fn(ctx)
+				},
+				OnStoppedLeading: func() {
+					klog.Infof("%s: released lease %s/%s", opt.Identity, opt.Namespace, opt.Name)
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error building leader elector: %w", err)
+		}
+
+		// Run returns when we lose the lease (or ctx is cancelled); campaign again unless we are shutting down.
+		elector.Run(ctx)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
@@ -40,13 +41,40 @@ func (k *KindInfo) GroupResource() schema.GroupResource {
 
 func GetKindInfo(msg proto.Message) *KindInfo {
 	// TODO: Cache
-	messageDescriptor := msg.ProtoReflect().Descriptor()
+	return GetKindInfoForDescriptor(msg.ProtoReflect().Descriptor())
+}
+
+// IsKind returns true if the message is annotated with the kube.kind option.
+func IsKind(messageDescriptor protoreflect.MessageDescriptor) bool {
+	messageOptions, ok := messageDescriptor.Options().(*descriptorpb.MessageOptions)
+	if !ok || messageOptions == nil {
+		return false
+	}
+	return proto.HasExtension(messageOptions, E_Kind)
+}
+
+// IsSensitive returns true if the field is annotated with the kube.sensitive option.
+func IsSensitive(fieldDescriptor protoreflect.FieldDescriptor) bool {
+	fieldOptions, ok := fieldDescriptor.Options().(*descriptorpb.FieldOptions)
+	if !ok || fieldOptions == nil {
+		return false
+	}
+	return proto.GetExtension(fieldOptions, E_Sensitive).(bool)
+}
+
+// GetKindAnnotation returns the kube.kind option of the message.
+func GetKindAnnotation(messageDescriptor protoreflect.MessageDescriptor) *Kind {
 	messageOptions := messageDescriptor.Options().(*descriptorpb.MessageOptions)
 	kindVal := messageOptions.ProtoReflect().Get(E_Kind.TypeDescriptor())
 	kind, ok := kindVal.Message().Interface().(*Kind)
 	if !ok {
 		klog.Fatalf("unexpected type for kind annotation, got %T", kindVal.Message().Interface())
 	}
+	return kind
+}
+
+func GetKindInfoForDescriptor(messageDescriptor protoreflect.MessageDescriptor) *KindInfo {
+	kind := GetKindAnnotation(messageDescriptor)
 
 	info := &KindInfo{}
 	info.Kind = kind.Kind
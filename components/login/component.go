@@ -35,20 +35,32 @@ func (c *Component) RegisterProvider(provider components.AuthenticationProvider)
 }
 
 func (c *Component) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
-	mux.HandleFunc("/_login/logout", s.ServeHTTP(c.Logout))
-	for _, provider := range c.providers {
-		provider := provider
-		id := provider.ProviderID()
-		fn := func(ctx context.Context, req *components.Request) (components.Response, error) {
-			return c.StartOAuth2Login(ctx, req, provider)
-		}
-		mux.HandleFunc("/_login/oauth2/"+id, s.ServeHTTP(fn))
-		mux.HandleFunc("/_login/oauth2-callback/"+id, s.ServeHTTP(c.OAuthCallback))
+	routes := s.Routes()
+	// Logout revokes our tokens, so only a (CSRF-protected) POST logs out; a GET asks for confirmation.
+	if err := routes.GET("/_login/logout", c.confirmLogout); err != nil {
+		return err
+	}
+	if err := routes.POST("/_login/logout", c.Logout); err != nil {
+		return err
+	}
+	if err := routes.GET("/_login/oauth2/{provider}", c.startOAuth2Login); err != nil {
+		return err
+	}
+	if err := routes.GET("/_login/oauth2-callback/{provider}", c.OAuthCallback); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+func (c *Component) startOAuth2Login(ctx context.Context, req *components.Request) (components.Response, error) {
+	provider := c.providers[req.PathParameter("provider")]
+	if provider == nil {
+		return nil, components.NewHTTPError(http.StatusNotFound, "unknown login provider", nil)
+	}
+	return c.StartOAuth2Login(ctx, req, provider)
+}
+
 func (c *Component) AddToScope(ctx context.Context, scope *scopes.Scope) {
 	m := map[string]any{
 		"logoutURL": "/_login/logout",
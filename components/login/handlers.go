@@ -1,17 +1,19 @@
 package login
 
 import (
+	"bytes"
 	"context"
 	cryptorand "crypto/rand"
 	"encoding/base64"
-	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/csrf"
 	"github.com/justinsb/kweb/components/login/pb"
+	"github.com/justinsb/kweb/components/oauthsessions"
 	"github.com/justinsb/kweb/components/users"
 	"k8s.io/klog/v2"
 )
@@ -32,7 +34,7 @@ func (p *Component) StartOAuth2Login(ctx context.Context, req *components.Reques
 
 	err := req.ParseForm()
 	if err != nil {
-		return components.ErrorResponse(http.StatusBadRequest), err
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid form", err)
 	}
 
 	redirect := req.FormValue("redirect")
@@ -54,7 +56,51 @@ func (p *Component) StartOAuth2Login(ctx context.Context, req *components.Reques
 	return components.RedirectResponse(provider.GetLoginURL(ctx, redirectURI, stateString)), nil
 }
 
+// logoutConfirmation is the page shown for a GET of the logout URL; it posts back to the same URL.
+var logoutConfirmation = template.Must(template.New("logout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Log out</title></head>
+<body>
+  <form method="POST" action="{{.Action}}">
+    {{if .CSRFToken}}<input type="hidden" name="{{.CSRFFieldName}}" value="{{.CSRFToken}}">{{end}}
+    <p>Do you want to log out?</p>
+    <button type="submit">Log out</button>
+  </form>
+</body>
+</html>
+`))
+
+func (p *Component) confirmLogout(ctx context.Context, req *components.Request) (components.Response, error) {
+	data := map[string]any{
+		"Action":        req.URL.Path,
+		"CSRFFieldName": csrf.FieldName,
+	}
+	if token, err := csrf.Token(ctx); err != nil {
+		klog.Warningf("not including csrf token in logout form: %v", err)
+	} else {
+		data["CSRFToken"] = token
+	}
+
+	var b bytes.Buffer
+	if err := logoutConfirmation.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	response := &components.SimpleResponse{
+		Body: b.Bytes(),
+	}
+	response.Headers().Set("Content-Type", "text/html; charset=utf-8")
+	response.Headers().Set("Cache-Control", "no-store")
+	return response, nil
+}
+
 func (p *Component) Logout(ctx context.Context, req *components.Request) (components.Response, error) {
+	// Revoke the tokens we obtained for this login, before we forget the user
+	if sessions := oauthsessions.GetComponent(ctx); sessions != nil {
+		if err := sessions.RevokeSessions(ctx); err != nil {
+			klog.Warningf("error revoking oauth sessions on logout: %v", err)
+		}
+	}
+
 	users.Logout(ctx)
 
 	return components.RedirectResponse("/"), nil
@@ -80,7 +126,7 @@ func (p *Component) OAuthCallback(ctx context.Context, req *components.Request)
 	// finish the oauth cycle
 	err := req.ParseForm()
 	if err != nil {
-		return nil, err
+		return nil, components.NewHTTPError(http.StatusBadRequest, "invalid form", err)
 	}
 
 	sessionState := pb.StateData{}
@@ -93,24 +139,25 @@ func (p *Component) OAuthCallback(ctx context.Context, req *components.Request)
 	stateParameter := req.URL.Query().Get("state")
 	if stateParameter != stateString {
 		klog.Warningf("state in session does not match state in request")
-		return nil, fmt.Errorf("state mismatch got=%q vs want=%q", stateParameter, stateString)
+		return nil, components.NewHTTPError(http.StatusBadRequest, "login session did not match; please try logging in again", fmt.Errorf("state mismatch got=%q vs want=%q", stateParameter, stateString))
 	}
 
 	req.Session.Clear(&pb.StateData{})
 
 	errorString := req.Form.Get("error")
 	if errorString != "" {
-		return components.ErrorResponse(http.StatusForbidden), fmt.Errorf("permission denied: %v", errorString)
+		return nil, components.NewHTTPError(http.StatusForbidden, "login was not completed", fmt.Errorf("permission denied: %v", errorString)).WithDetail("error", errorString)
 	}
 
-	redirect := sessionState.Redirect
-	if !strings.HasPrefix(redirect, "/") {
-		redirect = "/"
-	}
+	redirect := components.LocalRedirect(sessionState.Redirect)
 
 	code := req.Form.Get("code")
 	if code == "" {
-		return components.ErrorResponse(http.StatusBadRequest), errors.New("missing code")
+		return nil, components.NewHTTPError(http.StatusBadRequest, "missing code", nil)
+	}
+
+	if providerID := req.PathParameter("provider"); providerID != sessionState.ProviderId {
+		return nil, components.NewHTTPError(http.StatusBadRequest, "login provider did not match", fmt.Errorf("provider mismatch got=%q vs want=%q", providerID, sessionState.ProviderId))
 	}
 
 	redirectURI := p.getRedirectURI(req, sessionState.ProviderId)
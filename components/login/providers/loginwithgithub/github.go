@@ -7,6 +7,7 @@ import (
 
 	githubapi "github.com/google/go-github/v45/github"
 	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/oauthsessions"
 	"github.com/justinsb/kweb/components/users"
 	userapi "github.com/justinsb/kweb/components/users/pb"
 	"golang.org/x/oauth2"
@@ -78,6 +79,33 @@ func (p *GithubProvider) Redeem(ctx context.Context, redirectURL string, code st
 
 	users.SetUser(ctx, user)
 
+	// Keep the token, so that we can call the provider's APIs on behalf of the user
+	if sessions := oauthsessions.GetComponent(ctx); sessions != nil {
+		if err := sessions.StoreSession(ctx, p.ProviderID(), token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ components.TokenProvider = &GithubProvider{}
+
+// TokenSource returns a token source that refreshes token when it expires (if the app uses expiring tokens).
+func (p *GithubProvider) TokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource {
+	return p.conf.TokenSource(ctx, token)
+}
+
+// RevokeToken revokes the access token, using the app's client credentials.
+func (p *GithubProvider) RevokeToken(ctx context.Context, token *oauth2.Token) error {
+	tp := &githubapi.BasicAuthTransport{
+		Username: p.conf.ClientID,
+		Password: p.conf.ClientSecret,
+	}
+	githubClient := githubapi.NewClient(tp.Client())
+	if _, err := githubClient.Authorizations.Revoke(ctx, p.conf.ClientID, token.AccessToken); err != nil {
+		return fmt.Errorf("error revoking github token: %w", err)
+	}
 	return nil
 }
 
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/oauthsessions"
 	"github.com/justinsb/kweb/components/users"
 	userapi "github.com/justinsb/kweb/components/users/pb"
 	"golang.org/x/oauth2"
@@ -51,7 +54,8 @@ func (p *GoogleProvider) GetLoginURL(ctx context.Context, redirectURL string, st
 
 	// Redirect user to Google's consent page to ask for permission
 	// for the scopes specified above.
-	url := conf.AuthCodeURL(state)
+	// We request offline access so that we get a refresh token, and can keep calling APIs after the access token expires.
+	url := conf.AuthCodeURL(state, oauth2.AccessTypeOffline)
 	return url
 }
 
@@ -106,6 +110,48 @@ func (p *GoogleProvider) Redeem(ctx context.Context, redirectURL string, code st
 
 	users.SetUser(ctx, user)
 
+	// Keep the token, so that we can call the provider's APIs on behalf of the user
+	if sessions := oauthsessions.GetComponent(ctx); sessions != nil {
+		if err := sessions.StoreSession(ctx, p.ProviderID(), token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ components.TokenProvider = &GoogleProvider{}
+
+// TokenSource returns a token source that refreshes token when it expires.
+func (p *GoogleProvider) TokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource {
+	return p.conf.TokenSource(ctx, token)
+}
+
+// RevokeToken revokes the token; revoking the refresh token also revokes the access tokens issued for it.
+func (p *GoogleProvider) RevokeToken(ctx context.Context, token *oauth2.Token) error {
+	revoke := token.RefreshToken
+	if revoke == "" {
+		revoke = token.AccessToken
+	}
+
+	form := url.Values{}
+	form.Set("token", revoke)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://oauth2.googleapis.com/revoke", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error building HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error doing HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response revoking token: %v %s", resp.Status, string(b))
+	}
 	return nil
 }
 
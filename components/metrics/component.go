@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/templates/scopes"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewMetricsComponent serves the prometheus metrics of the process on /metrics.
+func NewMetricsComponent() components.Component {
+	return &MetricsComponent{}
+}
+
+type MetricsComponent struct {
+}
+
+func (c *MetricsComponent) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
+	mux.Handle("/metrics", promhttp.Handler())
+	return nil
+}
+
+func (c *MetricsComponent) AddToScope(ctx context.Context, scope *scopes.Scope) {
+}
@@ -3,28 +3,91 @@ package oauthsessions
 import (
 	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/keystore"
 	"github.com/justinsb/kweb/components/kube/kubeclient"
-	"github.com/justinsb/kweb/components/oauthsessions/api"
+	"github.com/justinsb/kweb/components/oauthsessions/pb"
 	"github.com/justinsb/kweb/components/users"
 	userapi "github.com/justinsb/kweb/components/users/pb"
 	"github.com/justinsb/kweb/templates/scopes"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"golang.org/x/oauth2"
+	"k8s.io/klog/v2"
 )
 
+// indexUser indexes sessions by spec.user
+const indexUser = "user"
+
+// Options configures the oauth sessions component.
+type Options struct {
+	// KeyStore holds the keys that encrypt the tokens in sessions; if nil, tokens are stored in plain text.
+	KeyStore keystore.KeyStore
+
+	// KeySetName is the name of the keyset in KeyStore.
+	KeySetName string
+
+	// ReencryptInterval is how often we re-encrypt sessions that are not encrypted with the active key (after key rotation).
+	ReencryptInterval time.Duration
+}
+
+func (o *Options) InitDefaults() {
+	o.KeySetName = "oauthsessions"
+	o.ReencryptInterval = time.Hour
+}
+
 type OAuthSessionsComponent struct {
-	kube *kubeclient.Client
+	kube     *kubeclient.Client
+	sessions *kubeclient.Informer[*pb.OauthSession]
+
+	// providers refresh and revoke the tokens in sessions, by provider id
+	providers map[string]components.TokenProvider
+
+	keyStore          keystore.KeyStore
+	keySetName        string
+	reencryptInterval time.Duration
 }
 
-func NewOAuthSessionsComponent(kube *kubeclient.Client) (*OAuthSessionsComponent, error) {
+func NewOAuthSessionsComponent(kube *kubeclient.Client, opt Options) (*OAuthSessionsComponent, error) {
+	var defaults Options
+	defaults.InitDefaults()
+	if opt.KeySetName == "" {
+		opt.KeySetName = defaults.KeySetName
+	}
+	if opt.ReencryptInterval == 0 {
+		opt.ReencryptInterval = defaults.ReencryptInterval
+	}
+	if opt.KeyStore == nil {
+		klog.Warningf("no key store configured for oauth sessions; tokens will be stored in plain text")
+	}
+
 	c := &OAuthSessionsComponent{
-		kube: kube,
+		kube:              kube,
+		sessions:          kubeclient.CachedClient(kube, &pb.OauthSession{}),
+		providers:         make(map[string]components.TokenProvider),
+		keyStore:          opt.KeyStore,
+		keySetName:        opt.KeySetName,
+		reencryptInterval: opt.ReencryptInterval,
 	}
 
+	c.sessions.AddIndex(indexUser, func(session *pb.OauthSession) []string {
+		return []string{session.GetSpec().GetUser()}
+	})
+
 	return c, nil
 }
 
+// RegisterProvider registers a provider, so that we can refresh and revoke the tokens it issues.
+func (c *OAuthSessionsComponent) RegisterProvider(provider components.TokenProvider) {
+	providerID := provider.ProviderID()
+	if c.providers[providerID] != nil {
+		klog.Fatalf("provider %q already registered", providerID)
+	}
+	c.providers[providerID] = provider
+}
+
+// GetComponent returns the oauth sessions component, or nil if it is not configured.
 func GetComponent(ctx context.Context) *OAuthSessionsComponent {
 	var component *OAuthSessionsComponent
 	components.GetComponent(ctx, &component)
@@ -43,27 +106,41 @@ func (c *OAuthSessionsComponent) RegisterHandlers(s *components.Server, mux *htt
 
 type scopeInfo struct {
 	// parent   *OAuthSessionsComponent
-	sessions []*api.OauthSession
+	sessions []*pb.OauthSession
 }
 
-func GetAllOauthSessions(ctx context.Context) ([]*api.OauthSession, error) {
+func GetAllOauthSessions(ctx context.Context) ([]*pb.OauthSession, error) {
 	// info := ctx.Value(contextKeyScopeInfo).(*scopeInfo)
 	info := &scopeInfo{} // TODO: Caching
 	return info.getAllOauthSessions(ctx)
 }
 
-func GetOauthSession(ctx context.Context) (*api.OauthSession, error) {
+// GetOauthSession returns the session of the logged-in user with the latest expiry, refreshing it if it has expired.
+// It returns nil if the user has no sessions.
+func GetOauthSession(ctx context.Context) (*pb.OauthSession, error) {
+	return getOauthSession(ctx, "")
+}
+
+// GetOauthSessionForProvider is like GetOauthSession, but only considers sessions issued by the provider.
+func GetOauthSessionForProvider(ctx context.Context, providerID string) (*pb.OauthSession, error) {
+	return getOauthSession(ctx, providerID)
+}
+
+func getOauthSession(ctx context.Context, providerID string) (*pb.OauthSession, error) {
 	sessions, err := GetAllOauthSessions(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var best *api.OauthSession
+	var best *pb.OauthSession
 	for _, session := range sessions {
+		if providerID != "" && session.GetSpec().GetProviderID() != providerID {
+			continue
+		}
 		if best == nil {
 			best = session
 			continue
 		}
-		if best.Spec.ExpiresAt < session.Spec.ExpiresAt {
+		if best.GetSpec().GetExpiresAt() < session.GetSpec().GetExpiresAt() {
 			best = session
 		}
 	}
@@ -72,12 +149,63 @@ func GetOauthSession(ctx context.Context) (*api.OauthSession, error) {
 		return nil, nil
 	}
 
-	// TODO: Check ttl and auto-refresh?
+	if needsRefresh(best, time.Now()) && best.GetSpec().GetRefreshToken() != "" {
+		if err := GetComponent(ctx).RefreshSession(ctx, best); err != nil {
+			return nil, err
+		}
+	}
 
 	return best, nil
 }
 
-func (i *scopeInfo) getAllOauthSessions(ctx context.Context) ([]*api.OauthSession, error) {
+// TokenSource returns a token source for the logged-in user's session with the provider, or nil if there is no session.
+// The token source refreshes the token when it expires, storing the new token in the session.
+func TokenSource(ctx context.Context, providerID string) (oauth2.TokenSource, error) {
+	session, err := GetOauthSessionForProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, nil
+	}
+	return &sessionTokenSource{ctx: ctx, component: GetComponent(ctx), session: session}, nil
+}
+
+// needsRefresh returns true if the token in the session has expired, or is about to.
+func needsRefresh(session *pb.OauthSession, now time.Time) bool {
+	expiresAt := session.GetSpec().GetExpiresAt()
+	if expiresAt <= 0 {
+		return false
+	}
+	return time.Unix(expiresAt, 0).Before(now.Add(time.Minute))
+}
+
+type sessionTokenSource struct {
+	ctx       context.Context
+	component *OAuthSessionsComponent
+
+	mutex   sync.Mutex
+	session *pb.OauthSession
+}
+
+var _ oauth2.TokenSource = &sessionTokenSource{}
+
+// Token returns a token or an error.
+// Token must be safe for concurrent use by multiple goroutines.
+// The returned Token must not be modified.
+func (t *sessionTokenSource) Token() (*oauth2.Token, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if needsRefresh(t.session, time.Now()) {
+		if err := t.component.RefreshSession(t.ctx, t.session); err != nil {
+			return nil, err
+		}
+	}
+	return tokenFromSession(t.session), nil
+}
+
+func (i *scopeInfo) getAllOauthSessions(ctx context.Context) ([]*pb.OauthSession, error) {
 	if i.sessions == nil {
 		user := users.GetUser(ctx)
 		if user != nil {
@@ -91,23 +219,24 @@ func (i *scopeInfo) getAllOauthSessions(ctx context.Context) ([]*api.OauthSessio
 	return i.sessions, nil
 }
 
-func (c *OAuthSessionsComponent) LoadOauthSessions(ctx context.Context, user *userapi.User) ([]*api.OauthSession, error) {
+func (c *OAuthSessionsComponent) LoadOauthSessions(ctx context.Context, user *userapi.User) ([]*pb.OauthSession, error) {
 	if user == nil {
 		return nil, nil
 	}
 	userID := user.GetMetadata().GetName()
 
-	// TODO: We really need an index!
-	allSessions := &api.OauthSessionList{}
-	ns := user.GetMetadata().GetNamespace()
-	if err := c.kube.Uncached().List(ctx, allSessions, client.InNamespace(ns)); err != nil {
+	sessions, err := c.sessions.ByIndex(ctx, indexUser, userID)
+	if err != nil {
 		return nil, err
 	}
 
-	var matches []*api.OauthSession
-	for i := range allSessions.Items {
-		session := &allSessions.Items[i]
-		if session.Spec.User == userID {
+	var matches []*pb.OauthSession
+	ns := user.GetMetadata().GetNamespace()
+	for _, session := range sessions {
+		if session.GetMetadata().GetNamespace() == ns {
+			if err := c.decrypt(ctx, session); err != nil {
+				return nil, err
+			}
 			matches = append(matches, session)
 		}
 	}
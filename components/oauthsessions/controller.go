@@ -0,0 +1,84 @@
+package oauthsessions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/kube/kubecontroller"
+	"github.com/justinsb/kweb/components/oauthsessions/pb"
+	userapi "github.com/justinsb/kweb/components/users/pb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// AddControllers adds the controller that deletes oauth sessions that can no longer be used:
+// sessions whose user has been deleted (sessions created before we set ownerReferences are not garbage-collected),
+// and sessions that have expired and cannot be refreshed (because there is no refresh token, or it has expired).
+// It also adds the job that re-encrypts sessions after key rotation.
+func (c *OAuthSessionsComponent) AddControllers(mgr *kubecontroller.Manager) error {
+	mgr.Add(kubecontroller.New(c.kube, &pb.OauthSession{}, kubecontroller.Options{Name: "oauthsessions"}, c.reconcileSession))
+	if c.keyStore != nil {
+		mgr.AddPeriodic("oauthsessions-reencrypt", c.reencryptInterval, c.ReencryptSessions)
+	}
+	return nil
+}
+
+func (c *OAuthSessionsComponent) reconcileSession(ctx context.Context, id types.NamespacedName, session *pb.OauthSession) (kubecontroller.Result, error) {
+	if session == nil {
+		return kubecontroller.Result{}, nil
+	}
+
+	userID := types.NamespacedName{Namespace: id.Namespace, Name: session.GetSpec().GetUser()}
+	userExists := false
+	if userID.Name != "" {
+		// We read directly, because the user may have been created after the session was cached
+		if err := c.kube.Get(ctx, userID, &userapi.User{}); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return kubecontroller.Result{}, fmt.Errorf("error getting user %v: %w", userID, err)
+			}
+		} else {
+			userExists = true
+		}
+	}
+	if !userExists {
+		klog.Infof("deleting oauth session %v for missing user %q", id, userID.Name)
+		return kubecontroller.Result{}, c.deleteSession(ctx, session)
+	}
+
+	expiresAt := session.GetSpec().GetExpiresAt()
+	if session.GetSpec().GetRefreshToken() != "" {
+		// The session can be refreshed until the refresh token expires
+		expiresAt = session.GetSpec().GetRefreshTokenExpiresAt()
+	}
+	if expiresAt <= 0 {
+		// The session does not expire
+		return kubecontroller.Result{}, nil
+	}
+	if ttl := time.Until(time.Unix(expiresAt, 0)); ttl > 0 {
+		return kubecontroller.Result{RequeueAfter: ttl}, nil
+	}
+	klog.Infof("deleting expired oauth session %v", id)
+	return kubecontroller.Result{}, c.deleteSession(ctx, session)
+}
+
+// deleteSession deletes the session, unless it has changed since we read it.
+func (c *OAuthSessionsComponent) deleteSession(ctx context.Context, session *pb.OauthSession) error {
+	uid := types.UID(session.GetMetadata().GetUid())
+	resourceVersion := session.GetMetadata().GetResourceVersion()
+	id := types.NamespacedName{Namespace: session.GetMetadata().GetNamespace(), Name: session.GetMetadata().GetName()}
+	opt := kubeclient.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion},
+	}
+	if err := c.kube.Delete(ctx, id, session, opt); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			// Already deleted, or changed; if it changed we will reconcile it again
+			return nil
+		}
+		return fmt.Errorf("error deleting oauth session %v: %w", id, err)
+	}
+	return nil
+}
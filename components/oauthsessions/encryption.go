@@ -0,0 +1,96 @@
+package oauthsessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/justinsb/kweb/components/keystore"
+	keystorepb "github.com/justinsb/kweb/components/keystore/pb"
+	"github.com/justinsb/kweb/components/kube/kubecrypt"
+	"github.com/justinsb/kweb/components/oauthsessions/pb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// The tokens in sessions are encrypted before they are written to the apiserver, and decrypted when we read them.
+// Sessions in the informer cache are encrypted.
+
+// keySet returns the keys for encrypting sessions, or nil if encryption is not configured.
+func (c *OAuthSessionsComponent) keySet(ctx context.Context) (keystore.KeySet, error) {
+	if c.keyStore == nil {
+		return nil, nil
+	}
+	keys, err := c.keyStore.KeySet(ctx, c.keySetName, keystorepb.KeyType_KEYTYPE_SECRETBOX)
+	if err != nil {
+		return nil, fmt.Errorf("error getting keys for oauth sessions: %w", err)
+	}
+	return keys, nil
+}
+
+// encrypt encrypts the tokens in the session, in place.
+func (c *OAuthSessionsComponent) encrypt(ctx context.Context, session *pb.OauthSession) error {
+	keys, err := c.keySet(ctx)
+	if err != nil || keys == nil {
+		return err
+	}
+	if err := kubecrypt.Encrypt(session, keys); err != nil {
+		return fmt.Errorf("error encrypting oauth session: %w", err)
+	}
+	return nil
+}
+
+// decrypt decrypts the tokens in the session, in place.
+func (c *OAuthSessionsComponent) decrypt(ctx context.Context, session *pb.OauthSession) error {
+	keys, err := c.keySet(ctx)
+	if err != nil || keys == nil {
+		return err
+	}
+	if err := kubecrypt.Decrypt(session, keys); err != nil {
+		return fmt.Errorf("error decrypting oauth session %s/%s: %w", session.GetMetadata().GetNamespace(), session.GetMetadata().GetName(), err)
+	}
+	return nil
+}
+
+// ReencryptSessions re-encrypts the sessions that are not encrypted with the active key,
+// either because the key has been rotated or because they were stored before encryption was configured.
+func (c *OAuthSessionsComponent) ReencryptSessions(ctx context.Context) error {
+	keys, err := c.keySet(ctx)
+	if err != nil || keys == nil {
+		return err
+	}
+
+	sessions, err := c.sessions.List(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, session := range sessions {
+		changed, err := kubecrypt.Reencrypt(session, keys)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		id := types.NamespacedName{Namespace: session.GetMetadata().GetNamespace(), Name: session.GetMetadata().GetName()}
+		klog.Infof("re-encrypting oauth session %v", id)
+		// The update is conditional on the resourceVersion; if the session changed, it was encrypted by the writer
+		if err := c.kube.Update(ctx, session); err != nil {
+			if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("error updating oauth session %v: %w", id, err))
+			continue
+		}
+		c.sessions.Observe(session)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("error re-encrypting oauth sessions: %w", err)
+	}
+	return nil
+}
@@ -4,17 +4,26 @@ import (
 	"context"
 	cryptorand "crypto/rand"
 	"encoding/base32"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/justinsb/kweb/components/oauthsessions/api"
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/kube"
+	"github.com/justinsb/kweb/components/oauthsessions/pb"
 	"github.com/justinsb/kweb/components/users"
 	"golang.org/x/oauth2"
+	"google.golang.org/protobuf/proto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 )
 
-func (c *OAuthSessionsComponent) StoreSession(ctx context.Context, token *oauth2.Token) error {
+// StoreSession stores the token issued by the provider for the logged-in user, so that we can call the provider's APIs later.
+// The session is recorded in the browser session, and is revoked on logout.
+func (c *OAuthSessionsComponent) StoreSession(ctx context.Context, providerID string, token *oauth2.Token) error {
 	user := users.GetUser(ctx)
 	if user == nil {
 		return fmt.Errorf("no user found")
@@ -26,48 +35,197 @@ func (c *OAuthSessionsComponent) StoreSession(ctx context.Context, token *oauth2
 		Namespace: user.Metadata.Namespace,
 		Name:      sessionID,
 	}
-	session := &api.OauthSession{}
-	session.Name = sessionKey.Name
-	session.Namespace = sessionKey.Namespace
+	session := &pb.OauthSession{
+		Metadata: &kube.ObjectMeta{
+			Name:      sessionKey.Name,
+			Namespace: sessionKey.Namespace,
+		},
+		Spec: &pb.OauthSessionSpec{
+			User:       user.GetMetadata().GetName(),
+			ProviderID: providerID,
+		},
+	}
+	setToken(session.Spec, token)
 
-	session.Spec = api.OauthSessionSpec{
-		User:         user.GetMetadata().GetName(),
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		ExpiresAt:    token.Expiry.Unix(),
+	if user.GetMetadata().GetUid() != "" {
+		// Sessions are garbage-collected when the user is deleted
+		session.Metadata.OwnerReferences = append(session.Metadata.OwnerReferences, kube.NewOwnerReference(user))
 	}
 
-	if err := c.kube.Uncached().Create(ctx, session); err != nil {
+	if err := c.encrypt(ctx, session); err != nil {
+		return err
+	}
+	if err := c.kube.Create(ctx, session); err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
+	c.sessions.Observe(session)
+
+	req := components.GetRequest(ctx)
+	info := &pb.LoginSessionInfo{}
+	req.Session.Get(info)
+	info.OauthSessions = append(info.OauthSessions, sessionID)
+	req.Session.Set(info)
 
 	return nil
 }
 
-func (c *OAuthSessionsComponent) RefreshSession(ctx context.Context, session *api.OauthSession) error {
-	// user := users.GetUser(ctx)
-	// if user == nil {
-	// 	return fmt.Errorf("no user found")
-	// }
+// setToken copies the token into the session spec.
+func setToken(spec *pb.OauthSessionSpec, token *oauth2.Token) {
+	spec.AccessToken = token.AccessToken
+	spec.TokenType = token.TokenType
+	if token.RefreshToken != "" {
+		// Providers do not always return a new refresh token when refreshing; we keep using the existing one
+		spec.RefreshToken = token.RefreshToken
+	}
+	spec.ExpiresAt = 0
+	if !token.Expiry.IsZero() {
+		spec.ExpiresAt = token.Expiry.Unix()
+	}
+	// GitHub reports the expiry of the refresh token, when the app uses expiring tokens
+	if refreshTokenExpiresIn := extraSeconds(token, "refresh_token_expires_in"); refreshTokenExpiresIn > 0 {
+		spec.RefreshTokenExpiresAt = time.Now().Add(time.Duration(refreshTokenExpiresIn) * time.Second).Unix()
+	}
+}
+
+// extraSeconds returns the numeric value of an extra field in the token response, or 0 if not set.
+func extraSeconds(token *oauth2.Token, key string) int64 {
+	switch v := token.Extra(key).(type) {
+	case float64:
+		return int64(v)
+	case string:
+		var n int64
+		fmt.Sscanf(v, "%d", &n)
+		return n
+	default:
+		return 0
+	}
+}
+
+// tokenFromSession returns the token stored in the session.
+func tokenFromSession(session *pb.OauthSession) *oauth2.Token {
+	spec := session.GetSpec()
+	token := &oauth2.Token{
+		AccessToken:  spec.GetAccessToken(),
+		TokenType:    spec.GetTokenType(),
+		RefreshToken: spec.GetRefreshToken(),
+	}
+	if spec.GetExpiresAt() > 0 {
+		token.Expiry = time.Unix(spec.GetExpiresAt(), 0)
+	}
+	return token
+}
 
-	// if session.Spec.RefreshToken == "" {
-	// 	return fmt.Errorf("cannot renew session without refreshToken")
-	// }
+// RefreshSession refreshes the token in the session with the provider, and writes the new token back to the session.
+// The write is conditional on the session not having changed; if another server refreshed the session first, we use its token.
+// If the provider rejects the refresh token, the session is deleted.
+func (c *OAuthSessionsComponent) RefreshSession(ctx context.Context, session *pb.OauthSession) error {
+	provider := c.providers[session.GetSpec().GetProviderID()]
+	if provider == nil {
+		return fmt.Errorf("cannot refresh session for unknown provider %q", session.GetSpec().GetProviderID())
+	}
+	if session.GetSpec().GetRefreshToken() == "" {
+		return fmt.Errorf("cannot renew session without refreshToken")
+	}
 
-	// session.Spec = api.OauthSessionSpec{
-	// 	User:         user.GetMetadata().GetName(),
-	// 	AccessToken:  token.AccessToken,
-	// 	RefreshToken: token.RefreshToken,
-	// 	ExpiresAt:    token.Expiry.Unix(),
-	// }
+	id := types.NamespacedName{Namespace: session.GetMetadata().GetNamespace(), Name: session.GetMetadata().GetName()}
 
-	if err := c.kube.Uncached().Update(ctx, session); err != nil {
+	// Expire the token we hold, so that the token source refreshes it
+	expired := tokenFromSession(session)
+	expired.Expiry = time.Unix(1, 0)
+	token, err := provider.TokenSource(ctx, expired).Token()
+	if err != nil {
+		// Another server may have refreshed (and rotated) the refresh token
+		latest := &pb.OauthSession{}
+		if getErr := c.kube.Get(ctx, id, latest); getErr == nil {
+			c.sessions.Observe(latest)
+			if decryptErr := c.decrypt(ctx, latest); decryptErr == nil && latest.GetSpec().GetRefreshToken() != session.GetSpec().GetRefreshToken() {
+				klog.Infof("oauth session %v was refreshed concurrently", id)
+				session.Reset()
+				proto.Merge(session, latest)
+				return nil
+			}
+		}
+
+		var retrieveError *oauth2.RetrieveError
+		if errors.As(err, &retrieveError) && retrieveError.ErrorCode == "invalid_grant" {
+			klog.Infof("refresh token for oauth session %v was rejected; deleting session", id)
+			if err := c.deleteSession(ctx, session); err != nil {
+				klog.Warningf("error deleting oauth session %v: %v", id, err)
+			}
+		}
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &pb.OauthSession{}
+		if err := c.kube.Get(ctx, id, latest); err != nil {
+			return err
+		}
+		if latest.GetSpec() == nil {
+			latest.Spec = &pb.OauthSessionSpec{}
+		}
+		setToken(latest.Spec, token)
+		if err := c.encrypt(ctx, latest); err != nil {
+			return err
+		}
+		if err := c.kube.Update(ctx, latest); err != nil {
+			return err
+		}
+		c.sessions.Observe(latest)
+
+		session.Reset()
+		proto.Merge(session, latest)
+		return c.decrypt(ctx, session)
+	}); err != nil {
 		return fmt.Errorf("failed to update session: %w", err)
 	}
 
 	return nil
 }
 
+// RevokeSessions revokes the oauth sessions created by the login of the current browser session, and deletes them.
+// Errors from the provider are logged, but do not prevent logout.
+func (c *OAuthSessionsComponent) RevokeSessions(ctx context.Context) error {
+	user := users.GetUser(ctx)
+	if user == nil {
+		return nil
+	}
+
+	req := components.GetRequest(ctx)
+	info := &pb.LoginSessionInfo{}
+	if !req.Session.Get(info) {
+		return nil
+	}
+	req.Session.Clear(&pb.LoginSessionInfo{})
+
+	for _, name := range info.GetOauthSessions() {
+		id := types.NamespacedName{Namespace: user.GetMetadata().GetNamespace(), Name: name}
+		session := &pb.OauthSession{}
+		if err := c.kube.Get(ctx, id, session); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("error getting oauth session %v: %w", id, err)
+		}
+		if session.GetSpec().GetUser() != user.GetMetadata().GetName() {
+			continue
+		}
+		if err := c.decrypt(ctx, session); err != nil {
+			return err
+		}
+
+		if provider := c.providers[session.GetSpec().GetProviderID()]; provider != nil {
+			if err := provider.RevokeToken(ctx, tokenFromSession(session)); err != nil {
+				klog.Warningf("error revoking token for oauth session %v: %v", id, err)
+			}
+		}
+		if err := c.deleteSession(ctx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func randomID() string {
 	// TODO: Create ids packages
 	b := make([]byte, 32)
@@ -0,0 +1,382 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: components/oauthsessions/pb/oauthsession.proto
+
+package pb
+
+import (
+	kube "github.com/justinsb/kweb/components/kube"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OauthSession struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Typemeta *kube.TypeMeta    `protobuf:"bytes,1,opt,name=typemeta,proto3" json:"typemeta,omitempty"`
+	Metadata *kube.ObjectMeta  `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Spec     *OauthSessionSpec `protobuf:"bytes,3,opt,name=spec,proto3" json:"spec,omitempty"`
+}
+
+func (x *OauthSession) Reset() {
+	*x = OauthSession{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_oauthsessions_pb_oauthsession_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OauthSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OauthSession) ProtoMessage() {}
+
+func (x *OauthSession) ProtoReflect() protoreflect.Message {
+	mi := &file_components_oauthsessions_pb_oauthsession_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OauthSession.ProtoReflect.Descriptor instead.
+func (*OauthSession) Descriptor() ([]byte, []int) {
+	return file_components_oauthsessions_pb_oauthsession_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OauthSession) GetTypemeta() *kube.TypeMeta {
+	if x != nil {
+		return x.Typemeta
+	}
+	return nil
+}
+
+func (x *OauthSession) GetMetadata() *kube.ObjectMeta {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *OauthSession) GetSpec() *OauthSessionSpec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+type OauthSessionSpec struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// access_token and refresh_token are encrypted at rest.
+	AccessToken  string `protobuf:"bytes,2,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	RefreshToken string `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	ExpiresAt    int64  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// providerID is the login provider that issued the token, and that refreshes it.
+	ProviderID string `protobuf:"bytes,5,opt,name=providerID,proto3" json:"providerID,omitempty"`
+	TokenType  string `protobuf:"bytes,6,opt,name=token_type,json=tokenType,proto3" json:"token_type,omitempty"`
+	// refresh_token_expires_at is the time after which the refresh token can no longer be used, if the provider reports one.
+	RefreshTokenExpiresAt int64 `protobuf:"varint,7,opt,name=refresh_token_expires_at,json=refreshTokenExpiresAt,proto3" json:"refresh_token_expires_at,omitempty"`
+}
+
+func (x *OauthSessionSpec) Reset() {
+	*x = OauthSessionSpec{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_oauthsessions_pb_oauthsession_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OauthSessionSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OauthSessionSpec) ProtoMessage() {}
+
+func (x *OauthSessionSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_components_oauthsessions_pb_oauthsession_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OauthSessionSpec.ProtoReflect.Descriptor instead.
+func (*OauthSessionSpec) Descriptor() ([]byte, []int) {
+	return file_components_oauthsessions_pb_oauthsession_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OauthSessionSpec) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *OauthSessionSpec) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *OauthSessionSpec) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *OauthSessionSpec) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *OauthSessionSpec) GetProviderID() string {
+	if x != nil {
+		return x.ProviderID
+	}
+	return ""
+}
+
+func (x *OauthSessionSpec) GetTokenType() string {
+	if x != nil {
+		return x.TokenType
+	}
+	return ""
+}
+
+func (x *OauthSessionSpec) GetRefreshTokenExpiresAt() int64 {
+	if x != nil {
+		return x.RefreshTokenExpiresAt
+	}
+	return 0
+}
+
+// LoginSessionInfo is stored in the browser session, recording the oauth sessions created by this login,
+// so that we revoke them on logout.
+type LoginSessionInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OauthSessions []string `protobuf:"bytes,1,rep,name=oauth_sessions,json=oauthSessions,proto3" json:"oauth_sessions,omitempty"`
+}
+
+func (x *LoginSessionInfo) Reset() {
+	*x = LoginSessionInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_oauthsessions_pb_oauthsession_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginSessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginSessionInfo) ProtoMessage() {}
+
+func (x *LoginSessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_components_oauthsessions_pb_oauthsession_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginSessionInfo.ProtoReflect.Descriptor instead.
+func (*LoginSessionInfo) Descriptor() ([]byte, []int) {
+	return file_components_oauthsessions_pb_oauthsession_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LoginSessionInfo) GetOauthSessions() []string {
+	if x != nil {
+		return x.OauthSessions
+	}
+	return nil
+}
+
+var File_components_oauthsessions_pb_oauthsession_proto protoreflect.FileDescriptor
+
+var file_components_oauthsessions_pb_oauthsession_proto_rawDesc = []byte{
+	0x0a, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x6f, 0x61, 0x75,
+	0x74, 0x68, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x70, 0x62, 0x2f, 0x6f, 0x61,
+	0x75, 0x74, 0x68, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x02, 0x70, 0x62, 0x1a, 0x1a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73,
+	0x2f, 0x6b, 0x75, 0x62, 0x65, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0xe8, 0x01, 0x0a, 0x0c, 0x4f, 0x61, 0x75, 0x74, 0x68, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x2a, 0x0a, 0x08, 0x74, 0x79, 0x70, 0x65, 0x6d, 0x65, 0x74, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x4d,
+	0x65, 0x74, 0x61, 0x52, 0x08, 0x74, 0x79, 0x70, 0x65, 0x6d, 0x65, 0x74, 0x61, 0x12, 0x2c, 0x0a,
+	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x74,
+	0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x28, 0x0a, 0x04, 0x73,
+	0x70, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x62, 0x2e, 0x4f,
+	0x61, 0x75, 0x74, 0x68, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63, 0x52,
+	0x04, 0x73, 0x70, 0x65, 0x63, 0x3a, 0x54, 0x8a, 0xb5, 0x18, 0x50, 0x0a, 0x0c, 0x4f, 0x61, 0x75,
+	0x74, 0x68, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x1a, 0x0a, 0x04, 0x55, 0x73, 0x65,
+	0x72, 0x12, 0x06, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x1a, 0x0a, 0x2e, 0x73, 0x70, 0x65, 0x63,
+	0x2e, 0x75, 0x73, 0x65, 0x72, 0x1a, 0x24, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x72, 0x12, 0x06, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x1a, 0x10, 0x2e, 0x73, 0x70, 0x65, 0x63,
+	0x2e, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x49, 0x44, 0x22, 0x91, 0x02, 0x0a, 0x10,
+	0x4f, 0x61, 0x75, 0x74, 0x68, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x70, 0x65, 0x63,
+	0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x27, 0x0a, 0x0c, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x04, 0x90, 0xb5, 0x18, 0x01,
+	0x52, 0x0b, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x29, 0x0a,
+	0x0d, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x42, 0x04, 0x90, 0xb5, 0x18, 0x01, 0x52, 0x0c, 0x72, 0x65, 0x66, 0x72,
+	0x65, 0x73, 0x68, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69,
+	0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x76, 0x69,
+	0x64, 0x65, 0x72, 0x49, 0x44, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x72, 0x6f,
+	0x76, 0x69, 0x64, 0x65, 0x72, 0x49, 0x44, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x12, 0x37, 0x0a, 0x18, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73,
+	0x68, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f,
+	0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73,
+	0x68, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x22,
+	0x39, 0x0a, 0x10, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x61, 0x75, 0x74, 0x68, 0x5f, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x6f, 0x61, 0x75,
+	0x74, 0x68, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x91, 0x01, 0x0a, 0x06, 0x63,
+	0x6f, 0x6d, 0x2e, 0x70, 0x62, 0x42, 0x11, 0x4f, 0x61, 0x75, 0x74, 0x68, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x73, 0x74, 0x69, 0x6e, 0x73, 0x62, 0x2f,
+	0x6b, 0x77, 0x65, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f,
+	0x6f, 0x61, 0x75, 0x74, 0x68, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x70, 0x62,
+	0xa2, 0x02, 0x03, 0x50, 0x58, 0x58, 0xaa, 0x02, 0x02, 0x50, 0x62, 0xca, 0x02, 0x02, 0x50, 0x62,
+	0xe2, 0x02, 0x0e, 0x50, 0x62, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0xea, 0x02, 0x02, 0x50, 0x62, 0x82, 0xb5, 0x18, 0x14, 0x0a, 0x08, 0x6b, 0x77, 0x65, 0x62,
+	0x2e, 0x64, 0x65, 0x76, 0x12, 0x08, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_components_oauthsessions_pb_oauthsession_proto_rawDescOnce sync.Once
+	file_components_oauthsessions_pb_oauthsession_proto_rawDescData = file_components_oauthsessions_pb_oauthsession_proto_rawDesc
+)
+
+func file_components_oauthsessions_pb_oauthsession_proto_rawDescGZIP() []byte {
+	file_components_oauthsessions_pb_oauthsession_proto_rawDescOnce.Do(func() {
+		file_components_oauthsessions_pb_oauthsession_proto_rawDescData = protoimpl.X.CompressGZIP(file_components_oauthsessions_pb_oauthsession_proto_rawDescData)
+	})
+	return file_components_oauthsessions_pb_oauthsession_proto_rawDescData
+}
+
+var file_components_oauthsessions_pb_oauthsession_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_components_oauthsessions_pb_oauthsession_proto_goTypes = []interface{}{
+	(*OauthSession)(nil),     // 0: pb.OauthSession
+	(*OauthSessionSpec)(nil), // 1: pb.OauthSessionSpec
+	(*LoginSessionInfo)(nil), // 2: pb.LoginSessionInfo
+	(*kube.TypeMeta)(nil),    // 3: kube.TypeMeta
+	(*kube.ObjectMeta)(nil),  // 4: kube.ObjectMeta
+}
+var file_components_oauthsessions_pb_oauthsession_proto_depIdxs = []int32{
+	3, // 0: pb.OauthSession.typemeta:type_name -> kube.TypeMeta
+	4, // 1: pb.OauthSession.metadata:type_name -> kube.ObjectMeta
+	1, // 2: pb.OauthSession.spec:type_name -> pb.OauthSessionSpec
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_components_oauthsessions_pb_oauthsession_proto_init() }
+func file_components_oauthsessions_pb_oauthsession_proto_init() {
+	if File_components_oauthsessions_pb_oauthsession_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_components_oauthsessions_pb_oauthsession_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OauthSession); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_oauthsessions_pb_oauthsession_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OauthSessionSpec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_components_oauthsessions_pb_oauthsession_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoginSessionInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_components_oauthsessions_pb_oauthsession_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_components_oauthsessions_pb_oauthsession_proto_goTypes,
+		DependencyIndexes: file_components_oauthsessions_pb_oauthsession_proto_depIdxs,
+		MessageInfos:      file_components_oauthsessions_pb_oauthsession_proto_msgTypes,
+	}.Build()
+	File_components_oauthsessions_pb_oauthsession_proto = out.File
+	file_components_oauthsessions_pb_oauthsession_proto_rawDesc = nil
+	file_components_oauthsessions_pb_oauthsession_proto_goTypes = nil
+	file_components_oauthsessions_pb_oauthsession_proto_depIdxs = nil
+}
@@ -31,6 +31,9 @@ func (o *Options) InitDefaults(appName string) {
 
 type Component struct {
 	options Options
+
+	// errorPage is the template for error pages, loaded from _error.html
+	errorPage *TemplateEndpoint
 }
 
 func New(opt Options) *Component {
@@ -60,17 +63,12 @@ func loadRaw(fs fs.FS, key string) ([]byte, error) {
 
 func (c *Component) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
 	m := &pageMux{
-		s:        s,
-		mux:      mux,
-		patterns: make(map[string]*patternMux),
+		component: c,
+		router:    s.Routes(),
 	}
 	if err := m.addHandlersFromDir(c.options.Base, "."); err != nil {
 		return err
 	}
-
-	for pattern, handler := range m.patterns {
-		mux.HandleFunc(pattern, s.ServeHTTP(handler.ServeHTTP))
-	}
 	return nil
 }
 
@@ -81,49 +79,8 @@ func (c *Component) AddToScope(ctx context.Context, scope *scopes.Scope) {
 }
 
 type pageMux struct {
-	s   *components.Server
-	mux *http.ServeMux
-
-	patterns map[string]*patternMux
-}
-
-type patternMux struct {
-	handlers []patternHandler
-}
-
-type patternHandler struct {
-	match       []string
-	templateMap map[int]string
-	handler     func(ctx context.Context, req *components.Request) (components.Response, error)
-}
-
-func (m *patternMux) ServeHTTP(ctx context.Context, req *components.Request) (components.Response, error) {
-	tokens := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
-	for _, h := range m.handlers {
-		klog.Infof("check match %+v", h.match)
-		if len(h.match) != len(tokens) {
-			continue
-		}
-		isMatch := true
-		for i, s := range h.match {
-			if s == "" {
-				continue
-			}
-			if s != tokens[i] {
-				isMatch = false
-				break
-			}
-		}
-		if !isMatch {
-			continue
-		}
-		for i, s := range h.templateMap {
-			req.PathParameters[s] = tokens[i]
-		}
-		return h.handler(ctx, req)
-	}
-	klog.Warningf("no match found for tokens %+v", tokens)
-	return components.ErrorResponse(http.StatusNotFound), nil
+	component *Component
+	router    *components.Router
 }
 
 func (m *pageMux) addHandlersFromDir(base fs.FS, p string) error {
@@ -165,37 +122,30 @@ func (m *pageMux) addHandlers(base fs.FS, p string, info fs.DirEntry) error {
 		if strings.HasSuffix(serveOn, "/index") {
 			serveOn = strings.TrimSuffix(serveOn, "index")
 		}
-		templateTokens := strings.Split(strings.Trim(serveOn, "/"), "/")
 
-		match := make([]string, len(templateTokens))
-		templateMap := make(map[int]string)
-		for i, s := range templateTokens {
-			if strings.HasPrefix(s, "_") {
-				templateMap[i] = strings.TrimPrefix(s, "_")
-			} else {
-				match[i] = s
-			}
+		// _error.html at the top level is used for error pages, rather than being served
+		if serveOn == "/_error" {
+			klog.Infof("using %s for error pages", p)
+			m.component.errorPage = endpoint
+			return nil
 		}
-		pattern := ""
-		for _, s := range templateTokens {
+
+		// A segment named _name is a path parameter
+		var pattern []string
+		for _, s := range strings.Split(strings.Trim(serveOn, "/"), "/") {
 			if strings.HasPrefix(s, "_") {
-				pattern += "/"
-				break
+				s = "{" + strings.TrimPrefix(s, "_") + "}"
 			}
-			pattern += "/" + s
+			pattern = append(pattern, s)
 		}
 
 		klog.Infof("serving %s on %s", p, serveOn)
-		pm, ok := m.patterns[pattern]
-		if !ok {
-			pm = &patternMux{}
-			m.patterns[pattern] = pm
+		// Pages can be the target of forms, so they accept POST as well as GET
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			if err := m.router.Handle(method, "/"+strings.Join(pattern, "/"), endpoint.ServeHTTP); err != nil {
+				return fmt.Errorf("error registering handler for %q: %w", p, err)
+			}
 		}
-		pm.handlers = append(pm.handlers, patternHandler{
-			match:       match,
-			templateMap: templateMap,
-			handler:     endpoint.ServeHTTP,
-		})
 	}
 
 	if info.IsDir() {
@@ -218,6 +168,42 @@ func (e *TemplateEndpoint) ServeHTTP(ctx context.Context, req *components.Reques
 	return e.Render(ctx, req, data)
 }
 
+var _ components.ErrorPageRenderer = &Component{}
+
+// RenderErrorPage renders the _error.html template, if one was provided.
+// The template can use the error value, with status, title, message and details.
+func (c *Component) RenderErrorPage(ctx context.Context, req *components.Request, httpError *components.HTTPError) (components.Response, error) {
+	if c.errorPage == nil {
+		return nil, nil
+	}
+
+	server := components.GetServer(ctx)
+	data := server.NewScope(ctx)
+
+	message := httpError.Message
+	if message == "" {
+		message = httpError.Title()
+	}
+	data.Values["error"] = scopes.Value{
+		Value: map[string]any{
+			"status":  httpError.Status,
+			"title":   httpError.Title(),
+			"message": message,
+			"details": httpError.Details,
+		},
+	}
+
+	var b bytes.Buffer
+	if err := c.errorPage.template.RenderHTML(ctx, &b, req, data); err != nil {
+		return nil, err
+	}
+	response := components.SimpleResponse{
+		StatusCode: httpError.Status,
+		Body:       b.Bytes(),
+	}
+	return response, nil
+}
+
 func (e *TemplateEndpoint) Render(ctx context.Context, req *components.Request, data *scopes.Scope) (components.Response, error) {
 	var b bytes.Buffer
 	if err := e.template.RenderHTML(ctx, &b, req, data); err != nil {
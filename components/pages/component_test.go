@@ -0,0 +1,123 @@
+package pages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/justinsb/kweb/components"
+)
+
+func newTestServer(t *testing.T, files fstest.MapFS) http.HandlerFunc {
+	t.Helper()
+	c := New(Options{Base: files})
+	server := &components.Server{Components: []components.Component{c}}
+	if err := server.BuildFilterChain(); err != nil {
+		t.Fatalf("error building filter chain: %v", err)
+	}
+	if err := c.RegisterHandlers(server, http.NewServeMux()); err != nil {
+		t.Fatalf("error registering handlers: %v", err)
+	}
+	return server.ServeHTTP(server.Routes().Serve)
+}
+
+func TestErrorPage(t *testing.T) {
+	handler := newTestServer(t, fstest.MapFS{
+		"index.html":  {Data: []byte(`<html><body><p>home</p></body></html>`)},
+		"_error.html": {Data: []byte(`<html><body><p>error {{error.status}}: {{error.message}}</p></body></html>`)},
+	})
+
+	grid := []struct {
+		name     string
+		path     string
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{name: "html", path: "/missing", accept: "text/html", wantBody: "error 404: Not Found"},
+		{name: "error page is not served", path: "/_error", accept: "text/html", wantBody: "error 404: Not Found"},
+		{name: "text", path: "/missing", accept: "", wantType: "text/plain; charset=utf-8", wantBody: "Not Found\n"},
+		{name: "json", path: "/missing", accept: "application/json", wantType: "application/problem+json", wantBody: `"status":404`},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", g.path, nil)
+			r.Header.Set("Accept", g.accept)
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+			}
+			if g.wantType != "" {
+				if got := w.Header().Get("Content-Type"); got != g.wantType {
+					t.Errorf("got content type %q, want %q", got, g.wantType)
+				}
+			}
+			if !strings.Contains(w.Body.String(), g.wantBody) {
+				t.Errorf("got body %q, want %q", w.Body.String(), g.wantBody)
+			}
+		})
+	}
+}
+
+func TestNoErrorPage(t *testing.T) {
+	handler := newTestServer(t, fstest.MapFS{
+		"index.html": {Data: []byte(`<html><body><p>home</p></body></html>`)},
+	})
+
+	r := httptest.NewRequest("GET", "/missing", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	// Without _error.html, we fall back to a plain text error
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("got content type %q", got)
+	}
+}
+
+func TestPageMethods(t *testing.T) {
+	handler := newTestServer(t, fstest.MapFS{
+		"index.html":       {Data: []byte(`<html><body><p>home</p></body></html>`)},
+		"items/_id.html":   {Data: []byte(`<html><body><p>item</p></body></html>`)},
+		"items/index.html": {Data: []byte(`<html><body><p>items</p></body></html>`)},
+	})
+
+	grid := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{method: "GET", path: "/", wantStatus: http.StatusOK, wantBody: "home"},
+		{method: "POST", path: "/", wantStatus: http.StatusOK, wantBody: "home"},
+		{method: "HEAD", path: "/", wantStatus: http.StatusOK},
+		{method: "GET", path: "/items/", wantStatus: http.StatusOK, wantBody: "items"},
+		{method: "GET", path: "/items/1", wantStatus: http.StatusOK, wantBody: "item"},
+		{method: "POST", path: "/items/1", wantStatus: http.StatusOK, wantBody: "item"},
+		{method: "DELETE", path: "/items/1", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, g := range grid {
+		r := httptest.NewRequest(g.method, g.path, nil)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != g.wantStatus {
+			t.Errorf("%s %s: got status %d, want %d", g.method, g.path, w.Code, g.wantStatus)
+		}
+		if !strings.Contains(w.Body.String(), g.wantBody) {
+			t.Errorf("%s %s: got body %q, want %q", g.method, g.path, w.Body.String(), g.wantBody)
+		}
+		if g.wantStatus == http.StatusMethodNotAllowed {
+			if got := w.Header().Get("Allow"); got != "GET, HEAD, POST" {
+				t.Errorf("%s %s: got Allow %q", g.method, g.path, got)
+			}
+		}
+	}
+}
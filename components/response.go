@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
 
 	"k8s.io/klog/v2"
 )
@@ -48,6 +51,25 @@ func RedirectResponse(redirectURL string) Response {
 	return r
 }
 
+// LocalRedirect returns the redirect if it is a path on our own site, and "/" otherwise, so we are not an open redirector.
+// Browsers treat a backslash like a slash (so "/\evil.com" is protocol-relative), and ignore some control characters,
+// so we reject those rather than trying to normalize them.
+func LocalRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		return "/"
+	}
+	for _, r := range redirect {
+		if r == '\\' || unicode.IsControl(r) {
+			return "/"
+		}
+	}
+	u, err := url.Parse(redirect)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil {
+		return "/"
+	}
+	return redirect
+}
+
 type SimpleResponse struct {
 	StatusCode int
 	StatusText string
@@ -0,0 +1,27 @@
+package components
+
+import "testing"
+
+func TestLocalRedirect(t *testing.T) {
+	grid := []struct {
+		redirect string
+		want     string
+	}{
+		{redirect: "/repos?page=2#top", want: "/repos?page=2#top"},
+		{redirect: "/", want: "/"},
+		{redirect: "", want: "/"},
+		{redirect: "repos", want: "/"},
+		{redirect: "https://evil.com/", want: "/"},
+		{redirect: "//evil.com", want: "/"},
+		{redirect: `/\evil.com`, want: "/"},
+		{redirect: `/\/evil.com`, want: "/"},
+		{redirect: "/\t/evil.com", want: "/"},
+		{redirect: "/\n/evil.com", want: "/"},
+		{redirect: "/%zz", want: "/"},
+	}
+	for _, g := range grid {
+		if got := LocalRedirect(g.redirect); got != g.want {
+			t.Errorf("LocalRedirect(%q): got %q, want %q", g.redirect, got, g.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RequestHandler is the signature of a function that serves a request.
+type RequestHandler func(ctx context.Context, req *Request) (Response, error)
+
+// Router matches requests by method and path pattern.
+//
+// Patterns are slash-separated segments, where a segment can be:
+//   - a literal, which must match exactly
+//   - {name}, which matches any single segment and sets the path parameter "name"
+//   - {name...}, which must be the last segment and matches all remaining segments (possibly none)
+//
+// Leading and trailing slashes are not significant.
+// When multiple patterns match, literal segments are preferred over wildcards.
+type Router struct {
+	routes []*route
+}
+
+type route struct {
+	method   string
+	pattern  string
+	segments []routeSegment
+	handler  RequestHandler
+}
+
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentParameter
+	segmentRest
+)
+
+type routeSegment struct {
+	kind  segmentKind
+	value string
+}
+
+// NewRouter constructs an empty Router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func parsePattern(pattern string) ([]routeSegment, error) {
+	var segments []routeSegment
+	tokens := splitPath(pattern)
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "{") && strings.HasSuffix(token, "}") {
+			name := strings.TrimSuffix(strings.TrimPrefix(token, "{"), "}")
+			if strings.HasSuffix(name, "...") {
+				name = strings.TrimSuffix(name, "...")
+				if i != len(tokens)-1 {
+					return nil, fmt.Errorf("wildcard {%s...} must be the last segment in pattern %q", name, pattern)
+				}
+				if name == "" {
+					return nil, fmt.Errorf("wildcard in pattern %q must have a name", pattern)
+				}
+				segments = append(segments, routeSegment{kind: segmentRest, value: name})
+				continue
+			}
+			if name == "" {
+				return nil, fmt.Errorf("wildcard in pattern %q must have a name", pattern)
+			}
+			segments = append(segments, routeSegment{kind: segmentParameter, value: name})
+			continue
+		}
+		if strings.ContainsAny(token, "{}") {
+			return nil, fmt.Errorf("invalid segment %q in pattern %q", token, pattern)
+		}
+		segments = append(segments, routeSegment{kind: segmentLiteral, value: token})
+	}
+	return segments, nil
+}
+
+// Handle registers the handler for requests with the given method and path pattern.
+// An empty method matches any method.
+func (r *Router) Handle(method string, pattern string, handler RequestHandler) error {
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+	method = strings.ToUpper(method)
+	for _, existing := range r.routes {
+		if existing.method == method && samePattern(existing.segments, segments) {
+			return fmt.Errorf("pattern %s %q conflicts with already registered pattern %q", method, pattern, existing.pattern)
+		}
+	}
+	r.routes = append(r.routes, &route{
+		method:   method,
+		pattern:  pattern,
+		segments: segments,
+		handler:  handler,
+	})
+	return nil
+}
+
+// GET registers a handler for GET (and HEAD) requests.
+func (r *Router) GET(pattern string, handler RequestHandler) error {
+	return r.Handle(http.MethodGet, pattern, handler)
+}
+
+// POST registers a handler for POST requests.
+func (r *Router) POST(pattern string, handler RequestHandler) error {
+	return r.Handle(http.MethodPost, pattern, handler)
+}
+
+// PUT registers a handler for PUT requests.
+func (r *Router) PUT(pattern string, handler RequestHandler) error {
+	return r.Handle(http.MethodPut, pattern, handler)
+}
+
+// DELETE registers a handler for DELETE requests.
+func (r *Router) DELETE(pattern string, handler RequestHandler) error {
+	return r.Handle(http.MethodDelete, pattern, handler)
+}
+
+// HasRoutes returns true if any routes have been registered.
+func (r *Router) HasRoutes() bool {
+	return len(r.routes) != 0
+}
+
+func samePattern(a, b []routeSegment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].kind != b[i].kind {
+			return false
+		}
+		if a[i].kind == segmentLiteral && a[i].value != b[i].value {
+			return false
+		}
+	}
+	return true
+}
+
+// match checks if the route matches the path tokens, returning the path parameters if so.
+func (rt *route) match(tokens []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, segment := range rt.segments {
+		if segment.kind == segmentRest {
+			params[segment.value] = strings.Join(tokens[i:], "/")
+			return params, true
+		}
+		if i >= len(tokens) {
+			return nil, false
+		}
+		switch segment.kind {
+		case segmentLiteral:
+			if tokens[i] != segment.value {
+				return nil, false
+			}
+		case segmentParameter:
+			params[segment.value] = tokens[i]
+		}
+	}
+	if len(tokens) != len(rt.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// moreSpecific returns true if route a should be preferred over route b.
+func moreSpecific(a, b *route) bool {
+	for i := 0; i < len(a.segments) && i < len(b.segments); i++ {
+		if a.segments[i].kind != b.segments[i].kind {
+			return a.segments[i].kind < b.segments[i].kind
+		}
+	}
+	if len(a.segments) != len(b.segments) {
+		return len(a.segments) > len(b.segments)
+	}
+	// Prefer an explicit method over a wildcard method
+	return a.method != "" && b.method == ""
+}
+
+func (rt *route) matchesMethod(method string) bool {
+	if rt.method == "" || rt.method == method {
+		return true
+	}
+	return rt.method == http.MethodGet && method == http.MethodHead
+}
+
+// Serve dispatches the request to the best matching route.
+// If no route matches the path, it returns a 404 HTTPError; if routes match the path but not the method,
+// it returns a 405 HTTPError with the Allow header set.
+func (r *Router) Serve(ctx context.Context, req *Request) (Response, error) {
+	tokens := splitPath(req.URL.Path)
+
+	var best *route
+	var bestParams map[string]string
+	allowed := make(map[string]bool)
+	for _, rt := range r.routes {
+		params, ok := rt.match(tokens)
+		if !ok {
+			continue
+		}
+		if !rt.matchesMethod(req.Method) {
+			allowed[rt.method] = true
+			if rt.method == http.MethodGet {
+				allowed[http.MethodHead] = true
+			}
+			continue
+		}
+		if best == nil || moreSpecific(rt, best) {
+			best = rt
+			bestParams = params
+		}
+	}
+
+	if best == nil {
+		if len(allowed) == 0 {
+			return nil, NewHTTPError(http.StatusNotFound, "", nil)
+		}
+		var methods []string
+		for method := range allowed {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		return nil, NewHTTPError(http.StatusMethodNotAllowed, "", nil).WithHeader("Allow", strings.Join(methods, ", "))
+	}
+
+	for k, v := range bestParams {
+		req.PathParameters[k] = v
+	}
+	return best.handler(ctx, req)
+}
+
+// Routes returns the shared router for the server; components can register routes with it in RegisterHandlers.
+func (s *Server) Routes() *Router {
+	if s.router == nil {
+		s.router = NewRouter()
+	}
+	return s.router
+}
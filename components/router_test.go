@@ -0,0 +1,180 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterErrors(t *testing.T) {
+	router := NewRouter()
+	handler := func(ctx context.Context, req *Request) (Response, error) {
+		return &SimpleResponse{}, nil
+	}
+	if err := router.GET("/items/{id}", handler); err != nil {
+		t.Fatalf("error registering route: %v", err)
+	}
+	if err := router.DELETE("/items/{id}", handler); err != nil {
+		t.Fatalf("error registering route: %v", err)
+	}
+
+	grid := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{method: "GET", path: "/items/1", wantStatus: 0},
+		{method: "HEAD", path: "/items/1", wantStatus: 0},
+		{method: "GET", path: "/other", wantStatus: http.StatusNotFound},
+		{method: "POST", path: "/items/1", wantStatus: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET, HEAD"},
+	}
+	for _, g := range grid {
+		req := &Request{
+			Request:        httptest.NewRequest(g.method, g.path, nil),
+			PathParameters: make(map[string]string),
+		}
+		_, err := router.Serve(context.Background(), req)
+		if g.wantStatus == 0 {
+			if err != nil {
+				t.Errorf("%s %s: unexpected error %v", g.method, g.path, err)
+			}
+			continue
+		}
+
+		var httpError *HTTPError
+		if !errors.As(err, &httpError) {
+			t.Errorf("%s %s: expected HTTPError, got %v", g.method, g.path, err)
+			continue
+		}
+		if httpError.Status != g.wantStatus {
+			t.Errorf("%s %s: got status %d, want %d", g.method, g.path, httpError.Status, g.wantStatus)
+		}
+		if got := httpError.Headers.Get("Allow"); got != g.wantAllow {
+			t.Errorf("%s %s: got Allow %q, want %q", g.method, g.path, got, g.wantAllow)
+		}
+	}
+}
+
+// serveRoute dispatches the request, returning the name of the route that served it and the path parameters.
+func serveRoute(t *testing.T, router *Router, method string, path string) (string, map[string]string) {
+	t.Helper()
+	req := &Request{
+		Request:        httptest.NewRequest(method, path, nil),
+		PathParameters: make(map[string]string),
+	}
+	response, err := router.Serve(context.Background(), req)
+	if err != nil {
+		return "", nil
+	}
+	return string(response.(*SimpleResponse).Body), req.PathParameters
+}
+
+func namedHandler(name string) RequestHandler {
+	return func(ctx context.Context, req *Request) (Response, error) {
+		return &SimpleResponse{Body: []byte(name)}, nil
+	}
+}
+
+func TestRouterMatching(t *testing.T) {
+	router := NewRouter()
+	for _, r := range []struct {
+		method  string
+		pattern string
+	}{
+		{method: "GET", pattern: "/"},
+		{method: "GET", pattern: "/repos/{owner}/{repo}"},
+		{method: "GET", pattern: "/repos/{owner}/settings"},
+		{method: "GET", pattern: "/repos/{owner}/{repo}/files/{path...}"},
+		{method: "GET", pattern: "/static/{path...}"},
+		{method: "GET", pattern: "/static/favicon.ico"},
+		{method: "", pattern: "/any/{id}"},
+		{method: "POST", pattern: "/any/{id}"},
+	} {
+		if err := router.Handle(r.method, r.pattern, namedHandler(r.method+" "+r.pattern)); err != nil {
+			t.Fatalf("error registering %s %q: %v", r.method, r.pattern, err)
+		}
+	}
+
+	grid := []struct {
+		method     string
+		path       string
+		wantRoute  string
+		wantParams map[string]string
+	}{
+		{method: "GET", path: "/", wantRoute: "GET /"},
+		{method: "GET", path: "/repos/kweb/server", wantRoute: "GET /repos/{owner}/{repo}", wantParams: map[string]string{"owner": "kweb", "repo": "server"}},
+		{method: "GET", path: "/repos/kweb/server/", wantRoute: "GET /repos/{owner}/{repo}", wantParams: map[string]string{"owner": "kweb", "repo": "server"}},
+		{method: "GET", path: "/repos/kweb/settings", wantRoute: "GET /repos/{owner}/settings", wantParams: map[string]string{"owner": "kweb"}},
+		{method: "GET", path: "/repos/kweb/server/files/a/b/c.go", wantRoute: "GET /repos/{owner}/{repo}/files/{path...}", wantParams: map[string]string{"owner": "kweb", "repo": "server", "path": "a/b/c.go"}},
+		{method: "GET", path: "/repos/kweb/server/files", wantRoute: "GET /repos/{owner}/{repo}/files/{path...}", wantParams: map[string]string{"owner": "kweb", "repo": "server", "path": ""}},
+		{method: "GET", path: "/static/css/site.css", wantRoute: "GET /static/{path...}", wantParams: map[string]string{"path": "css/site.css"}},
+		{method: "GET", path: "/static/favicon.ico", wantRoute: "GET /static/favicon.ico"},
+		{method: "PUT", path: "/any/1", wantRoute: " /any/{id}", wantParams: map[string]string{"id": "1"}},
+		{method: "POST", path: "/any/1", wantRoute: "POST /any/{id}", wantParams: map[string]string{"id": "1"}},
+		{method: "GET", path: "/repos/kweb", wantRoute: ""},
+		{method: "GET", path: "/repos/kweb/server/extra", wantRoute: ""},
+	}
+	for _, g := range grid {
+		route, params := serveRoute(t, router, g.method, g.path)
+		if route != g.wantRoute {
+			t.Errorf("%s %s: served by %q, want %q", g.method, g.path, route, g.wantRoute)
+			continue
+		}
+		if len(params) != len(g.wantParams) {
+			t.Errorf("%s %s: got params %v, want %v", g.method, g.path, params, g.wantParams)
+			continue
+		}
+		for k, v := range g.wantParams {
+			if params[k] != v {
+				t.Errorf("%s %s: got params %v, want %v", g.method, g.path, params, g.wantParams)
+				break
+			}
+		}
+	}
+}
+
+func TestRouterRegistrationErrors(t *testing.T) {
+	handler := namedHandler("")
+
+	grid := []struct {
+		name     string
+		existing []string
+		method   string
+		pattern  string
+		wantErr  string
+	}{
+		{name: "same pattern", existing: []string{"/items/{id}"}, method: "GET", pattern: "/items/{id}", wantErr: "conflicts with already registered pattern"},
+		{name: "different parameter name", existing: []string{"/items/{id}"}, method: "GET", pattern: "/items/{name}", wantErr: "conflicts with already registered pattern"},
+		{name: "trailing slash", existing: []string{"/items"}, method: "GET", pattern: "/items/", wantErr: "conflicts with already registered pattern"},
+		{name: "different method", existing: []string{"/items/{id}"}, method: "POST", pattern: "/items/{id}"},
+		{name: "different literal", existing: []string{"/items/{id}"}, method: "GET", pattern: "/items/new"},
+		{name: "wildcard not last", method: "GET", pattern: "/files/{path...}/raw", wantErr: "must be the last segment"},
+		{name: "unnamed parameter", method: "GET", pattern: "/items/{}", wantErr: "must have a name"},
+		{name: "unnamed wildcard", method: "GET", pattern: "/files/{...}", wantErr: "must have a name"},
+		{name: "partial parameter", method: "GET", pattern: "/items/id-{id}", wantErr: "invalid segment"},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			router := NewRouter()
+			for _, pattern := range g.existing {
+				if err := router.GET(pattern, handler); err != nil {
+					t.Fatalf("error registering %q: %v", pattern, err)
+				}
+			}
+			err := router.Handle(g.method, g.pattern, handler)
+			if g.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), g.wantErr) {
+				t.Errorf("got error %v, want %q", err, g.wantErr)
+			}
+		})
+	}
+}
@@ -11,6 +11,13 @@ import (
 
 type Server struct {
 	Components []Component
+
+	// filters is the ordered request filter chain, computed by BuildFilterChain
+	filters      []RequestFilterFunction
+	filtersBuilt bool
+
+	// router is the shared router, see Routes
+	router *Router
 }
 
 var contextKeyServer = &Server{}
@@ -64,26 +71,6 @@ type Response interface {
 }
 
 func (s *Server) ServeHTTP(fn func(ctx context.Context, req *Request) (Response, error)) func(w http.ResponseWriter, r *http.Request) {
-	// TODO: Can we cache / build once?
-	var filters []RequestFilterFunction
-	for _, component := range s.Components {
-		if filter, ok := component.(RequestFilter); ok {
-			filters = append(filters, filter.ProcessRequest)
-		}
-	}
-
-	next := func(ctx context.Context, req *Request) (Response, error) {
-		return fn(ctx, req)
-	}
-	for i := len(filters) - 1; i >= 0; i-- {
-		filter := filters[i]
-		captureNext := next
-		invoke := func(ctx context.Context, req *Request) (Response, error) {
-			return filter(ctx, req, captureNext)
-		}
-		next = invoke
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -98,19 +85,28 @@ func (s *Server) ServeHTTP(fn func(ctx context.Context, req *Request) (Response,
 		// This is a little tricky, as req.Request and Context refer to each other
 		req.Request = req.Request.WithContext(ctx)
 
-		response, err := next(ctx, req)
+		if !s.filtersBuilt {
+			klog.Errorf("request filter chain has not been built (BuildFilterChain not called)")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		response, err := s.runFilters(ctx, req, fn)
 
 		if err != nil {
-			if response != nil {
-				// Can return a response to override the default error
-				klog.Warningf("error serving request: %v", err)
-				response.WriteTo(ctx, w)
-				return
-			} else {
+			httpError := AsHTTPError(err)
+			if httpError.Status >= 500 {
 				klog.Warningf("internal error serving request: %v", err)
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
+			} else {
+				klog.Infof("error serving request: %v", err)
+			}
+			for k, values := range httpError.Headers {
+				for _, v := range values {
+					w.Header().Add(k, v)
+				}
 			}
+			s.errorResponse(ctx, req, httpError).WriteTo(ctx, w)
+			return
 		}
 
 		response.WriteTo(ctx, w)
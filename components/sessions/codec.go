@@ -1,47 +1,170 @@
 package sessions
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/justinsb/kweb/components/sessions/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-type data struct {
-	Entries []dataEntry `json:"entries"`
+// formatVersion is the current version of SessionData encoding.
+const formatVersion = 1
+
+// compressionThreshold is the payload size above which we try compressing.
+const compressionThreshold = 1024
+
+// typeURLPrefix is the prefix for type URLs, as used by google.protobuf.Any.
+const typeURLPrefix = "type.googleapis.com/"
+
+// legacyData is the original JSON encoding of sessions.
+type legacyData struct {
+	Entries []legacyDataEntry `json:"entries"`
 }
 
-type dataEntry struct {
+type legacyDataEntry struct {
 	Key        string `json:"k"`
 	ProtoValue []byte `json:"pv"`
 }
 
+// IsLegacyFormat returns true if the data is in the original JSON encoding.
+func IsLegacyFormat(b []byte) bool {
+	return len(b) != 0 && b[0] == '{'
+}
+
+// Encode serializes the session as a SessionEnvelope.
+// It records the updated time on the session (and the created time, for new sessions).
 func Encode(session *Session) ([]byte, error) {
-	var data data
+	now := time.Now()
+
+	session.mutex.Lock()
+	if session.Created.IsZero() {
+		session.Created = now
+	}
+	session.Updated = now
+	data := &pb.SessionData{
+		Created: timestamppb.New(session.Created),
+		Updated: timestamppb.New(session.Updated),
+	}
 	for k, value := range session.values {
-		data.Entries = append(data.Entries, dataEntry{
-			Key:        k,
-			ProtoValue: value.Data,
+		data.Entries = append(data.Entries, &pb.SessionEntry{
+			Key:     k,
+			TypeUrl: typeURLPrefix + k,
+			Value:   value.Data,
 		})
 	}
+	session.mutex.Unlock()
+
+	// Sort so the encoding is stable
+	sort.Slice(data.Entries, func(i, j int) bool {
+		return data.Entries[i].Key < data.Entries[j].Key
+	})
 
-	// TODO: Use proto instead
-	b, err := json.Marshal(data)
+	payload, err := proto.Marshal(data)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error serializing session: %w", err)
+	}
+
+	envelope := &pb.SessionEnvelope{
+		FormatVersion: formatVersion,
+		Compression:   pb.Compression_COMPRESSION_NONE,
+		Payload:       payload,
+	}
+
+	if len(payload) > compressionThreshold {
+		var compressed bytes.Buffer
+		w := gzip.NewWriter(&compressed)
+		if _, err := w.Write(payload); err != nil {
+			return nil, fmt.Errorf("error compressing session: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("error compressing session: %w", err)
+		}
+		if compressed.Len() < len(payload) {
+			envelope.Compression = pb.Compression_COMPRESSION_GZIP
+			envelope.Payload = compressed.Bytes()
+		}
+	}
+
+	b, err := proto.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing session: %w", err)
 	}
 	return b, nil
 }
 
+// Decode deserializes a session, accepting both the SessionEnvelope and the legacy JSON encoding.
 func Decode(sessionID string, b []byte) (*Session, error) {
-	var data data
-	if err := json.Unmarshal(b, &data); err != nil {
-		return nil, err
-	}
-
 	session := &Session{
 		values: make(map[string]*sessionValue),
 	}
-	for _, entry := range data.Entries {
-		session.values[entry.Key] = &sessionValue{Data: entry.ProtoValue}
+
+	if len(b) == 0 {
+		// An empty session
+	} else if IsLegacyFormat(b) {
+		var data legacyData
+		if err := json.Unmarshal(b, &data); err != nil {
+			return nil, err
+		}
+		for _, entry := range data.Entries {
+			session.values[entry.Key] = &sessionValue{Data: entry.ProtoValue}
+		}
+	} else {
+		data, err := decodeEnvelope(b)
+		if err != nil {
+			return nil, err
+		}
+		if data.Created != nil {
+			session.Created = data.Created.AsTime()
+		}
+		if data.Updated != nil {
+			session.Updated = data.Updated.AsTime()
+		}
+		for _, entry := range data.Entries {
+			session.values[entry.Key] = &sessionValue{Data: entry.Value}
+		}
 	}
+
+	session.original = copyValues(session.values)
 	session.ID = sessionID
 	return session, nil
 }
+
+func decodeEnvelope(b []byte) (*pb.SessionData, error) {
+	envelope := &pb.SessionEnvelope{}
+	if err := proto.Unmarshal(b, envelope); err != nil {
+		return nil, fmt.Errorf("error parsing session: %w", err)
+	}
+	if envelope.FormatVersion != formatVersion {
+		return nil, fmt.Errorf("unknown session format version %d", envelope.FormatVersion)
+	}
+
+	payload := envelope.Payload
+	switch envelope.Compression {
+	case pb.Compression_COMPRESSION_NONE:
+	case pb.Compression_COMPRESSION_GZIP:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing session: %w", err)
+		}
+		decompressed, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing session: %w", err)
+		}
+		payload = decompressed
+	default:
+		return nil, fmt.Errorf("unknown session compression %v", envelope.Compression)
+	}
+
+	data := &pb.SessionData{}
+	if err := proto.Unmarshal(payload, data); err != nil {
+		return nil, fmt.Errorf("error parsing session data: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,143 @@
+package sessions
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/justinsb/kweb/components/sessions/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDecodeLegacyFormat(t *testing.T) {
+	value, err := proto.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatalf("error marshaling value: %v", err)
+	}
+	b, err := json.Marshal(legacyData{
+		Entries: []legacyDataEntry{
+			{Key: "google.protobuf.StringValue", ProtoValue: value},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error building legacy data: %v", err)
+	}
+	if !IsLegacyFormat(b) {
+		t.Fatalf("expected %q to be detected as the legacy format", string(b))
+	}
+
+	session, err := Decode("id", b)
+	if err != nil {
+		t.Fatalf("error decoding legacy session: %v", err)
+	}
+	if session.ID != "id" {
+		t.Errorf("unexpected session id %q", session.ID)
+	}
+	got := &wrapperspb.StringValue{}
+	if !session.Get(got) || got.GetValue() != "hello" {
+		t.Errorf("unexpected session value %v", got)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	session := &Session{}
+	session.Set(wrapperspb.String("hello"))
+	session.Set(wrapperspb.Int64(42))
+
+	b, err := Encode(session)
+	if err != nil {
+		t.Fatalf("error encoding session: %v", err)
+	}
+	if IsLegacyFormat(b) {
+		t.Errorf("encoded session was detected as the legacy format")
+	}
+	if session.Created.IsZero() || session.Updated.IsZero() {
+		t.Errorf("expected created and updated times to be recorded")
+	}
+
+	envelope := &pb.SessionEnvelope{}
+	if err := proto.Unmarshal(b, envelope); err != nil {
+		t.Fatalf("error parsing envelope: %v", err)
+	}
+	if envelope.GetFormatVersion() != formatVersion {
+		t.Errorf("unexpected format version %d", envelope.GetFormatVersion())
+	}
+	if envelope.GetCompression() != pb.Compression_COMPRESSION_NONE {
+		t.Errorf("expected a small session not to be compressed, got %v", envelope.GetCompression())
+	}
+
+	decoded, err := Decode("id", b)
+	if err != nil {
+		t.Fatalf("error decoding session: %v", err)
+	}
+	s := &wrapperspb.StringValue{}
+	if !decoded.Get(s) || s.GetValue() != "hello" {
+		t.Errorf("unexpected string value %v", s)
+	}
+	n := &wrapperspb.Int64Value{}
+	if !decoded.Get(n) || n.GetValue() != 42 {
+		t.Errorf("unexpected int64 value %v", n)
+	}
+	if !decoded.Created.Equal(session.Created) || !decoded.Updated.Equal(session.Updated) {
+		t.Errorf("timestamps were not preserved: got %v/%v, want %v/%v", decoded.Created, decoded.Updated, session.Created, session.Updated)
+	}
+
+	// A decoded session can be written again
+	again, err := Encode(decoded)
+	if err != nil {
+		t.Fatalf("error encoding session: %v", err)
+	}
+	if _, err := Decode("id", again); err != nil {
+		t.Errorf("error decoding re-encoded session: %v", err)
+	}
+}
+
+func TestEncodeCompressesLargeSessions(t *testing.T) {
+	value := strings.Repeat("compressible ", compressionThreshold)
+
+	session := &Session{}
+	session.Set(wrapperspb.String(value))
+
+	b, err := Encode(session)
+	if err != nil {
+		t.Fatalf("error encoding session: %v", err)
+	}
+	envelope := &pb.SessionEnvelope{}
+	if err := proto.Unmarshal(b, envelope); err != nil {
+		t.Fatalf("error parsing envelope: %v", err)
+	}
+	if envelope.GetCompression() != pb.Compression_COMPRESSION_GZIP {
+		t.Errorf("expected a large session to be compressed, got %v", envelope.GetCompression())
+	}
+	if len(b) >= len(value) {
+		t.Errorf("compressed session is %d bytes, not smaller than the %d byte value", len(b), len(value))
+	}
+
+	decoded, err := Decode("id", b)
+	if err != nil {
+		t.Fatalf("error decoding session: %v", err)
+	}
+	got := &wrapperspb.StringValue{}
+	if !decoded.Get(got) || got.GetValue() != value {
+		t.Errorf("large session value was not preserved")
+	}
+}
+
+func TestDecodeRejectsUnknownFormatVersion(t *testing.T) {
+	payload, err := proto.Marshal(&pb.SessionData{})
+	if err != nil {
+		t.Fatalf("error marshaling data: %v", err)
+	}
+	b, err := proto.Marshal(&pb.SessionEnvelope{
+		FormatVersion: formatVersion + 1,
+		Payload:       payload,
+	})
+	if err != nil {
+		t.Fatalf("error marshaling envelope: %v", err)
+	}
+
+	if _, err := Decode("id", b); err == nil || !strings.Contains(err.Error(), "unknown session format version") {
+		t.Errorf("expected unknown format version to be rejected, got %v", err)
+	}
+}
@@ -58,7 +58,12 @@ func (c *SessionComponent) beforeRequest(ctx context.Context, req *components.Re
 	return session, nil
 }
 
-var _ components.RequestFilter = &SessionComponent{}
+var _ components.OrderedRequestFilter = &SessionComponent{}
+
+func (c *SessionComponent) FilterOrder() components.FilterOrder {
+	// We set the session cookie
+	return components.FilterOrder{Name: "sessions", After: []string{"cookies"}}
+}
 
 func (c *SessionComponent) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
 	session, err := c.beforeRequest(ctx, req)
@@ -74,42 +79,77 @@ func (c *SessionComponent) ProcessRequest(ctx context.Context, req *components.R
 		return nil, err
 	}
 
-	if session.dirty {
+	if session.isDirty() {
 		err := c.storage.WriteSession(ctx, session)
 		if err != nil {
 			return nil, err
 		}
 
 		if session.newSession {
-			sessionCookie := http.Cookie{
-				Name:     cookieSessionID,
-				Value:    session.ID,
-				Expires:  time.Now().Add(time.Hour * 24 * 365),
-				HttpOnly: true,
-				Secure:   true,
-				Path:     "/", // Otherwise cookie is filtered
-			}
-
-			if !req.BrowserUsingHTTPS() {
-				if req.IsLocalhost() {
-					klog.Warningf("setting cookie to _not_ be secure, because running on localhost")
-					sessionCookie.Secure = false
-				} else {
-					klog.Warningf("session invoked but running without TLS (and not on localhost); likely won't work")
-				}
+			expires := session.Expires
+			if expires.IsZero() {
+				expires = time.Now().Add(time.Hour * 24 * 365)
 			}
-
+			sessionCookie := NewCookie(req, cookieSessionID, session.ID, expires)
 			cookies.SetCookie(ctx, sessionCookie)
 
 			session.newSession = false
 		}
-		klog.Infof("session %q => %v", session.ID, debug.JSON(session.values))
-		session.dirty = false
+
+		if session.previousID != "" {
+			if err := c.storage.DeleteSession(ctx, session.previousID); err != nil {
+				klog.Warningf("error deleting previous session: %v", err)
+			}
+			session.previousID = ""
+		}
+		values, _ := session.snapshot()
+		klog.Infof("session %q => %v", session.ID, debug.JSON(values))
+		session.markClean()
 	}
 
 	return response, nil
 }
 
+// RegenerateID moves the current session to a new ID, to prevent session fixation.
+// The values are copied to the new session, the old session is deleted and the cookie is reissued when the response is sent.
+func (c *SessionComponent) RegenerateID(ctx context.Context) {
+	req := components.GetRequest(ctx)
+	req.Session.RegenerateID()
+}
+
+// NewCookie builds a cookie for session state, which is http-only and (unless we are running on localhost) secure.
+// Storage implementations that keep state in cookies should use it with cookies.SetCookie.
+func NewCookie(req *components.Request, name string, value string, expires time.Time) http.Cookie {
+	cookie := http.Cookie{
+		Name:     name,
+		Value:    value,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/", // Otherwise cookie is filtered
+	}
+
+	if !req.BrowserUsingHTTPS() {
+		if req.IsLocalhost() {
+			klog.Warningf("setting cookie to _not_ be secure, because running on localhost")
+			cookie.Secure = false
+		} else {
+			klog.Warningf("session invoked but running without TLS (and not on localhost); likely won't work")
+		}
+	}
+	return cookie
+}
+
+var _ components.Runnable = &SessionComponent{}
+
+// Start starts any background tasks of the storage, such as removing expired sessions.
+func (c *SessionComponent) Start(ctx context.Context) error {
+	if runnable, ok := c.storage.(components.Runnable); ok {
+		return runnable.Start(ctx)
+	}
+	return nil
+}
+
 func (c *SessionComponent) RegisterHandlers(s *components.Server, mux *http.ServeMux) error {
 	return nil
 }
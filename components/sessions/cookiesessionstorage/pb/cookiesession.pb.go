@@ -0,0 +1,187 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: components/sessions/cookiesessionstorage/pb/cookiesession.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CookieSessionData is the plaintext of the encrypted session cookie.
+type CookieSessionData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// session_id binds the data to the session cookie, so cookies cannot be mixed between sessions.
+	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// expires is the expiry time, in unix seconds.
+	Expires int64 `protobuf:"varint,2,opt,name=expires,proto3" json:"expires,omitempty"`
+	// data is the encoded session.
+	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// idle_expires is the time after which the cookie is rejected if it has not been refreshed, in unix seconds.
+	IdleExpires int64 `protobuf:"varint,4,opt,name=idle_expires,json=idleExpires,proto3" json:"idle_expires,omitempty"`
+}
+
+func (x *CookieSessionData) Reset() {
+	*x = CookieSessionData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CookieSessionData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CookieSessionData) ProtoMessage() {}
+
+func (x *CookieSessionData) ProtoReflect() protoreflect.Message {
+	mi := &file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CookieSessionData.ProtoReflect.Descriptor instead.
+func (*CookieSessionData) Descriptor() ([]byte, []int) {
+	return file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CookieSessionData) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CookieSessionData) GetExpires() int64 {
+	if x != nil {
+		return x.Expires
+	}
+	return 0
+}
+
+func (x *CookieSessionData) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *CookieSessionData) GetIdleExpires() int64 {
+	if x != nil {
+		return x.IdleExpires
+	}
+	return 0
+}
+
+var File_components_sessions_cookiesessionstorage_pb_cookiesession_proto protoreflect.FileDescriptor
+
+var file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDesc = []byte{
+	0x0a, 0x3f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2f, 0x70, 0x62, 0x2f, 0x63, 0x6f,
+	0x6f, 0x6b, 0x69, 0x65, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x02, 0x70, 0x62, 0x22, 0x83, 0x01, 0x0a, 0x11, 0x43, 0x6f, 0x6f, 0x6b, 0x69, 0x65,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x0a, 0x0a, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x64, 0x6c, 0x65,
+	0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b,
+	0x69, 0x64, 0x6c, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x42, 0x8a, 0x01, 0x0a, 0x06,
+	0x63, 0x6f, 0x6d, 0x2e, 0x70, 0x62, 0x42, 0x12, 0x43, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x44, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x73, 0x74, 0x69, 0x6e, 0x73,
+	0x62, 0x2f, 0x6b, 0x77, 0x65, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
+	0x73, 0x2f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x63, 0x6f, 0x6f, 0x6b, 0x69,
+	0x65, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2f,
+	0x70, 0x62, 0xa2, 0x02, 0x03, 0x50, 0x58, 0x58, 0xaa, 0x02, 0x02, 0x50, 0x62, 0xca, 0x02, 0x02,
+	0x50, 0x62, 0xe2, 0x02, 0x0e, 0x50, 0x62, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0xea, 0x02, 0x02, 0x50, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescOnce sync.Once
+	file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescData = file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDesc
+)
+
+func file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescGZIP() []byte {
+	file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescOnce.Do(func() {
+		file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescData = protoimpl.X.CompressGZIP(file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescData)
+	})
+	return file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDescData
+}
+
+var file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_goTypes = []interface{}{
+	(*CookieSessionData)(nil), // 0: pb.CookieSessionData
+}
+var file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_init() }
+func file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_init() {
+	if File_components_sessions_cookiesessionstorage_pb_cookiesession_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CookieSessionData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_goTypes,
+		DependencyIndexes: file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_depIdxs,
+		MessageInfos:      file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_msgTypes,
+	}.Build()
+	File_components_sessions_cookiesessionstorage_pb_cookiesession_proto = out.File
+	file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_rawDesc = nil
+	file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_goTypes = nil
+	file_components_sessions_cookiesessionstorage_pb_cookiesession_proto_depIdxs = nil
+}
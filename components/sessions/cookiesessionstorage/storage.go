@@ -0,0 +1,305 @@
+package cookiesessionstorage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cryptorand "crypto/rand"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/cookies"
+	"github.com/justinsb/kweb/components/keystore"
+	keystorepb "github.com/justinsb/kweb/components/keystore/pb"
+	"github.com/justinsb/kweb/components/sessions"
+	"github.com/justinsb/kweb/components/sessions/cookiesessionstorage/pb"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/klog/v2"
+)
+
+// ErrSessionTooLarge is returned when the session does not fit in the allowed cookies.
+var ErrSessionTooLarge = errors.New("session is too large to store in cookies")
+
+// Options configures the CookieSessionStorage.
+type Options struct {
+	// KeyStore holds the keys that encrypt the session cookies; it is required.
+	KeyStore keystore.KeyStore
+
+	// KeySetName is the name of the keyset in KeyStore.
+	KeySetName string
+
+	// CookieName is the prefix of the cookies holding the session data; chunks are named <CookieName>.0, <CookieName>.1 etc.
+	CookieName string
+
+	// MaxAge is the lifetime of a session.
+	MaxAge time.Duration
+
+	// IdleTTL is how long the session cookies remain valid without being used; they are refreshed on use.
+	// Because cookies cannot be revoked, this bounds how long a copied cookie can be used after the session ends.
+	IdleTTL time.Duration
+
+	// ChunkSize is the maximum length of the value of each cookie.
+	// Browsers generally limit cookies to 4096 bytes, including the name and attributes.
+	ChunkSize int
+
+	// MaxChunks is the maximum number of cookies we will use; sessions that need more are rejected with ErrSessionTooLarge.
+	MaxChunks int
+}
+
+// InitDefaults sets the default options.
+func (o *Options) InitDefaults() {
+	o.KeySetName = "sessions"
+	o.CookieName = "session-data"
+	o.MaxAge = 30 * 24 * time.Hour
+	o.IdleTTL = 24 * time.Hour
+	o.ChunkSize = 3800
+	o.MaxChunks = 4
+}
+
+// CookieSessionStorage stores the session data in the browser, in encrypted cookies.
+// The data is encrypted and authenticated with the active key of the keyset;
+// cookies encrypted with older keys remain readable as long as those keys are in the keyset.
+//
+// Because the session lives only in the browser, sessions cannot be revoked: DeleteSession
+// (on logout or RegenerateID) does not invalidate a copy of the cookies.
+// A copied cookie remains usable until its idle expiry (see Options.IdleTTL), or for as long as it keeps being used,
+// up to the absolute expiry (Options.MaxAge).
+type CookieSessionStorage struct {
+	keyStore   keystore.KeyStore
+	keySetName string
+	options    Options
+}
+
+var _ sessions.Storage = &CookieSessionStorage{}
+
+func NewCookieSessionStorage(opt Options) (*CookieSessionStorage, error) {
+	if opt.KeyStore == nil {
+		return nil, fmt.Errorf("cookie session storage requires a key store")
+	}
+
+	var defaults Options
+	defaults.InitDefaults()
+	if opt.KeySetName == "" {
+		opt.KeySetName = defaults.KeySetName
+	}
+	if opt.CookieName == "" {
+		opt.CookieName = defaults.CookieName
+	}
+	if opt.MaxAge == 0 {
+		opt.MaxAge = defaults.MaxAge
+	}
+	if opt.IdleTTL == 0 {
+		opt.IdleTTL = defaults.IdleTTL
+	}
+	if opt.ChunkSize == 0 {
+		opt.ChunkSize = defaults.ChunkSize
+	}
+	if opt.MaxChunks == 0 {
+		opt.MaxChunks = defaults.MaxChunks
+	}
+
+	return &CookieSessionStorage{
+		keyStore:   opt.KeyStore,
+		keySetName: opt.KeySetName,
+		options:    opt,
+	}, nil
+}
+
+// keySet returns the keys for encrypting cookies.
+// We look up the keyset for each operation, so we use keys rotated by other replicas.
+func (s *CookieSessionStorage) keySet(ctx context.Context) (keystore.KeySet, error) {
+	keys, err := s.keyStore.KeySet(ctx, s.keySetName, keystorepb.KeyType_KEYTYPE_SECRETBOX)
+	if err != nil {
+		return nil, fmt.Errorf("error getting keys for session cookies: %w", err)
+	}
+	return keys, nil
+}
+
+// touchInterval is how often we refresh the idle expiry of the cookies of a session that is in use.
+func (s *CookieSessionStorage) touchInterval() time.Duration {
+	interval := s.options.IdleTTL / 10
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	return interval
+}
+
+func (s *CookieSessionStorage) chunkName(i int) string {
+	return s.options.CookieName + "." + strconv.Itoa(i)
+}
+
+// readChunks returns the value of the chunked cookie, and the number of chunks in the request.
+func (s *CookieSessionStorage) readChunks(req *components.Request) (string, int) {
+	var sb strings.Builder
+	n := 0
+	for i := 0; ; i++ {
+		cookie, err := req.Cookie(s.chunkName(i))
+		if err != nil {
+			break
+		}
+		sb.WriteString(cookie.Value)
+		n++
+	}
+	return sb.String(), n
+}
+
+func (s *CookieSessionStorage) LookupSession(ctx context.Context, sessionID string) (*sessions.Session, error) {
+	if sessionID == "" {
+		return nil, nil
+	}
+
+	req := components.GetRequest(ctx)
+	value, n := s.readChunks(req)
+	if n == 0 {
+		return nil, nil
+	}
+
+	data, err := s.decrypt(ctx, value)
+	if err != nil {
+		// Treat this as a new session, e.g. if the key has been removed
+		klog.Warningf("ignoring session cookie: %v", err)
+		return nil, nil
+	}
+
+	if data.GetSessionId() != sessionID {
+		klog.Warningf("ignoring session cookie: it is for a different session")
+		return nil, nil
+	}
+
+	now := time.Now()
+	expires := time.Unix(data.GetExpires(), 0)
+	idleExpires := time.Unix(data.GetIdleExpires(), 0)
+	if now.After(expires) || now.After(idleExpires) {
+		klog.Infof("session %q has expired", sessionID)
+		return nil, nil
+	}
+
+	if idleExpires.Sub(now) < s.options.IdleTTL-s.touchInterval() {
+		// We don't fail the request if we can't refresh the cookies; the session will just expire sooner
+		if err := s.writeCookies(ctx, data); err != nil {
+			klog.Warningf("error refreshing session cookies for %q: %v", sessionID, err)
+		}
+	}
+
+	session, err := sessions.Decode(sessionID, data.GetData())
+	if err != nil {
+		return nil, err
+	}
+	session.Expires = expires
+	return session, nil
+}
+
+func (s *CookieSessionStorage) decrypt(ctx context.Context, value string) (*pb.CookieSessionData, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding cookie: %w", err)
+	}
+	keys, err := s.keySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := keys.AuthenticateAndDecrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting cookie: %w", err)
+	}
+	data := &pb.CookieSessionData{}
+	if err := proto.Unmarshal(plaintext, data); err != nil {
+		return nil, fmt.Errorf("error parsing cookie: %w", err)
+	}
+	return data, nil
+}
+
+func (s *CookieSessionStorage) WriteSession(ctx context.Context, session *sessions.Session) error {
+	if session.ID == "" {
+		// A new session
+		session.ID = GenerateSessionID()
+		session.Expires = time.Now().Add(s.options.MaxAge)
+	}
+	if session.Expires.IsZero() {
+		session.Expires = time.Now().Add(s.options.MaxAge)
+	}
+
+	b, err := sessions.Encode(session)
+	if err != nil {
+		return err
+	}
+
+	data := &pb.CookieSessionData{
+		SessionId: session.ID,
+		Expires:   session.Expires.Unix(),
+		Data:      b,
+	}
+	return s.writeCookies(ctx, data)
+}
+
+// writeCookies encrypts the session data into the response cookies, with a new idle expiry.
+func (s *CookieSessionStorage) writeCookies(ctx context.Context, data *pb.CookieSessionData) error {
+	req := components.GetRequest(ctx)
+
+	data.IdleExpires = time.Now().Add(s.options.IdleTTL).Unix()
+	if data.IdleExpires > data.Expires {
+		data.IdleExpires = data.Expires
+	}
+
+	plaintext, err := proto.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error serializing session: %w", err)
+	}
+	keys, err := s.keySet(ctx)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := keys.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("error encrypting session: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(ciphertext)
+	encodedLength := len(value)
+
+	var chunks []string
+	for len(value) > s.options.ChunkSize {
+		chunks = append(chunks, value[:s.options.ChunkSize])
+		value = value[s.options.ChunkSize:]
+	}
+	chunks = append(chunks, value)
+
+	if len(chunks) > s.options.MaxChunks {
+		limit := s.options.ChunkSize * s.options.MaxChunks
+		return fmt.Errorf("%w: encoded session is %d bytes, but the limit is %d bytes", ErrSessionTooLarge, encodedLength, limit)
+	}
+
+	expires := time.Unix(data.Expires, 0)
+	klog.Infof("storing session %q in %d cookies", data.SessionId, len(chunks))
+	for i, chunk := range chunks {
+		cookies.SetCookie(ctx, sessions.NewCookie(req, s.chunkName(i), chunk, expires))
+	}
+
+	// Remove any chunks left over from a larger session
+	_, existing := s.readChunks(req)
+	for i := len(chunks); i < existing; i++ {
+		cookie := sessions.NewCookie(req, s.chunkName(i), "", time.Time{})
+		cookie.MaxAge = -1
+		cookies.SetCookie(ctx, cookie)
+	}
+
+	return nil
+}
+
+// DeleteSession is a no-op: the data cookies are bound to the session ID, and are overwritten when the new session is written.
+// We cannot revoke copies of the cookies; they expire after IdleTTL unless they are used.
+func (s *CookieSessionStorage) DeleteSession(ctx context.Context, sessionID string) error {
+	return nil
+}
+
+func GenerateSessionID() string {
+	b := make([]byte, 32, 32)
+	if _, err := cryptorand.Read(b); err != nil {
+		klog.Fatalf("error building session id: %v", err)
+	}
+	sessionID := base64.RawURLEncoding.EncodeToString(b)
+	return sessionID
+}
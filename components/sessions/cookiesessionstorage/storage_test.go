@@ -0,0 +1,287 @@
+package cookiesessionstorage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cryptorand "crypto/rand"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/cookies"
+	"github.com/justinsb/kweb/components/keystore"
+	keystorepb "github.com/justinsb/kweb/components/keystore/pb"
+	"github.com/justinsb/kweb/components/sessions"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testBrowser sends requests through the cookies filter, keeping the cookies it is sent like a browser.
+type testBrowser struct {
+	t       *testing.T
+	server  *components.Server
+	cookies map[string]*http.Cookie
+}
+
+func newTestBrowser(t *testing.T) *testBrowser {
+	t.Helper()
+	server := &components.Server{
+		Components: []components.Component{cookies.NewCookiesComponent()},
+	}
+	if err := server.BuildFilterChain(); err != nil {
+		t.Fatalf("error building filter chain: %v", err)
+	}
+	return &testBrowser{t: t, server: server, cookies: make(map[string]*http.Cookie)}
+}
+
+// do runs fn in the context of a request, returning its error.
+func (b *testBrowser) do(fn func(ctx context.Context) error) error {
+	var fnErr error
+	handler := b.server.ServeHTTP(func(ctx context.Context, req *components.Request) (components.Response, error) {
+		fnErr = fn(ctx)
+		return &components.SimpleResponse{}, nil
+	})
+
+	r := httptest.NewRequest("GET", "https://example.com/", nil)
+	for _, cookie := range b.cookies {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(b.cookies, cookie.Name)
+		} else {
+			b.cookies[cookie.Name] = cookie
+		}
+	}
+	return fnErr
+}
+
+func newTestKeyStore(t *testing.T) *keystore.KubernetesKeyStore {
+	t.Helper()
+	keyStore, err := keystore.NewKubernetesKeyStore(fake.NewSimpleClientset(), "default", "keys")
+	if err != nil {
+		t.Fatalf("error building key store: %v", err)
+	}
+	return keyStore
+}
+
+func newTestStorage(t *testing.T, opt Options) *CookieSessionStorage {
+	t.Helper()
+	if opt.KeyStore == nil {
+		opt.KeyStore = newTestKeyStore(t)
+	}
+	storage, err := NewCookieSessionStorage(opt)
+	if err != nil {
+		t.Fatalf("error building storage: %v", err)
+	}
+	return storage
+}
+
+func (b *testBrowser) mustWrite(storage *CookieSessionStorage, session *sessions.Session) {
+	b.t.Helper()
+	if err := b.do(func(ctx context.Context) error {
+		return storage.WriteSession(ctx, session)
+	}); err != nil {
+		b.t.Fatalf("error writing session: %v", err)
+	}
+}
+
+func (b *testBrowser) mustLookup(storage *CookieSessionStorage, sessionID string) *sessions.Session {
+	b.t.Helper()
+	var session *sessions.Session
+	if err := b.do(func(ctx context.Context) error {
+		var err error
+		session, err = storage.LookupSession(ctx, sessionID)
+		return err
+	}); err != nil {
+		b.t.Fatalf("error looking up session: %v", err)
+	}
+	return session
+}
+
+// randomString returns a string that doesn't compress, so we control the size of the cookies.
+func randomString(t *testing.T, n int) string {
+	b := make([]byte, n)
+	if _, err := cryptorand.Read(b); err != nil {
+		t.Fatalf("error reading random data: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)[:n]
+}
+
+func TestRoundTrip(t *testing.T) {
+	storage := newTestStorage(t, Options{})
+	browser := newTestBrowser(t)
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	browser.mustWrite(storage, session)
+	if session.ID == "" {
+		t.Fatalf("session id was not assigned")
+	}
+	if len(browser.cookies) != 1 {
+		t.Errorf("expected one cookie, got %d", len(browser.cookies))
+	}
+
+	loaded := browser.mustLookup(storage, session.ID)
+	if loaded == nil {
+		t.Fatalf("session %q not found", session.ID)
+	}
+	got := &wrapperspb.StringValue{}
+	if !loaded.Get(got) || got.GetValue() != "hello" {
+		t.Errorf("unexpected session value %v", got)
+	}
+	if !loaded.Expires.Equal(session.Expires.Truncate(time.Second)) {
+		t.Errorf("unexpected expiry: got %v, want %v", loaded.Expires, session.Expires)
+	}
+
+	// The cookies are bound to the session id
+	if other := browser.mustLookup(storage, "other"); other != nil {
+		t.Errorf("expected nil for a different session id, got %v", other)
+	}
+}
+
+func TestChunking(t *testing.T) {
+	storage := newTestStorage(t, Options{ChunkSize: 1000})
+	browser := newTestBrowser(t)
+
+	value := randomString(t, 2500)
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String(value))
+	browser.mustWrite(storage, session)
+	if len(browser.cookies) < 2 {
+		t.Errorf("expected the session to be split across several cookies, got %d", len(browser.cookies))
+	}
+	for name, cookie := range browser.cookies {
+		if len(cookie.Value) > 1000 {
+			t.Errorf("cookie %q is %d bytes, larger than the chunk size", name, len(cookie.Value))
+		}
+	}
+
+	loaded := browser.mustLookup(storage, session.ID)
+	if loaded == nil {
+		t.Fatalf("session %q not found", session.ID)
+	}
+	got := &wrapperspb.StringValue{}
+	if !loaded.Get(got) || got.GetValue() != value {
+		t.Errorf("session value was not reassembled from the chunks")
+	}
+
+	// When the session shrinks, the chunks we no longer need are removed
+	loaded.Set(wrapperspb.String("small"))
+	browser.mustWrite(storage, loaded)
+	if len(browser.cookies) != 1 {
+		t.Errorf("expected one cookie after shrinking, got %d", len(browser.cookies))
+	}
+	if browser.mustLookup(storage, session.ID) == nil {
+		t.Errorf("session %q not found after shrinking", session.ID)
+	}
+}
+
+func TestSessionTooLarge(t *testing.T) {
+	storage := newTestStorage(t, Options{ChunkSize: 1000, MaxChunks: 2})
+	browser := newTestBrowser(t)
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String(randomString(t, 2500)))
+	err := browser.do(func(ctx context.Context) error {
+		return storage.WriteSession(ctx, session)
+	})
+	if !errors.Is(err, ErrSessionTooLarge) {
+		t.Errorf("expected ErrSessionTooLarge, got %v", err)
+	}
+	if len(browser.cookies) != 0 {
+		t.Errorf("expected no cookies to be set, got %d", len(browser.cookies))
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	keyStore := newTestKeyStore(t)
+	storage := newTestStorage(t, Options{KeyStore: keyStore})
+	browser := newTestBrowser(t)
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	browser.mustWrite(storage, session)
+	if got := encryptedKeyID(t, storage, browser); got != 1 {
+		t.Fatalf("expected cookie to be encrypted with key 1, got %d", got)
+	}
+
+	if err := keyStore.RotateKeySet(ctx, "sessions", keystorepb.KeyType_KEYTYPE_SECRETBOX); err != nil {
+		t.Fatalf("error rotating keys: %v", err)
+	}
+
+	// Cookies encrypted with the older key can still be read
+	loaded := browser.mustLookup(storage, session.ID)
+	if loaded == nil {
+		t.Fatalf("session %q not found after key rotation", session.ID)
+	}
+	got := &wrapperspb.StringValue{}
+	if !loaded.Get(got) || got.GetValue() != "hello" {
+		t.Errorf("unexpected session value %v", got)
+	}
+
+	// ... and are written with the new key
+	loaded.Set(wrapperspb.String("updated"))
+	browser.mustWrite(storage, loaded)
+	if got := encryptedKeyID(t, storage, browser); got != 2 {
+		t.Errorf("expected cookie to be encrypted with key 2, got %d", got)
+	}
+}
+
+// encryptedKeyID returns the id of the key that encrypted the session cookies.
+func encryptedKeyID(t *testing.T, storage *CookieSessionStorage, browser *testBrowser) int32 {
+	t.Helper()
+	var values []string
+	for i := 0; ; i++ {
+		cookie := browser.cookies[storage.chunkName(i)]
+		if cookie == nil {
+			break
+		}
+		values = append(values, cookie.Value)
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(strings.Join(values, ""))
+	if err != nil {
+		t.Fatalf("error decoding cookie: %v", err)
+	}
+	keyID, err := keystore.EncryptedKeyID(ciphertext)
+	if err != nil {
+		t.Fatalf("error reading key id: %v", err)
+	}
+	return keyID
+}
+
+func TestIdleExpiry(t *testing.T) {
+	storage := newTestStorage(t, Options{IdleTTL: 3 * time.Second})
+	browser := newTestBrowser(t)
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	browser.mustWrite(storage, session)
+
+	// A copy of the cookies taken now stops working once it is idle, even though the session is still in use
+	copied := newTestBrowser(t)
+	for name, cookie := range browser.cookies {
+		copied.cookies[name] = cookie
+	}
+
+	// Each lookup refreshes the idle expiry
+	for i := 0; i < 3; i++ {
+		time.Sleep(1500 * time.Millisecond)
+		if browser.mustLookup(storage, session.ID) == nil {
+			t.Fatalf("session expired while in use (lookup %d)", i)
+		}
+	}
+
+	if loaded := copied.mustLookup(storage, session.ID); loaded != nil {
+		t.Errorf("expected idle cookies to have expired")
+	}
+}
@@ -15,6 +15,12 @@ type Session struct {
 
 type SessionSpec struct {
 	Data []byte `json:"data,omitempty"`
+
+	// ExpiresAt is the time after which the session is no longer valid, however recently it was used.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// IdleExpiresAt is the time after which the session is no longer valid if it has not been used; it is extended on use.
+	IdleExpiresAt *metav1.Time `json:"idleExpiresAt,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
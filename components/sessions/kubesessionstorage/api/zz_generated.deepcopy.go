@@ -75,6 +75,14 @@ func (in *SessionSpec) DeepCopyInto(out *SessionSpec) {
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.IdleExpiresAt != nil {
+		in, out := &in.IdleExpiresAt, &out.IdleExpiresAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionSpec.
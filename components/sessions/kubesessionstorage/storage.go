@@ -3,28 +3,112 @@ package kubesessionstorage
 import (
 	"context"
 	"encoding/base32"
+	"fmt"
 	"strings"
+	"time"
 
 	cryptorand "crypto/rand"
 
 	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/kube/kubecontroller"
 	"github.com/justinsb/kweb/components/sessions"
 	"github.com/justinsb/kweb/components/sessions/kubesessionstorage/api"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Options configures the KubeSessionStorage.
+type Options struct {
+	// Namespace holds the Session objects.
+	Namespace string
+
+	// AbsoluteTTL is the maximum lifetime of a session, however active it is.
+	AbsoluteTTL time.Duration
+
+	// IdleTTL is how long a session remains valid without being used.
+	IdleTTL time.Duration
+
+	// SweepInterval is how often we delete expired sessions; a negative value disables the sweeper.
+	SweepInterval time.Duration
+
+	// SweepBatchSize is the number of sessions we list at a time when sweeping.
+	SweepBatchSize int64
+}
+
+// InitDefaults sets the default options.
+func (o *Options) InitDefaults() {
+	o.Namespace = "sessions"
+	o.AbsoluteTTL = 30 * 24 * time.Hour
+	o.IdleTTL = 7 * 24 * time.Hour
+	o.SweepInterval = time.Hour
+	o.SweepBatchSize = 500
+}
+
 type KubeSessionStorage struct {
-	kube *kubeclient.Client
+	kube    *kubeclient.Client
+	options Options
 }
 
 var _ sessions.Storage = &KubeSessionStorage{}
 
-func NewKubeSessionStorage(kube *kubeclient.Client) *KubeSessionStorage {
+func NewKubeSessionStorage(kube *kubeclient.Client, opt Options) *KubeSessionStorage {
+	var defaults Options
+	defaults.InitDefaults()
+	if opt.Namespace == "" {
+		opt.Namespace = defaults.Namespace
+	}
+	if opt.AbsoluteTTL == 0 {
+		opt.AbsoluteTTL = defaults.AbsoluteTTL
+	}
+	if opt.IdleTTL == 0 {
+		opt.IdleTTL = defaults.IdleTTL
+	}
+	if opt.SweepInterval == 0 {
+		opt.SweepInterval = defaults.SweepInterval
+	}
+	if opt.SweepBatchSize == 0 {
+		opt.SweepBatchSize = defaults.SweepBatchSize
+	}
+
 	return &KubeSessionStorage{
-		kube: kube,
+		kube:    kube,
+		options: opt,
+	}
+}
+
+// expiry returns the absolute and idle expiry times of the session.
+// Sessions created before we recorded expiry are treated as if they were created with the current TTLs.
+func (s *KubeSessionStorage) expiry(obj *api.Session) (time.Time, time.Time) {
+	var expiresAt, idleExpiresAt time.Time
+	if obj.Spec.ExpiresAt != nil {
+		expiresAt = obj.Spec.ExpiresAt.Time
+	} else {
+		expiresAt = obj.CreationTimestamp.Add(s.options.AbsoluteTTL)
+	}
+	if obj.Spec.IdleExpiresAt != nil {
+		idleExpiresAt = obj.Spec.IdleExpiresAt.Time
+	} else {
+		idleExpiresAt = obj.CreationTimestamp.Add(s.options.IdleTTL)
+	}
+	return expiresAt, idleExpiresAt
+}
+
+func (s *KubeSessionStorage) isExpired(obj *api.Session, now time.Time) bool {
+	expiresAt, idleExpiresAt := s.expiry(obj)
+	return now.After(expiresAt) || now.After(idleExpiresAt)
+}
+
+// touchInterval is how often we extend the idle expiry of a session that is in use.
+func (s *KubeSessionStorage) touchInterval() time.Duration {
+	interval := s.options.IdleTTL / 10
+	if interval > time.Hour {
+		interval = time.Hour
 	}
+	return interval
 }
 
 func (s *KubeSessionStorage) LookupSession(ctx context.Context, sessionID string) (*sessions.Session, error) {
@@ -34,7 +118,7 @@ func (s *KubeSessionStorage) LookupSession(ctx context.Context, sessionID string
 
 	obj := api.Session{}
 	key := types.NamespacedName{
-		Namespace: "sessions",
+		Namespace: s.options.Namespace,
 		Name:      sessionID,
 	}
 	if err := s.kube.Uncached().Get(ctx, key, &obj); err != nil {
@@ -44,17 +128,99 @@ func (s *KubeSessionStorage) LookupSession(ctx context.Context, sessionID string
 		return nil, err
 	}
 
-	return sessions.Decode(sessionID, obj.Spec.Data)
+	now := time.Now()
+	if s.isExpired(&obj, now) {
+		klog.Infof("session %q has expired", sessionID)
+		return nil, nil
+	}
+
+	expiresAt, idleExpiresAt := s.expiry(&obj)
+	if idleExpiresAt.Sub(now) < s.options.IdleTTL-s.touchInterval() {
+		// We don't fail the request if we can't extend the session; it will just expire sooner
+		if err := s.touchSession(ctx, &obj, expiresAt, now.Add(s.options.IdleTTL)); err != nil {
+			klog.Warningf("error extending session %q: %v", sessionID, err)
+		}
+	}
+
+	return s.decode(&obj)
+}
+
+// decode builds the Session from the kubernetes object, tracking the resourceVersion for optimistic concurrency.
+func (s *KubeSessionStorage) decode(obj *api.Session) (*sessions.Session, error) {
+	session, err := sessions.Decode(obj.Name, obj.Spec.Data)
+	if err != nil {
+		return nil, err
+	}
+	session.Expires, _ = s.expiry(obj)
+	session.MarkStored(obj.ResourceVersion)
+	return session, nil
+}
+
+// touchSession records the new idle expiry for the session (and the absolute expiry, for sessions that predate it).
+func (s *KubeSessionStorage) touchSession(ctx context.Context, obj *api.Session, expiresAt time.Time, idleExpiresAt time.Time) error {
+	original := obj.DeepCopy()
+	obj.Spec.ExpiresAt = &metav1.Time{Time: expiresAt}
+	obj.Spec.IdleExpiresAt = &metav1.Time{Time: idleExpiresAt}
+	return s.kube.Uncached().Patch(ctx, obj, client.MergeFrom(original))
 }
 
 func (s *KubeSessionStorage) WriteSession(ctx context.Context, session *sessions.Session) error {
+	if session.ID == "" {
+		return s.createSession(ctx, session)
+	}
+
+	// If another request wrote the session since we read it, the Update will fail with a conflict;
+	// we then re-read the session, merge our changes and try again.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return s.updateSession(ctx, session)
+	})
+}
+
+func (s *KubeSessionStorage) createSession(ctx context.Context, session *sessions.Session) error {
+	sessionID := GenerateSessionID()
+	session.ID = sessionID
+
+	b, err := sessions.Encode(session)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	klog.Infof("storing new session %q", sessionID)
+	obj := api.Session{}
+	obj.Spec.Data = b
+	obj.Spec.ExpiresAt = &metav1.Time{Time: now.Add(s.options.AbsoluteTTL)}
+	obj.Spec.IdleExpiresAt = &metav1.Time{Time: now.Add(s.options.IdleTTL)}
+	obj.Namespace = s.options.Namespace
+	obj.Name = sessionID
+	if err := s.kube.Uncached().Create(ctx, &obj); err != nil {
+		return err
+	}
+	session.Expires = obj.Spec.ExpiresAt.Time
+	session.MarkStored(obj.ResourceVersion)
+	return nil
+}
+
+func (s *KubeSessionStorage) updateSession(ctx context.Context, session *sessions.Session) error {
 	sessionID := session.ID
-	create := false
-	if sessionID == "" {
-		// A new session
-		create = true
-		sessionID = GenerateSessionID()
-		session.ID = sessionID
+
+	obj := api.Session{}
+	key := types.NamespacedName{
+		Namespace: s.options.Namespace,
+		Name:      sessionID,
+	}
+	if err := s.kube.Uncached().Get(ctx, key, &obj); err != nil {
+		return err
+	}
+
+	if obj.ResourceVersion != session.StorageVersion() {
+		klog.Infof("session %q was changed concurrently; merging", sessionID)
+		latest, err := s.decode(&obj)
+		if err != nil {
+			return err
+		}
+		session.MergeFrom(latest)
 	}
 
 	b, err := sessions.Encode(session)
@@ -62,30 +228,93 @@ func (s *KubeSessionStorage) WriteSession(ctx context.Context, session *sessions
 		return err
 	}
 
+	expiresAt, _ := s.expiry(&obj)
+
 	klog.Infof("storing session %q", sessionID)
-	if create {
-		obj := api.Session{}
-		obj.Spec.Data = b
-		obj.Namespace = "sessions"
-		obj.Name = sessionID
-		if err := s.kube.Uncached().Create(ctx, &obj); err != nil {
-			return err
+	obj.Spec.Data = b
+	obj.Spec.ExpiresAt = &metav1.Time{Time: expiresAt}
+	obj.Spec.IdleExpiresAt = &metav1.Time{Time: time.Now().Add(s.options.IdleTTL)}
+	// Update fails with a conflict if the object has changed since our Get
+	if err := s.kube.Uncached().Update(ctx, &obj); err != nil {
+		return err
+	}
+	session.Expires = expiresAt
+	session.MarkStored(obj.ResourceVersion)
+	return nil
+}
+
+func (s *KubeSessionStorage) DeleteSession(ctx context.Context, sessionID string) error {
+	obj := &api.Session{}
+	obj.Namespace = s.options.Namespace
+	obj.Name = sessionID
+	klog.Infof("deleting session %q", sessionID)
+	if err := s.kube.Uncached().Delete(ctx, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
 		}
-	} else {
-		obj := api.Session{}
-		key := types.NamespacedName{
-			Namespace: "sessions",
-			Name:      sessionID,
+		return fmt.Errorf("error deleting session %q: %w", sessionID, err)
+	}
+	return nil
+}
+
+// Sweep deletes expired sessions, listing them in batches.
+func (s *KubeSessionStorage) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	scanned := 0
+	deleted := 0
+	continueToken := ""
+	for {
+		var list api.SessionList
+		listOptions := []client.ListOption{
+			client.InNamespace(s.options.Namespace),
+			client.Limit(s.options.SweepBatchSize),
 		}
-		if err := s.kube.Uncached().Get(ctx, key, &obj); err != nil {
-			return err
+		if continueToken != "" {
+			listOptions = append(listOptions, client.Continue(continueToken))
 		}
-		obj.Spec.Data = b
-		if err := s.kube.Uncached().Update(ctx, &obj); err != nil {
-			return err
+		if err := s.kube.Uncached().List(ctx, &list, listOptions...); err != nil {
+			return fmt.Errorf("error listing sessions: %w", err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			scanned++
+			if !s.isExpired(obj, now) {
+				continue
+			}
+
+			// Use preconditions so we don't delete a session that was extended since we listed it
+			preconditions := client.Preconditions{
+				UID:             &obj.UID,
+				ResourceVersion: &obj.ResourceVersion,
+			}
+			if err := s.kube.Uncached().Delete(ctx, obj, preconditions); err != nil {
+				if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+					continue
+				}
+				return fmt.Errorf("error deleting session %q: %w", obj.Name, err)
+			}
+			deleted++
+		}
+
+		continueToken = list.Continue
+		if continueToken == "" {
+			break
 		}
 	}
 
+	klog.Infof("deleted %d expired sessions (of %d)", deleted, scanned)
+	return nil
+}
+
+// AddControllers registers the sweeper, which runs on whichever replica is the controller leader.
+func (s *KubeSessionStorage) AddControllers(mgr *kubecontroller.Manager) error {
+	if s.options.SweepInterval < 0 {
+		klog.Infof("session sweeper is disabled")
+		return nil
+	}
+	mgr.AddPeriodic("session-sweeper", s.options.SweepInterval, s.Sweep)
 	return nil
 }
 
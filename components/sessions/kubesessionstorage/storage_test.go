@@ -0,0 +1,146 @@
+package kubesessionstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/sessions"
+	"github.com/justinsb/kweb/components/sessions/kubesessionstorage/api"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestStorage(t *testing.T, opt Options) *KubeSessionStorage {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := api.AllKinds.AddToScheme(scheme); err != nil {
+		t.Fatalf("error building scheme: %v", err)
+	}
+	kube := kubeclient.NewForClient(fake.NewClientBuilder().WithScheme(scheme).Build())
+	return NewKubeSessionStorage(kube, opt)
+}
+
+func TestCreateAndLookup(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, Options{})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	if err := storage.WriteSession(ctx, session); err != nil {
+		t.Fatalf("error writing session: %v", err)
+	}
+	if session.ID == "" {
+		t.Fatalf("session id was not assigned")
+	}
+
+	loaded, err := storage.LookupSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("error looking up session: %v", err)
+	}
+	if loaded == nil {
+		t.Fatalf("session %q not found", session.ID)
+	}
+	got := &wrapperspb.StringValue{}
+	if !loaded.Get(got) || got.GetValue() != "hello" {
+		t.Errorf("unexpected session value %v", got)
+	}
+	if loaded.Expires.IsZero() {
+		t.Errorf("expected session expiry to be set")
+	}
+	if loaded.StorageVersion() == "" {
+		t.Errorf("expected storage version to be set")
+	}
+
+	missing, err := storage.LookupSession(ctx, "missing")
+	if err != nil {
+		t.Fatalf("error looking up missing session: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("expected nil for missing session, got %v", missing)
+	}
+}
+
+func TestConcurrentUpdatesAreMerged(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, Options{})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("initial"))
+	if err := storage.WriteSession(ctx, session); err != nil {
+		t.Fatalf("error writing session: %v", err)
+	}
+
+	// Two requests load the same session
+	first, err := storage.LookupSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("error looking up session: %v", err)
+	}
+	second, err := storage.LookupSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("error looking up session: %v", err)
+	}
+
+	first.Set(wrapperspb.String("from first"))
+	if err := storage.WriteSession(ctx, first); err != nil {
+		t.Fatalf("error writing first: %v", err)
+	}
+
+	// second is now stale: its changes are merged onto the latest version when it is written
+	second.Set(wrapperspb.Int64(42))
+	if err := storage.WriteSession(ctx, second); err != nil {
+		t.Fatalf("error writing second: %v", err)
+	}
+
+	loaded, err := storage.LookupSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("error looking up session: %v", err)
+	}
+	s := &wrapperspb.StringValue{}
+	if !loaded.Get(s) || s.GetValue() != "from first" {
+		t.Errorf("lost the first write: %v", s)
+	}
+	n := &wrapperspb.Int64Value{}
+	if !loaded.Get(n) || n.GetValue() != 42 {
+		t.Errorf("lost the second write: %v", n)
+	}
+}
+
+func TestExpiredSessions(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t, Options{IdleTTL: time.Hour})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	if err := storage.WriteSession(ctx, session); err != nil {
+		t.Fatalf("error writing session: %v", err)
+	}
+
+	// Move the idle expiry into the past
+	obj := &api.Session{}
+	key := types.NamespacedName{Namespace: storage.options.Namespace, Name: session.ID}
+	if err := storage.kube.Uncached().Get(ctx, key, obj); err != nil {
+		t.Fatalf("error getting session object: %v", err)
+	}
+	if err := storage.touchSession(ctx, obj, obj.Spec.ExpiresAt.Time, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("error updating session object: %v", err)
+	}
+
+	loaded, err := storage.LookupSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("error looking up session: %v", err)
+	}
+	if loaded != nil {
+		t.Errorf("expected expired session not to be returned")
+	}
+
+	if err := storage.Sweep(ctx); err != nil {
+		t.Fatalf("error sweeping: %v", err)
+	}
+	if err := storage.kube.Uncached().Get(ctx, key, &api.Session{}); err == nil {
+		t.Errorf("expected expired session to be deleted by Sweep")
+	}
+}
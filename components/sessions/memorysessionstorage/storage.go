@@ -3,6 +3,7 @@ package memorysessionstorage
 import (
 	"context"
 	"encoding/base64"
+	"strconv"
 	"sync"
 
 	cryptorand "crypto/rand"
@@ -13,14 +14,19 @@ import (
 
 type MemorySessionStorage struct {
 	mutex    sync.Mutex
-	sessions map[string][]byte
+	sessions map[string]*storedSession
+}
+
+type storedSession struct {
+	data    []byte
+	version int64
 }
 
 var _ sessions.Storage = &MemorySessionStorage{}
 
 func NewMemorySessionStorage() *MemorySessionStorage {
 	return &MemorySessionStorage{
-		sessions: make(map[string][]byte),
+		sessions: make(map[string]*storedSession),
 	}
 }
 
@@ -30,14 +36,23 @@ func (s *MemorySessionStorage) LookupSession(ctx context.Context, sessionID stri
 	}
 
 	s.mutex.Lock()
-	data, found := s.sessions[sessionID]
+	stored, found := s.sessions[sessionID]
 	s.mutex.Unlock()
 
 	if !found {
 		return nil, nil
 	}
 
-	return sessions.Decode(sessionID, data)
+	return stored.decode(sessionID)
+}
+
+func (s *storedSession) decode(sessionID string) (*sessions.Session, error) {
+	session, err := sessions.Decode(sessionID, s.data)
+	if err != nil {
+		return nil, err
+	}
+	session.MarkStored(strconv.FormatInt(s.version, 10))
+	return session, nil
 }
 
 func (s *MemorySessionStorage) WriteSession(ctx context.Context, session *sessions.Session) error {
@@ -48,16 +63,39 @@ func (s *MemorySessionStorage) WriteSession(ctx context.Context, session *sessio
 		session.ID = sessionID
 	}
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := s.sessions[sessionID]
+	if stored == nil {
+		stored = &storedSession{}
+	} else if strconv.FormatInt(stored.version, 10) != session.StorageVersion() {
+		klog.Infof("session %q was changed concurrently; merging", sessionID)
+		latest, err := stored.decode(sessionID)
+		if err != nil {
+			return err
+		}
+		session.MergeFrom(latest)
+	}
+
 	b, err := sessions.Encode(session)
 	if err != nil {
 		return err
 	}
 
 	klog.Infof("storing session %q", sessionID)
+	version := stored.version + 1
+	s.sessions[sessionID] = &storedSession{data: b, version: version}
+	session.MarkStored(strconv.FormatInt(version, 10))
+
+	return nil
+}
+
+func (s *MemorySessionStorage) DeleteSession(ctx context.Context, sessionID string) error {
 	s.mutex.Lock()
-	s.sessions[sessionID] = b
-	s.mutex.Unlock()
+	defer s.mutex.Unlock()
 
+	delete(s.sessions, sessionID)
 	return nil
 }
 
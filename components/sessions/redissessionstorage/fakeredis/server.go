@@ -0,0 +1,490 @@
+package fakeredis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Server is an in-process server that speaks enough of the redis protocol for testing session storage.
+// It supports strings and hashes, key expiry, and WATCH / MULTI / EXEC transactions.
+type Server struct {
+	listener net.Listener
+
+	mutex sync.Mutex
+	data  map[string]*entry
+	// versions counts modifications of each key, so we can implement WATCH.
+	versions map[string]int64
+
+	// beforeExec is called before a transaction is executed, if set.
+	beforeExec func()
+
+	wg sync.WaitGroup
+}
+
+type entry struct {
+	str     []byte
+	hash    map[string][]byte
+	expires time.Time
+}
+
+// Start starts a server listening on a random localhost port.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("error listening: %w", err)
+	}
+	s := &Server{
+		listener: listener,
+		data:     make(map[string]*entry),
+		versions: make(map[string]int64),
+	}
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Addr returns the host:port on which the server is listening.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// SetBeforeExec sets a function that is called before each EXEC checks the watched keys.
+// Tests use it to change keys concurrently with a transaction.
+func (s *Server) SetBeforeExec(fn func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.beforeExec = fn
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(c)
+	}
+}
+
+// connState is the per-connection state for transactions.
+type connState struct {
+	watched map[string]int64
+	inMulti bool
+	queued  [][]string
+	// multiFailed is set if a queued command was invalid, so EXEC must fail.
+	multiFailed bool
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	state := &connState{}
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err != io.EOF {
+				klog.V(2).Infof("fakeredis: error reading command: %v", err)
+			}
+			return
+		}
+		reply := s.handle(state, args)
+		writeReply(w, reply)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// errorReply is written as a RESP error.
+type errorReply string
+
+// statusReply is written as a RESP simple string.
+type statusReply string
+
+// nilArrayReply is written as a null array (used for an aborted EXEC).
+type nilArrayReply struct{}
+
+func (s *Server) handle(state *connState, args []string) any {
+	if len(args) == 0 {
+		return errorReply("ERR empty command")
+	}
+	command := strings.ToUpper(args[0])
+
+	switch command {
+	case "MULTI":
+		if state.inMulti {
+			return errorReply("ERR MULTI calls can not be nested")
+		}
+		state.inMulti = true
+		state.queued = nil
+		state.multiFailed = false
+		return statusReply("OK")
+
+	case "DISCARD":
+		if !state.inMulti {
+			return errorReply("ERR DISCARD without MULTI")
+		}
+		state.inMulti = false
+		state.queued = nil
+		state.watched = nil
+		return statusReply("OK")
+
+	case "EXEC":
+		if !state.inMulti {
+			return errorReply("ERR EXEC without MULTI")
+		}
+		return s.exec(state)
+
+	case "WATCH":
+		if state.inMulti {
+			return errorReply("ERR WATCH inside MULTI is not allowed")
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if state.watched == nil {
+			state.watched = make(map[string]int64)
+		}
+		for _, key := range args[1:] {
+			s.expireIfNeeded(key)
+			state.watched[key] = s.versions[key]
+		}
+		return statusReply("OK")
+
+	case "UNWATCH":
+		state.watched = nil
+		return statusReply("OK")
+	}
+
+	if state.inMulti {
+		if !isKnownCommand(command) {
+			state.multiFailed = true
+			return errorReply(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		}
+		state.queued = append(state.queued, args)
+		return statusReply("QUEUED")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.run(args)
+}
+
+func (s *Server) exec(state *connState) any {
+	queued := state.queued
+	watched := state.watched
+	failed := state.multiFailed
+	state.inMulti = false
+	state.queued = nil
+	state.watched = nil
+	state.multiFailed = false
+
+	if failed {
+		return errorReply("EXECABORT Transaction discarded because of previous errors.")
+	}
+
+	s.mutex.Lock()
+	beforeExec := s.beforeExec
+	s.mutex.Unlock()
+	if beforeExec != nil {
+		beforeExec()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, version := range watched {
+		s.expireIfNeeded(key)
+		if s.versions[key] != version {
+			return nilArrayReply{}
+		}
+	}
+
+	replies := []any{}
+	for _, args := range queued {
+		replies = append(replies, s.run(args))
+	}
+	return replies
+}
+
+func isKnownCommand(command string) bool {
+	switch command {
+	case "PING", "AUTH", "SELECT", "GET", "SET", "DEL", "EXISTS", "HSET", "HGET", "HGETALL", "PEXPIRE", "PTTL", "FLUSHALL":
+		return true
+	}
+	return false
+}
+
+// touch records a modification of the key.
+func (s *Server) touch(key string) {
+	s.versions[key]++
+}
+
+// expireIfNeeded removes the key if it has expired; s.mutex must be held.
+func (s *Server) expireIfNeeded(key string) {
+	e := s.data[key]
+	if e != nil && !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		delete(s.data, key)
+		s.touch(key)
+	}
+}
+
+// run executes a single command; s.mutex must be held.
+func (s *Server) run(args []string) any {
+	command := strings.ToUpper(args[0])
+	if !isKnownCommand(command) {
+		return errorReply(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+	keys := args[1:]
+	if command != "DEL" && command != "EXISTS" && len(keys) > 1 {
+		keys = keys[:1]
+	}
+	for _, key := range keys {
+		s.expireIfNeeded(key)
+	}
+
+	switch command {
+	case "PING":
+		return statusReply("PONG")
+
+	case "AUTH", "SELECT":
+		return statusReply("OK")
+
+	case "FLUSHALL":
+		for key := range s.data {
+			s.touch(key)
+		}
+		s.data = make(map[string]*entry)
+		return statusReply("OK")
+
+	case "GET":
+		if len(args) != 2 {
+			return wrongArgs(command)
+		}
+		e := s.data[args[1]]
+		if e == nil {
+			return nil
+		}
+		if e.hash != nil {
+			return wrongType()
+		}
+		return e.str
+
+	case "SET":
+		if len(args) != 3 {
+			return wrongArgs(command)
+		}
+		s.data[args[1]] = &entry{str: []byte(args[2])}
+		s.touch(args[1])
+		return statusReply("OK")
+
+	case "DEL":
+		n := int64(0)
+		for _, key := range args[1:] {
+			if s.data[key] != nil {
+				delete(s.data, key)
+				s.touch(key)
+				n++
+			}
+		}
+		return n
+
+	case "EXISTS":
+		n := int64(0)
+		for _, key := range args[1:] {
+			if s.data[key] != nil {
+				n++
+			}
+		}
+		return n
+
+	case "HSET":
+		if len(args) < 4 || len(args)%2 != 0 {
+			return wrongArgs(command)
+		}
+		key := args[1]
+		e := s.data[key]
+		if e == nil {
+			e = &entry{hash: make(map[string][]byte)}
+			s.data[key] = e
+		}
+		if e.hash == nil {
+			return wrongType()
+		}
+		added := int64(0)
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, found := e.hash[args[i]]; !found {
+				added++
+			}
+			e.hash[args[i]] = []byte(args[i+1])
+		}
+		s.touch(key)
+		return added
+
+	case "HGET":
+		if len(args) != 3 {
+			return wrongArgs(command)
+		}
+		e := s.data[args[1]]
+		if e == nil {
+			return nil
+		}
+		if e.hash == nil {
+			return wrongType()
+		}
+		v, found := e.hash[args[2]]
+		if !found {
+			return nil
+		}
+		return v
+
+	case "HGETALL":
+		if len(args) != 2 {
+			return wrongArgs(command)
+		}
+		e := s.data[args[1]]
+		replies := []any{}
+		if e == nil {
+			return replies
+		}
+		if e.hash == nil {
+			return wrongType()
+		}
+		for k, v := range e.hash {
+			replies = append(replies, []byte(k), v)
+		}
+		return replies
+
+	case "PEXPIRE":
+		if len(args) != 3 {
+			return wrongArgs(command)
+		}
+		ms, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return errorReply("ERR value is not an integer or out of range")
+		}
+		e := s.data[args[1]]
+		if e == nil {
+			return int64(0)
+		}
+		if ms <= 0 {
+			delete(s.data, args[1])
+		} else {
+			e.expires = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.touch(args[1])
+		return int64(1)
+
+	case "PTTL":
+		if len(args) != 2 {
+			return wrongArgs(command)
+		}
+		e := s.data[args[1]]
+		if e == nil {
+			return int64(-2)
+		}
+		if e.expires.IsZero() {
+			return int64(-1)
+		}
+		return time.Until(e.expires).Milliseconds()
+	}
+
+	return errorReply(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+}
+
+func wrongArgs(command string) errorReply {
+	return errorReply(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(command)))
+}
+
+func wrongType() errorReply {
+	return errorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+}
+
+// readCommand reads a command, sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		// Inline command
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed command %q", line)
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("expected bulk string, got %q", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk string length %q", line)
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args = append(args, string(b[:size]))
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func writeReply(w *bufio.Writer, reply any) {
+	switch reply := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case nilArrayReply:
+		w.WriteString("*-1\r\n")
+	case statusReply:
+		fmt.Fprintf(w, "+%s\r\n", string(reply))
+	case errorReply:
+		fmt.Fprintf(w, "-%s\r\n", string(reply))
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", reply)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n", len(reply))
+		w.Write(reply)
+		w.WriteString("\r\n")
+	case []any:
+		fmt.Fprintf(w, "*%d\r\n", len(reply))
+		for _, v := range reply {
+			writeReply(w, v)
+		}
+	default:
+		klog.Warningf("fakeredis: unhandled reply type %T", reply)
+		w.WriteString("-ERR internal error\r\n")
+	}
+}
@@ -0,0 +1,226 @@
+package redissessionstorage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// We implement just enough of the redis protocol (RESP) for session storage, to avoid a dependency on a full client.
+
+// RedisError is an error reply from the server.
+type RedisError struct {
+	Message string
+}
+
+func (e *RedisError) Error() string {
+	return "redis: " + e.Message
+}
+
+// conn is a single connection to the server; commands on a connection must not be interleaved.
+type conn struct {
+	netConn net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+
+	// broken is set if the connection is in an unknown state and must not be reused.
+	broken bool
+}
+
+// do sends a command and reads the reply.
+// Replies are returned as string (simple strings), int64 (integers), []byte (bulk strings), []any (arrays) or nil.
+// Error replies are returned as a *RedisError.
+func (c *conn) do(ctx context.Context, args ...any) (any, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.netConn.SetDeadline(deadline)
+	} else {
+		c.netConn.SetDeadline(time.Time{})
+	}
+
+	if err := c.writeCommand(args); err != nil {
+		c.broken = true
+		return nil, err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		if _, ok := err.(*RedisError); !ok {
+			c.broken = true
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (c *conn) writeCommand(args []any) error {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var b []byte
+		switch arg := arg.(type) {
+		case string:
+			b = []byte(arg)
+		case []byte:
+			b = arg
+		case int:
+			b = []byte(strconv.Itoa(arg))
+		case int64:
+			b = []byte(strconv.FormatInt(arg, 10))
+		default:
+			return fmt.Errorf("unhandled argument type %T", arg)
+		}
+		fmt.Fprintf(c.w, "$%d\r\n", len(b))
+		c.w.Write(b)
+		c.w.WriteString("\r\n")
+	}
+	return c.w.Flush()
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed reply line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+func (c *conn) readReply() (any, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, fmt.Errorf("empty reply line")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+
+	case '-':
+		return nil, &RedisError{Message: line[1:]}
+
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed integer reply %q", line)
+		}
+		return n, nil
+
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed array reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]any, 0, n)
+		var firstErr error
+		for i := 0; i < n; i++ {
+			v, err := c.readReply()
+			if err != nil {
+				// Keep reading so the connection stays in sync; an EXEC can include error replies
+				if _, ok := err.(*RedisError); !ok {
+					return nil, err
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			values = append(values, v)
+		}
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return values, nil
+
+	default:
+		return nil, fmt.Errorf("unknown reply type %q", line)
+	}
+}
+
+func (c *conn) Close() error {
+	return c.netConn.Close()
+}
+
+// pool is a simple pool of connections.
+type pool struct {
+	options Options
+
+	mutex sync.Mutex
+	idle  []*conn
+}
+
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	p.mutex.Lock()
+	if n := len(p.idle); n != 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mutex.Unlock()
+		return c, nil
+	}
+	p.mutex.Unlock()
+
+	dialer := net.Dialer{Timeout: p.options.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", p.options.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to redis at %q: %w", p.options.Addr, err)
+	}
+	c := &conn{
+		netConn: netConn,
+		r:       bufio.NewReader(netConn),
+		w:       bufio.NewWriter(netConn),
+	}
+
+	if p.options.Password != "" {
+		if _, err := c.do(ctx, "AUTH", p.options.Password); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("error authenticating to redis: %w", err)
+		}
+	}
+	if p.options.DB != 0 {
+		if _, err := c.do(ctx, "SELECT", p.options.DB); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("error selecting redis database %d: %w", p.options.DB, err)
+		}
+	}
+	return c, nil
+}
+
+// put returns a connection to the pool, closing it if it is broken or the pool is full.
+func (p *pool) put(c *conn) {
+	if c.broken {
+		c.Close()
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.idle) >= p.options.MaxIdleConnections {
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
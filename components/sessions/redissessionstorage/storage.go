@@ -0,0 +1,356 @@
+package redissessionstorage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	cryptorand "crypto/rand"
+
+	"github.com/justinsb/kweb/components/sessions"
+	"k8s.io/klog/v2"
+)
+
+// Options configures the RedisSessionStorage.
+type Options struct {
+	// Addr is the host:port of the redis server.
+	Addr string
+
+	// Password is used to AUTH, if set.
+	Password string
+
+	// DB is the database number to SELECT.
+	DB int
+
+	// KeyPrefix is prepended to the session ID to form the redis key.
+	KeyPrefix string
+
+	// AbsoluteTTL is the maximum lifetime of a session, however active it is.
+	AbsoluteTTL time.Duration
+
+	// IdleTTL is how long a session remains valid without being used.
+	IdleTTL time.Duration
+
+	DialTimeout        time.Duration
+	MaxIdleConnections int
+
+	// MaxWriteAttempts bounds the retries when a session is written concurrently.
+	MaxWriteAttempts int
+}
+
+// InitDefaults sets the default options.
+func (o *Options) InitDefaults() {
+	o.Addr = "localhost:6379"
+	o.KeyPrefix = "kweb:session:"
+	o.AbsoluteTTL = 30 * 24 * time.Hour
+	o.IdleTTL = 7 * 24 * time.Hour
+	o.DialTimeout = 5 * time.Second
+	o.MaxIdleConnections = 8
+	o.MaxWriteAttempts = 5
+}
+
+// RedisSessionStorage stores each session as a redis hash, with fields for the data, the version and the absolute expiry.
+// Redis expires the key when the session is idle; writes use WATCH / MULTI so concurrent writes can be merged.
+type RedisSessionStorage struct {
+	options Options
+	pool    *pool
+}
+
+var _ sessions.Storage = &RedisSessionStorage{}
+
+const (
+	fieldData    = "data"
+	fieldVersion = "version"
+	fieldExpires = "expires"
+)
+
+func NewRedisSessionStorage(opt Options) *RedisSessionStorage {
+	var defaults Options
+	defaults.InitDefaults()
+	if opt.Addr == "" {
+		opt.Addr = defaults.Addr
+	}
+	if opt.KeyPrefix == "" {
+		opt.KeyPrefix = defaults.KeyPrefix
+	}
+	if opt.AbsoluteTTL == 0 {
+		opt.AbsoluteTTL = defaults.AbsoluteTTL
+	}
+	if opt.IdleTTL == 0 {
+		opt.IdleTTL = defaults.IdleTTL
+	}
+	if opt.DialTimeout == 0 {
+		opt.DialTimeout = defaults.DialTimeout
+	}
+	if opt.MaxIdleConnections == 0 {
+		opt.MaxIdleConnections = defaults.MaxIdleConnections
+	}
+	if opt.MaxWriteAttempts == 0 {
+		opt.MaxWriteAttempts = defaults.MaxWriteAttempts
+	}
+
+	return &RedisSessionStorage{
+		options: opt,
+		pool:    &pool{options: opt},
+	}
+}
+
+func (s *RedisSessionStorage) key(sessionID string) string {
+	return s.options.KeyPrefix + sessionID
+}
+
+// storedSession is the parsed value of the session hash.
+type storedSession struct {
+	data    []byte
+	version int64
+	expires time.Time
+}
+
+// readSession reads the session hash, returning nil if it does not exist.
+func readSession(ctx context.Context, c *conn, key string) (*storedSession, error) {
+	reply, err := c.do(ctx, "HGETALL", key)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply to HGETALL: %T", reply)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	stored := &storedSession{}
+	for i := 0; i+1 < len(values); i += 2 {
+		k, _ := values[i].([]byte)
+		v, _ := values[i+1].([]byte)
+		switch string(k) {
+		case fieldData:
+			stored.data = v
+		case fieldVersion:
+			n, err := strconv.ParseInt(string(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid session version %q", string(v))
+			}
+			stored.version = n
+		case fieldExpires:
+			n, err := strconv.ParseInt(string(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid session expiry %q", string(v))
+			}
+			stored.expires = time.UnixMilli(n)
+		}
+	}
+	return stored, nil
+}
+
+func (stored *storedSession) decode(sessionID string) (*sessions.Session, error) {
+	session, err := sessions.Decode(sessionID, stored.data)
+	if err != nil {
+		return nil, err
+	}
+	session.Expires = stored.expires
+	session.MarkStored(strconv.FormatInt(stored.version, 10))
+	return session, nil
+}
+
+// ttl returns the redis expiry for a session: the idle TTL, but never beyond the absolute expiry.
+func (s *RedisSessionStorage) ttl(expires time.Time) time.Duration {
+	ttl := s.options.IdleTTL
+	if remaining := time.Until(expires); remaining < ttl {
+		ttl = remaining
+	}
+	return ttl
+}
+
+func (s *RedisSessionStorage) LookupSession(ctx context.Context, sessionID string) (*sessions.Session, error) {
+	if sessionID == "" {
+		return nil, nil
+	}
+
+	c, err := s.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.pool.put(c)
+
+	key := s.key(sessionID)
+	stored, err := readSession(ctx, c, key)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session %q: %w", sessionID, err)
+	}
+	if stored == nil {
+		return nil, nil
+	}
+	if time.Now().After(stored.expires) {
+		klog.Infof("session %q has expired", sessionID)
+		return nil, nil
+	}
+
+	// Extend the idle expiry
+	if _, err := c.do(ctx, "PEXPIRE", key, s.ttl(stored.expires).Milliseconds()); err != nil {
+		klog.Warningf("error extending session %q: %v", sessionID, err)
+	}
+
+	return stored.decode(sessionID)
+}
+
+func (s *RedisSessionStorage) WriteSession(ctx context.Context, session *sessions.Session) error {
+	c, err := s.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.pool.put(c)
+
+	if session.ID == "" {
+		return s.createSession(ctx, c, session)
+	}
+
+	for attempt := 1; ; attempt++ {
+		done, err := s.updateSession(ctx, c, session)
+		if err != nil {
+			// Make sure the connection isn't left watching keys
+			if _, err := c.do(ctx, "UNWATCH"); err != nil {
+				c.broken = true
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+		if attempt >= s.options.MaxWriteAttempts {
+			return fmt.Errorf("session %q was changed concurrently %d times; giving up", session.ID, attempt)
+		}
+		klog.Infof("session %q was changed concurrently; retrying", session.ID)
+	}
+}
+
+func (s *RedisSessionStorage) createSession(ctx context.Context, c *conn, session *sessions.Session) error {
+	session.ID = GenerateSessionID()
+	session.Expires = time.Now().Add(s.options.AbsoluteTTL)
+
+	b, err := sessions.Encode(session)
+	if err != nil {
+		return err
+	}
+
+	key := s.key(session.ID)
+	klog.Infof("storing new session %q", session.ID)
+	if err := s.exec(ctx, c, [][]any{
+		{"HSET", key, fieldData, b, fieldVersion, int64(1), fieldExpires, session.Expires.UnixMilli()},
+		{"PEXPIRE", key, s.ttl(session.Expires).Milliseconds()},
+	}); err != nil {
+		return fmt.Errorf("error writing session %q: %w", session.ID, err)
+	}
+	session.MarkStored("1")
+	return nil
+}
+
+// updateSession tries to write the session, returning false if the session was changed concurrently.
+func (s *RedisSessionStorage) updateSession(ctx context.Context, c *conn, session *sessions.Session) (bool, error) {
+	key := s.key(session.ID)
+
+	if _, err := c.do(ctx, "WATCH", key); err != nil {
+		return false, err
+	}
+
+	stored, err := readSession(ctx, c, key)
+	if err != nil {
+		return false, fmt.Errorf("error reading session %q: %w", session.ID, err)
+	}
+
+	if stored == nil {
+		// The session was deleted (by logout or RegenerateID) or has expired; we must not recreate it
+		return false, fmt.Errorf("error writing session %q: %w", session.ID, ErrSessionNotFound)
+	}
+
+	version := stored.version
+	expires := stored.expires
+	if strconv.FormatInt(stored.version, 10) != session.StorageVersion() {
+		klog.Infof("session %q was changed concurrently; merging", session.ID)
+		latest, err := stored.decode(session.ID)
+		if err != nil {
+			return false, err
+		}
+		session.MergeFrom(latest)
+	}
+	if expires.IsZero() {
+		expires = time.Now().Add(s.options.AbsoluteTTL)
+	}
+
+	b, err := sessions.Encode(session)
+	if err != nil {
+		return false, err
+	}
+
+	newVersion := version + 1
+	err = s.exec(ctx, c, [][]any{
+		{"HSET", key, fieldData, b, fieldVersion, newVersion, fieldExpires, expires.UnixMilli()},
+		{"PEXPIRE", key, s.ttl(expires).Milliseconds()},
+	})
+	if err == errTransactionAborted {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error writing session %q: %w", session.ID, err)
+	}
+
+	session.Expires = expires
+	session.MarkStored(strconv.FormatInt(newVersion, 10))
+	return true, nil
+}
+
+// ErrSessionNotFound is returned when writing a session that no longer exists in redis.
+var ErrSessionNotFound = errors.New("session not found")
+
+// errTransactionAborted is returned by exec when a watched key was changed.
+var errTransactionAborted = errors.New("transaction aborted because a watched key changed")
+
+// exec runs the commands in a MULTI / EXEC transaction.
+func (s *RedisSessionStorage) exec(ctx context.Context, c *conn, commands [][]any) error {
+	if _, err := c.do(ctx, "MULTI"); err != nil {
+		return err
+	}
+	for _, command := range commands {
+		if _, err := c.do(ctx, command...); err != nil {
+			if _, err := c.do(ctx, "DISCARD"); err != nil {
+				c.broken = true
+			}
+			return err
+		}
+	}
+	reply, err := c.do(ctx, "EXEC")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return errTransactionAborted
+	}
+	return nil
+}
+
+func (s *RedisSessionStorage) DeleteSession(ctx context.Context, sessionID string) error {
+	c, err := s.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.pool.put(c)
+
+	klog.Infof("deleting session %q", sessionID)
+	if _, err := c.do(ctx, "DEL", s.key(sessionID)); err != nil {
+		return fmt.Errorf("error deleting session %q: %w", sessionID, err)
+	}
+	return nil
+}
+
+func GenerateSessionID() string {
+	b := make([]byte, 32, 32)
+	if _, err := cryptorand.Read(b); err != nil {
+		klog.Fatalf("error building session id: %v", err)
+	}
+	sessionID := base64.RawURLEncoding.EncodeToString(b)
+	return sessionID
+}
@@ -0,0 +1,274 @@
+package redissessionstorage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justinsb/kweb/components/sessions"
+	"github.com/justinsb/kweb/components/sessions/redissessionstorage/fakeredis"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newTestStorage(t *testing.T, opt Options) (*RedisSessionStorage, *fakeredis.Server) {
+	t.Helper()
+	s, err := fakeredis.Start()
+	if err != nil {
+		t.Fatalf("error starting fakeredis: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	opt.Addr = s.Addr()
+	return NewRedisSessionStorage(opt), s
+}
+
+func mustWrite(t *testing.T, storage *RedisSessionStorage, session *sessions.Session) {
+	t.Helper()
+	if err := storage.WriteSession(context.Background(), session); err != nil {
+		t.Fatalf("error writing session: %v", err)
+	}
+}
+
+func mustLookup(t *testing.T, storage *RedisSessionStorage, sessionID string) *sessions.Session {
+	t.Helper()
+	session, err := storage.LookupSession(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("error looking up session %q: %v", sessionID, err)
+	}
+	return session
+}
+
+func TestCreateAndLookup(t *testing.T) {
+	storage, _ := newTestStorage(t, Options{})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	mustWrite(t, storage, session)
+	if session.ID == "" {
+		t.Fatalf("session id was not assigned")
+	}
+	if session.Expires.IsZero() {
+		t.Errorf("session expiry was not set")
+	}
+
+	loaded := mustLookup(t, storage, session.ID)
+	if loaded == nil {
+		t.Fatalf("session %q not found", session.ID)
+	}
+	got := &wrapperspb.StringValue{}
+	if !loaded.Get(got) || got.GetValue() != "hello" {
+		t.Errorf("unexpected session value %v", got)
+	}
+	if got, want := loaded.StorageVersion(), "1"; got != want {
+		t.Errorf("unexpected storage version: got %q, want %q", got, want)
+	}
+	if !loaded.Expires.Equal(session.Expires.Truncate(time.Millisecond)) {
+		t.Errorf("unexpected expiry: got %v, want %v", loaded.Expires, session.Expires)
+	}
+
+	if missing := mustLookup(t, storage, "missing"); missing != nil {
+		t.Errorf("expected nil for missing session, got %v", missing)
+	}
+}
+
+func TestIdleExpiry(t *testing.T) {
+	storage, _ := newTestStorage(t, Options{IdleTTL: 500 * time.Millisecond})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	mustWrite(t, storage, session)
+
+	// Each lookup extends the idle expiry
+	for i := 0; i < 3; i++ {
+		time.Sleep(300 * time.Millisecond)
+		if mustLookup(t, storage, session.ID) == nil {
+			t.Fatalf("session expired while in use (lookup %d)", i)
+		}
+	}
+
+	time.Sleep(700 * time.Millisecond)
+	if loaded := mustLookup(t, storage, session.ID); loaded != nil {
+		t.Errorf("expected idle session to have expired")
+	}
+}
+
+func TestAbsoluteExpiry(t *testing.T) {
+	storage, _ := newTestStorage(t, Options{AbsoluteTTL: 500 * time.Millisecond})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	mustWrite(t, storage, session)
+
+	time.Sleep(300 * time.Millisecond)
+	if mustLookup(t, storage, session.ID) == nil {
+		t.Fatalf("session expired too early")
+	}
+
+	// Activity does not extend the session past the absolute expiry
+	time.Sleep(300 * time.Millisecond)
+	if loaded := mustLookup(t, storage, session.ID); loaded != nil {
+		t.Errorf("expected session to have expired")
+	}
+}
+
+func TestRegenerateID(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := newTestStorage(t, Options{})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("hello"))
+	mustWrite(t, storage, session)
+	oldID := session.ID
+
+	loaded := mustLookup(t, storage, oldID)
+	loaded.RegenerateID()
+	loaded.Set(wrapperspb.Int64(42))
+	mustWrite(t, storage, loaded)
+	if loaded.ID == "" || loaded.ID == oldID {
+		t.Fatalf("expected a new session id, got %q", loaded.ID)
+	}
+	// The session component deletes the previous session once the new one is written
+	if err := storage.DeleteSession(ctx, oldID); err != nil {
+		t.Fatalf("error deleting session: %v", err)
+	}
+
+	if old := mustLookup(t, storage, oldID); old != nil {
+		t.Errorf("expected old session %q to be deleted", oldID)
+	}
+	regenerated := mustLookup(t, storage, loaded.ID)
+	if regenerated == nil {
+		t.Fatalf("session %q not found", loaded.ID)
+	}
+	s := &wrapperspb.StringValue{}
+	if !regenerated.Get(s) || s.GetValue() != "hello" {
+		t.Errorf("value was not carried over to the new session: %v", s)
+	}
+	n := &wrapperspb.Int64Value{}
+	if !regenerated.Get(n) || n.GetValue() != 42 {
+		t.Errorf("unexpected value %v", n)
+	}
+}
+
+func TestConcurrentWriteIsRetriedAndMerged(t *testing.T) {
+	storage, server := newTestStorage(t, Options{})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("initial"))
+	mustWrite(t, storage, session)
+
+	// Two requests load the same session; the second writes first
+	first := mustLookup(t, storage, session.ID)
+	second := mustLookup(t, storage, session.ID)
+	second.Set(wrapperspb.Int64(42))
+	mustWrite(t, storage, second)
+
+	// A third request writes between the WATCH and the EXEC of the first, so the first transaction is aborted
+	var concurrentWrites atomic.Int32
+	server.SetBeforeExec(func() {
+		if !concurrentWrites.CompareAndSwap(0, 1) {
+			return
+		}
+		third := mustLookup(t, storage, session.ID)
+		third.Set(wrapperspb.Bool(true))
+		mustWrite(t, storage, third)
+	})
+
+	first.Set(wrapperspb.String("updated"))
+	mustWrite(t, storage, first)
+	if concurrentWrites.Load() != 1 {
+		t.Fatalf("concurrent write did not happen")
+	}
+
+	loaded := mustLookup(t, storage, session.ID)
+	s := &wrapperspb.StringValue{}
+	if !loaded.Get(s) || s.GetValue() != "updated" {
+		t.Errorf("unexpected string value %v", s)
+	}
+	n := &wrapperspb.Int64Value{}
+	if !loaded.Get(n) || n.GetValue() != 42 {
+		t.Errorf("unexpected int64 value %v", n)
+	}
+	b := &wrapperspb.BoolValue{}
+	if !loaded.Get(b) || !b.GetValue() {
+		t.Errorf("unexpected bool value %v", b)
+	}
+	if got, want := loaded.StorageVersion(), "4"; got != want {
+		t.Errorf("unexpected storage version: got %q, want %q", got, want)
+	}
+}
+
+func TestConcurrentWriteGivesUp(t *testing.T) {
+	storage, server := newTestStorage(t, Options{MaxWriteAttempts: 3})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("initial"))
+	mustWrite(t, storage, session)
+
+	// Every transaction of the session is interrupted by another write
+	var writing atomic.Bool
+	server.SetBeforeExec(func() {
+		if !writing.CompareAndSwap(false, true) {
+			return
+		}
+		defer writing.Store(false)
+		other := mustLookup(t, storage, session.ID)
+		other.Set(wrapperspb.Int64(time.Now().UnixNano()))
+		mustWrite(t, storage, other)
+	})
+
+	session.Set(wrapperspb.String("updated"))
+	err := storage.WriteSession(context.Background(), session)
+	if err == nil || !strings.Contains(err.Error(), "changed concurrently 3 times") {
+		t.Errorf("expected write to give up after 3 attempts, got %v", err)
+	}
+}
+
+func TestWriteDeletedSession(t *testing.T) {
+	ctx := context.Background()
+	storage, server := newTestStorage(t, Options{})
+
+	session := &sessions.Session{}
+	session.Set(wrapperspb.String("initial"))
+	mustWrite(t, storage, session)
+
+	// The session is deleted (e.g. by logout) between the lookup and the write
+	loaded := mustLookup(t, storage, session.ID)
+	if err := storage.DeleteSession(ctx, session.ID); err != nil {
+		t.Fatalf("error deleting session: %v", err)
+	}
+
+	loaded.Set(wrapperspb.String("updated"))
+	if err := storage.WriteSession(ctx, loaded); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+	if resurrected := mustLookup(t, storage, session.ID); resurrected != nil {
+		t.Errorf("deleted session was written back")
+	}
+
+	// The same applies if the session is deleted between the WATCH and the EXEC
+	session = &sessions.Session{}
+	session.Set(wrapperspb.String("initial"))
+	mustWrite(t, storage, session)
+	loaded = mustLookup(t, storage, session.ID)
+
+	var deleted atomic.Bool
+	server.SetBeforeExec(func() {
+		if !deleted.CompareAndSwap(false, true) {
+			return
+		}
+		if err := storage.DeleteSession(ctx, session.ID); err != nil {
+			t.Errorf("error deleting session: %v", err)
+		}
+	})
+
+	loaded.Set(wrapperspb.String("updated"))
+	if err := storage.WriteSession(ctx, loaded); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+	if resurrected := mustLookup(t, storage, session.ID); resurrected != nil {
+		t.Errorf("deleted session was written back")
+	}
+}
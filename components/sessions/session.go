@@ -1,22 +1,44 @@
 package sessions
 
 import (
+	"bytes"
 	"sync"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 	"k8s.io/klog/v2"
 )
 
+// Session holds the values for a browser session.
+// Methods are safe to call from multiple goroutines.
+//
+// Concurrent requests for the same session each get their own Session object;
+// storage implementations use the StorageVersion to detect concurrent writes, and MergeFrom to combine them.
 type Session struct {
 	ID string
 
+	// Expires is when the session ends, regardless of activity.
+	// It is zero if the storage does not expire sessions.
+	Expires time.Time
+
+	// Created and Updated are when the session was first and most recently written.
+	Created time.Time
+	Updated time.Time
+
 	newSession bool
 	dirty      bool
 	component  *SessionComponent
 
-	// TODO: Need to consider concurrent requests.  Should we lock the session?  A read-write lock?  Snapshot semantics?
+	// previousID is the ID the session had before RegenerateID; it is deleted once the session is written.
+	previousID string
+
 	mutex  sync.Mutex
 	values map[string]*sessionValue
+
+	// storageVersion is the version of the stored session that original reflects (opaque to us).
+	storageVersion string
+	// original is a snapshot of the values as stored, used as the base for merging.
+	original map[string]*sessionValue
 }
 
 type sessionValue struct {
@@ -24,6 +46,9 @@ type sessionValue struct {
 }
 
 func (s *Session) Clear(v proto.Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	key := string(v.ProtoReflect().Descriptor().FullName())
 	_, found := s.values[key]
 	if !found {
@@ -34,6 +59,9 @@ func (s *Session) Clear(v proto.Message) {
 }
 
 func (s *Session) Set(msg proto.Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	key := string(msg.ProtoReflect().Descriptor().FullName())
 	if s.values == nil {
 		s.values = make(map[string]*sessionValue)
@@ -49,6 +77,9 @@ func (s *Session) Set(msg proto.Message) {
 }
 
 func (s *Session) Get(dest proto.Message) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	key := string(dest.ProtoReflect().Descriptor().FullName())
 	v, found := s.values[key]
 	if !found {
@@ -60,3 +91,110 @@ func (s *Session) Get(dest proto.Message) bool {
 	}
 	return true
 }
+
+// RegenerateID moves the session to a new ID when it is written, deleting the old session.
+func (s *Session) RegenerateID() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ID != "" && !s.newSession {
+		s.previousID = s.ID
+	}
+	s.ID = ""
+	s.newSession = true
+	s.dirty = true
+
+	// The new session starts from nothing in storage, so all our values are changes
+	s.storageVersion = ""
+	s.original = nil
+}
+
+// isDirty returns true if the values have been changed since the session was loaded or written.
+func (s *Session) isDirty() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.dirty
+}
+
+// markClean records that the values have been written.
+func (s *Session) markClean() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dirty = false
+}
+
+// StorageVersion returns the version of the stored session that this session was based on.
+func (s *Session) StorageVersion() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.storageVersion
+}
+
+// MarkStored records that the current values match the stored session with the given version.
+// Storage implementations should call it after reading or writing a session.
+func (s *Session) MarkStored(storageVersion string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.storageVersion = storageVersion
+	s.original = copyValues(s.values)
+	s.dirty = false
+}
+
+// MergeFrom rebases our changes onto latest, which is a more recent version of the stored session.
+// This is a per-key three-way merge: keys that we changed (or removed) keep our value,
+// other keys take the value from latest.
+func (s *Session) MergeFrom(latest *Session) {
+	latestValues, latestVersion := latest.snapshot()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	merged := make(map[string]*sessionValue)
+	for k, v := range latestValues {
+		merged[k] = v
+	}
+	keys := make(map[string]bool)
+	for k := range s.values {
+		keys[k] = true
+	}
+	for k := range s.original {
+		keys[k] = true
+	}
+	for k := range keys {
+		ours, inOurs := s.values[k]
+		base, inBase := s.original[k]
+		if inOurs == inBase && (!inOurs || bytes.Equal(ours.Data, base.Data)) {
+			// Unchanged by us
+			continue
+		}
+		if inOurs {
+			merged[k] = ours
+		} else {
+			delete(merged, k)
+		}
+	}
+
+	s.values = merged
+	s.original = copyValues(latestValues)
+	s.storageVersion = latestVersion
+}
+
+// snapshot returns a copy of the values and the storage version.
+func (s *Session) snapshot() (map[string]*sessionValue, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return copyValues(s.values), s.storageVersion
+}
+
+func copyValues(values map[string]*sessionValue) map[string]*sessionValue {
+	c := make(map[string]*sessionValue, len(values))
+	for k, v := range values {
+		c[k] = &sessionValue{Data: v.Data}
+	}
+	return c
+}
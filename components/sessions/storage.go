@@ -2,7 +2,14 @@ package sessions
 
 import "context"
 
+// Storage persists sessions.
+//
+// The context is that of the request, so implementations can read request cookies with components.GetRequest,
+// and can emit cookies with cookies.SetCookie (see NewCookie).
 type Storage interface {
 	LookupSession(ctx context.Context, sessionID string) (*Session, error)
 	WriteSession(ctx context.Context, session *Session) error
+
+	// DeleteSession removes the stored session; it is not an error if it does not exist.
+	DeleteSession(ctx context.Context, sessionID string) error
 }
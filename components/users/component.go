@@ -21,8 +21,12 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// indexLinkedAccount indexes users by linked account, as providerID/providerUserID
+const indexLinkedAccount = "linkedAccount"
+
 type UserComponent struct {
 	kube            *kubeclient.Client
+	users           *kubeclient.Informer[*userapi.User]
 	namespaceMapper NamespaceMapper
 }
 
@@ -53,13 +57,27 @@ func (m *NamespacePerUser) NamespaceForUserID(userID string) string {
 func NewUserComponent(kube *kubeclient.Client, namespaceMapper NamespaceMapper) (*UserComponent, error) {
 	c := &UserComponent{
 		kube:            kube,
+		users:           kubeclient.CachedClient(kube, &userapi.User{}),
 		namespaceMapper: namespaceMapper,
 	}
 
+	c.users.AddIndex(indexLinkedAccount, func(user *userapi.User) []string {
+		var values []string
+		for _, linkedAccount := range user.GetSpec().GetLinkedAccounts() {
+			values = append(values, linkedAccountKey(linkedAccount.GetProviderID(), linkedAccount.GetProviderUserID()))
+		}
+		return values
+	})
+
 	return c, nil
 }
 
-var _ components.RequestFilter = &UserComponent{}
+var _ components.OrderedRequestFilter = &UserComponent{}
+
+func (c *UserComponent) FilterOrder() components.FilterOrder {
+	// We read the user from the session
+	return components.FilterOrder{Name: "users", After: []string{"sessions"}}
+}
 
 func (c *UserComponent) ProcessRequest(ctx context.Context, req *components.Request, next components.RequestFilterChain) (components.Response, error) {
 	user, err := c.userFromSession(ctx)
@@ -97,6 +115,18 @@ func SetUser(ctx context.Context, user *userapi.User) {
 	info.(*scopeInfo).currentUser = user
 
 	req := components.GetRequest(ctx)
+
+	// Move to a new session ID whenever the user changes (login or logout), to prevent session fixation
+	previous := &userapi.UserSessionInfo{}
+	req.Session.Get(previous)
+	newUserID := ""
+	if user != nil {
+		newUserID = user.Metadata.Name
+	}
+	if previous.GetUserId() != newUserID {
+		req.Session.RegenerateID()
+	}
+
 	if user != nil {
 		userID := user.Metadata.Name
 		userInfo := &userapi.UserSessionInfo{
@@ -108,8 +138,12 @@ func SetUser(ctx context.Context, user *userapi.User) {
 	}
 }
 
+// GetUser returns the logged-in user, or nil if there is no user (or the user component is not configured).
 func GetUser(ctx context.Context) *userapi.User {
-	info := ctx.Value(contextKeyUser).(*scopeInfo)
+	info, ok := ctx.Value(contextKeyUser).(*scopeInfo)
+	if !ok {
+		return nil
+	}
 	return info.currentUser
 }
 
@@ -126,8 +160,6 @@ func (c *UserComponent) MapToUser(ctx context.Context, token *oauth2.Token, info
 
 	providerID := info.Provider.ProviderID()
 
-	// TODO: We really need an index!
-	usersClient := kubeclient.TypedClient(c.kube, &userapi.User{})
 	// A bit of a hack!
 	namespace := ""
 	switch nsStrategy := c.namespaceMapper.(type) {
@@ -135,25 +167,15 @@ func (c *UserComponent) MapToUser(ctx context.Context, token *oauth2.Token, info
 		namespace = nsStrategy.namespace
 	case *NamespacePerUser:
 		namespace = ""
-		klog.Warningf("doing very inefficient all-namespace scan")
 	default:
 		return nil, fmt.Errorf("unknown namespace strategy %T", nsStrategy)
 	}
-	allUsers, err := usersClient.List(ctx, namespace)
+	users, err := c.users.ByIndex(ctx, indexLinkedAccount, linkedAccountKey(providerID, info.ProviderUserID))
 	if err != nil {
 		return nil, err
 	}
-	for _, user := range allUsers {
-		match := false
-		for _, linkedAccount := range user.GetSpec().GetLinkedAccounts() {
-			if linkedAccount.GetProviderID() != providerID {
-				continue
-			}
-			if linkedAccount.GetProviderUserID() == info.ProviderUserID {
-				match = true
-			}
-		}
-		if match {
+	for _, user := range users {
+		if namespace == "" || user.GetMetadata().GetNamespace() == namespace {
 			return user, nil
 		}
 	}
@@ -185,10 +207,15 @@ func (c *UserComponent) MapToUser(ctx context.Context, token *oauth2.Token, info
 	if err := c.kube.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	c.users.Observe(user)
 
 	return user, nil
 }
 
+func linkedAccountKey(providerID string, providerUserID string) string {
+	return providerID + "/" + providerUserID
+}
+
 func generateUserID() string {
 	b := make([]byte, 16, 16)
 	if _, err := cryptorand.Read(b); err != nil {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/justinsb/kweb/components"
 	userapi "github.com/justinsb/kweb/components/users/pb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 func (c *UserComponent) userFromSession(ctx context.Context) (*userapi.User, error) {
@@ -27,9 +28,14 @@ func (c *UserComponent) LoadUser(ctx context.Context, userID string) (*userapi.U
 	if userID == "" {
 		return nil, nil
 	}
-	user := &userapi.User{}
 	key := c.buildUserKey(userID)
-	if err := c.kube.Get(ctx, key, user); err != nil {
+	user, err := c.users.Get(ctx, key)
+	if apierrors.IsNotFound(err) {
+		// The user may have been created by another server, and not yet reached our cache
+		user = &userapi.User{}
+		err = c.kube.Get(ctx, key, user)
+	}
+	if err != nil {
 		// apierrors.IsNotFound would be unexpected here; the userid is set in the session
 		return nil, fmt.Errorf("error fetching user %v: %w", key, err)
 	}
@@ -38,5 +44,9 @@ func (c *UserComponent) LoadUser(ctx context.Context, userID string) (*userapi.U
 }
 
 func Logout(ctx context.Context) {
+	if ctx.Value(contextKeyUser) == nil {
+		// The user component is not configured, so nobody can be logged in
+		return
+	}
 	SetUser(ctx, nil)
 }
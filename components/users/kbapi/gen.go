@@ -1,6 +1,7 @@
 package api
 
-//go:generate go run sigs.k8s.io/controller-tools/cmd/controller-gen@v0.8.0 object crd:crdVersions=v1 output:crd:artifacts:config=config/ paths=./...
+// The CRDs are generated from the protos (in pb/config), so we only generate the deepcopy functions here.
+//go:generate go run sigs.k8s.io/controller-tools/cmd/controller-gen@v0.8.0 object paths=./...
 
 //+kubebuilder:object:generate=true
 //+groupName=kweb.dev
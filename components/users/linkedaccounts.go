@@ -0,0 +1,69 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/justinsb/kweb/components"
+	"github.com/justinsb/kweb/components/kube"
+	userapi "github.com/justinsb/kweb/components/users/pb"
+	"google.golang.org/protobuf/proto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// GetComponent returns the user component of the server.
+func GetComponent(ctx context.Context) *UserComponent {
+	var component *UserComponent
+	components.GetComponent(ctx, &component)
+	return component
+}
+
+// LinkedAccounts returns the accounts of the user with the given provider.
+func LinkedAccounts(user *userapi.User, providerID string) []*userapi.LinkedAccount {
+	var accounts []*userapi.LinkedAccount
+	for _, account := range user.GetSpec().GetLinkedAccounts() {
+		if account.GetProviderID() == providerID {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// LinkAccount adds the account to the user's linked accounts, returning the updated user.
+// It returns a Conflict error (check with apierrors.IsConflict) if the account is linked to another user.
+func (c *UserComponent) LinkAccount(ctx context.Context, user *userapi.User, account *userapi.LinkedAccount) (*userapi.User, error) {
+	userKey := types.NamespacedName{Namespace: user.GetMetadata().GetNamespace(), Name: user.GetMetadata().GetName()}
+
+	linked, err := c.users.ByIndex(ctx, indexLinkedAccount, linkedAccountKey(account.GetProviderID(), account.GetProviderUserID()))
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range linked {
+		if other.GetMetadata().GetNamespace() != userKey.Namespace || other.GetMetadata().GetName() != userKey.Name {
+			return nil, apierrors.NewConflict(kube.GetKindInfo(user).GroupResource(), userKey.Name, fmt.Errorf("account %s is linked to another user", linkedAccountKey(account.GetProviderID(), account.GetProviderUserID())))
+		}
+	}
+
+	updated := &userapi.User{}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.kube.Get(ctx, userKey, updated); err != nil {
+			return err
+		}
+		for _, existing := range updated.GetSpec().GetLinkedAccounts() {
+			if existing.GetProviderID() == account.GetProviderID() && existing.GetProviderUserID() == account.GetProviderUserID() {
+				return nil
+			}
+		}
+		if updated.Spec == nil {
+			updated.Spec = &userapi.UserSpec{}
+		}
+		updated.Spec.LinkedAccounts = append(updated.Spec.LinkedAccounts, proto.Clone(account).(*userapi.LinkedAccount))
+		return c.kube.Update(ctx, updated)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error linking account to user %v: %w", userKey, err)
+	}
+	return updated, nil
+}
@@ -27,15 +27,19 @@ type Options struct {
 }
 
 func NewApp(opt *Options) (*App, error) {
-	a := &App{options: *opt}
-
 	rand.Seed(time.Now().UnixNano())
 	klog.InitFlags(nil)
 
 	flag.StringVar(&opt.Server.Listen, "listen", opt.Server.Listen, "endpoint on which to start http server")
+	profile := string(opt.Server.Profile)
+	flag.StringVar(&profile, "profile", profile, "backends to use: kubernetes, or local to run without a cluster")
 
 	flag.Parse()
 
+	opt.Server.Profile = server.Profile(profile)
+
+	a := &App{options: *opt}
+
 	s, err := server.New(a.options.Server)
 	if err != nil {
 		return nil, err
@@ -54,6 +58,21 @@ func (o *Options) InitDefaults(appName string) {
 	o.Server.InitDefaults(appName)
 }
 
+// UseLocalProfile configures the app to run without kubernetes, using in-memory backends.
+func (o *Options) UseLocalProfile() {
+	o.Server.Profile = server.ProfileLocal
+}
+
+// AddComponent adds a component, which is registered after the built-in components.
+func (o *Options) AddComponent(component components.Component) {
+	o.Server.Components = append(o.Server.Components, component)
+}
+
+// AddLoginProvider adds a login provider, which will be built when the app is created.
+func (o *Options) AddLoginProvider(factory server.LoginProviderFactory) {
+	o.Server.LoginProviders = append(o.Server.LoginProviders, factory)
+}
+
 func (a *App) RunFromMain() {
 	err := a.Run(context.Background())
 	if err != nil {
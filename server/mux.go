@@ -15,6 +15,10 @@ func (s *Server) ensureMux() error {
 		return nil
 	}
 
+	if err := s.Server.BuildFilterChain(); err != nil {
+		return fmt.Errorf("error building request filter chain: %w", err)
+	}
+
 	mux := http.NewServeMux()
 	for _, component := range s.Components {
 		if err := component.RegisterHandlers(&s.Server, mux); err != nil {
@@ -22,6 +26,11 @@ func (s *Server) ensureMux() error {
 		}
 	}
 
+	// Routes registered with the shared router are served for any path not otherwise registered.
+	if router := s.Server.Routes(); router.HasRoutes() {
+		mux.HandleFunc("/", s.Server.ServeHTTP(router.Serve))
+	}
+
 	s.mux = mux
 	return nil
 }
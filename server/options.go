@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/justinsb/kweb/components"
+)
+
+// Profile selects the default backends for the server.
+type Profile string
+
+const (
+	// ProfileKubernetes stores users, sessions and other state in kubernetes.
+	ProfileKubernetes Profile = "kubernetes"
+
+	// ProfileLocal runs without a kubernetes cluster, using in-memory backends.
+	// Unless a KubeClient is provided, kubernetes objects are stored in an in-memory apiserver.
+	ProfileLocal Profile = "local"
+)
+
+// ParseProfile parses the name of a profile.
+func ParseProfile(s string) (Profile, error) {
+	switch Profile(s) {
+	case ProfileKubernetes, ProfileLocal:
+		return Profile(s), nil
+	case "":
+		return ProfileKubernetes, nil
+	default:
+		return "", fmt.Errorf("unknown profile %q (expected %q or %q)", s, ProfileKubernetes, ProfileLocal)
+	}
+}
+
+// SessionBackend selects where sessions are stored.
+type SessionBackend string
+
+const (
+	// SessionBackendKubernetes stores sessions as Session objects in kubernetes.
+	SessionBackendKubernetes SessionBackend = "kubernetes"
+
+	// SessionBackendMemory stores sessions in memory; they are lost on restart and are not shared between replicas.
+	SessionBackendMemory SessionBackend = "memory"
+
+	// SessionBackendCookie stores sessions in encrypted cookies, and needs the key store.
+	// Cookie sessions cannot be revoked: logging out does not invalidate a copy of the cookies,
+	// which remains usable until it expires (see cookiesessionstorage.Options.IdleTTL).
+	SessionBackendCookie SessionBackend = "cookie"
+)
+
+// BuiltinComponents selects which of the built-in components the server includes.
+// Components that other components rely on must be included too; New returns an error if they are not.
+type BuiltinComponents struct {
+	// ControllerManager runs background tasks, such as removing expired sessions, on the leader replica.
+	ControllerManager bool
+
+	CSRF  bool
+	Pages bool
+
+	// Users stores users in kubernetes; it is needed for Login and the GitHub App integration.
+	Users bool
+
+	// OAuthSessions stores the oauth tokens of logged-in users in kubernetes.
+	OAuthSessions bool
+
+	// Login serves the login and logout endpoints, for the login providers.
+	Login bool
+}
+
+// InitDefaults includes all the built-in components.
+func (o *BuiltinComponents) InitDefaults() {
+	o.ControllerManager = true
+	o.CSRF = true
+	o.Pages = true
+	o.Users = true
+	o.OAuthSessions = true
+	o.Login = true
+}
+
+// validate checks that the components we depend on are included.
+func (o *BuiltinComponents) validate() error {
+	if o.Login && !o.Users {
+		return fmt.Errorf("the login component requires the users component")
+	}
+	return nil
+}
+
+// OAuth2Options configures login with an OAuth2 provider.
+type OAuth2Options struct {
+	// Provider is the login provider: google or github.
+	Provider string
+
+	ClientID     string
+	ClientSecret string
+}
+
+// InitFromEnv reads the options from the OAUTH2_PROVIDER, OAUTH2_CLIENT_ID and OAUTH2_CLIENT_SECRET environment variables.
+func (o *OAuth2Options) InitFromEnv() {
+	o.Provider = os.Getenv("OAUTH2_PROVIDER")
+	o.ClientID = os.Getenv("OAUTH2_CLIENT_ID")
+	o.ClientSecret = os.Getenv("OAUTH2_CLIENT_SECRET")
+}
+
+// GitHubAppOptions configures the GitHub App integration.
+type GitHubAppOptions struct {
+	// AppID is the ID of the GitHub App; the integration is disabled if it is empty.
+	AppID string
+
+	// PrivateKeyPath is the path to the PEM-encoded private key of the app.
+	PrivateKeyPath string
+
+	// WebhookSecret is the secret configured for the app's webhook; webhooks are rejected if it is empty.
+	WebhookSecret string
+
+	// Slug is the URL-friendly name of the app, used to send users to GitHub to install it.
+	Slug string
+
+	// ClientID and ClientSecret are the OAuth credentials of the app, used to verify who installed the app.
+	ClientID     string
+	ClientSecret string
+}
+
+// InitFromEnv reads the options from the GITHUB_APP_ID, GITHUB_APP_KEY, GITHUB_APP_WEBHOOK_SECRET, GITHUB_APP_SLUG,
+// GITHUB_APP_CLIENT_ID and GITHUB_APP_CLIENT_SECRET environment variables.
+func (o *GitHubAppOptions) InitFromEnv() {
+	o.AppID = os.Getenv("GITHUB_APP_ID")
+	o.PrivateKeyPath = os.Getenv("GITHUB_APP_KEY")
+	o.WebhookSecret = os.Getenv("GITHUB_APP_WEBHOOK_SECRET")
+	o.Slug = os.Getenv("GITHUB_APP_SLUG")
+	o.ClientID = os.Getenv("GITHUB_APP_CLIENT_ID")
+	o.ClientSecret = os.Getenv("GITHUB_APP_CLIENT_SECRET")
+}
+
+// KeyStoreOptions configures the kubernetes secret that holds the keys for encrypting data at rest, such as oauth tokens.
+//
+// The key store is not enabled by default, because it needs permission to get, list, watch, create and update
+// secrets in the namespace, e.g. with a Role like:
+//
+//	rules:
+//	- apiGroups: [""]
+//	  resources: ["secrets"]
+//	  verbs: ["get", "list", "watch", "create", "update"]
+type KeyStoreOptions struct {
+	Namespace string
+
+	// SecretName is the name of the secret holding the keys; the key store is enabled if it is set (e.g. to <appName>-keys).
+	SecretName string
+}
+
+// InitDefaults stores the keys in the app's namespace; SecretName must be set to enable the key store.
+func (o *KeyStoreOptions) InitDefaults(appName string) {
+	o.Namespace = appName
+}
+
+// LoginProviderFactory builds a login provider, that maps logins to users with userMapper.
+type LoginProviderFactory func(userMapper components.UserMapper) (components.AuthenticationProvider, error)
@@ -16,21 +16,29 @@ import (
 
 	"github.com/justinsb/kweb/components"
 	"github.com/justinsb/kweb/components/cookies"
+	"github.com/justinsb/kweb/components/csrf"
 	"github.com/justinsb/kweb/components/github"
 	"github.com/justinsb/kweb/components/healthcheck"
+	"github.com/justinsb/kweb/components/keystore"
 	"github.com/justinsb/kweb/components/kube/kubeclient"
+	"github.com/justinsb/kweb/components/kube/kubeclient/fakekube"
+	"github.com/justinsb/kweb/components/kube/kubecontroller"
 	"github.com/justinsb/kweb/components/login"
+	"github.com/justinsb/kweb/components/metrics"
 	"github.com/justinsb/kweb/components/oauthsessions"
 	"github.com/justinsb/kweb/components/pages"
+	"github.com/justinsb/kweb/components/sessions/cookiesessionstorage"
 	"github.com/justinsb/kweb/components/sessions/kubesessionstorage"
 
 	// "github.com/justinsb/kweb/components/login/providers"
 	"github.com/justinsb/kweb/components/login/providers/loginwithgithub"
 	"github.com/justinsb/kweb/components/login/providers/loginwithgoogle"
 	"github.com/justinsb/kweb/components/sessions"
+	"github.com/justinsb/kweb/components/sessions/memorysessionstorage"
 	"github.com/justinsb/kweb/components/users"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
@@ -45,8 +53,52 @@ type Options struct {
 	Listen                string
 	UserNamespaceStrategy users.NamespaceMapper
 	Pages                 pages.Options
+	CSRF                  csrf.Options
+	Sessions              kubesessionstorage.Options
 	Scheme                *runtime.Scheme
 
+	// Profile selects the default backends; the default is ProfileKubernetes.
+	Profile Profile
+
+	// KubeClient is the kubernetes client to use; if nil one is built from the environment,
+	// or connected to an in-memory apiserver with ProfileLocal.
+	KubeClient *kubeclient.Client
+
+	// SessionStorage overrides the storage for sessions; by default it is chosen by SessionBackend.
+	SessionStorage sessions.Storage
+
+	// SessionBackend selects where sessions are stored; by default they are stored in kubernetes (configured by Sessions),
+	// or in memory with ProfileLocal.
+	// SessionBackendCookie (configured by CookieSessions) needs the key store, and cannot revoke sessions on logout.
+	SessionBackend SessionBackend
+
+	// CookieSessions configures SessionBackendCookie; the KeyStore is set from the server's key store.
+	CookieSessions cookiesessionstorage.Options
+
+	// OAuth2 configures the built-in login provider; no provider is registered if ClientID is empty.
+	OAuth2 OAuth2Options
+
+	// LoginProviders build additional login providers.
+	LoginProviders []LoginProviderFactory
+
+	// GitHubApp configures the GitHub App integration, which is disabled if AppID is empty.
+	GitHubApp GitHubAppOptions
+
+	// KeyStore configures the keys for encrypting data at rest; data is not encrypted if SecretName is empty.
+	KeyStore KeyStoreOptions
+
+	// Controllers configures the background controllers, which run on the replica that holds the leader election lease.
+	Controllers kubecontroller.ManagerOptions
+
+	// EnableMetrics serves prometheus metrics (such as the GitHub rate limits) on /metrics.
+	EnableMetrics bool
+
+	// Builtins selects the built-in components; InitDefaults includes all of them.
+	Builtins BuiltinComponents
+
+	// Components are added to the server after the built-in components.
+	Components []components.Component
+
 	TLSConfig *tls.Config
 	UseSPIFFE bool
 }
@@ -55,107 +107,278 @@ func (o *Options) InitDefaults(appName string) {
 	o.Listen = ":8443"
 	o.UserNamespaceStrategy = users.NewSingleNamespaceMapper(appName)
 	o.Pages.InitDefaults(appName)
+	o.Sessions.InitDefaults()
+	o.CookieSessions.InitDefaults()
+	o.OAuth2.InitFromEnv()
+	o.GitHubApp.InitFromEnv()
+	o.KeyStore.InitDefaults(appName)
+	o.Controllers.InitDefaults(appName)
+	o.Builtins.InitDefaults()
+	o.Profile = Profile(os.Getenv("KWEB_PROFILE"))
 }
 
 func New(opt Options) (*Server, error) {
-	restConfig, err := GetRESTConfig()
+	profile, err := ParseProfile(string(opt.Profile))
 	if err != nil {
-		return nil, fmt.Errorf("error getting kubernetes configuration: %w", err)
+		return nil, err
+	}
+	builtins := opt.Builtins
+	if err := builtins.validate(); err != nil {
+		return nil, err
 	}
 
 	s := &Server{}
 
-	kubeClient, err := kubeclient.New(restConfig, opt.Scheme)
-	if err != nil {
-		return nil, fmt.Errorf("error building kubernetes controller client: %w", err)
+	scheme := opt.Scheme
+	if scheme == nil {
+		scheme = runtime.NewScheme()
+	}
+
+	kubeClient := opt.KubeClient
+	if kubeClient == nil {
+		switch profile {
+		case ProfileKubernetes:
+			restConfig, err := GetRESTConfig()
+			if err != nil {
+				return nil, fmt.Errorf("error getting kubernetes configuration: %w", err)
+			}
+
+			kubeClient, err = kubeclient.New(restConfig, scheme)
+			if err != nil {
+				return nil, fmt.Errorf("error building kubernetes controller client: %w", err)
+			}
+
+		case ProfileLocal:
+			// Objects are stored in an in-memory apiserver, and are lost on restart
+			fakeKube, err := fakekube.Start()
+			if err != nil {
+				return nil, fmt.Errorf("error starting in-memory kubernetes: %w", err)
+			}
+			kubeClient, err = fakeKube.NewClient(scheme)
+			if err != nil {
+				return nil, fmt.Errorf("error building kubernetes controller client: %w", err)
+			}
+		}
 	}
 	s.Components = append(s.Components, &kubeclient.Component{Client: kubeClient})
 
+	var controllerManager *kubecontroller.Manager
+	if builtins.ControllerManager {
+		controllerManager = kubecontroller.NewManager(kubeClient, opt.Controllers)
+		s.Components = append(s.Components, controllerManager)
+	}
+
 	healthcheckComponent := healthcheck.NewHealthcheckComponent()
 	s.Components = append(s.Components, healthcheckComponent)
 
+	if opt.EnableMetrics {
+		s.Components = append(s.Components, metrics.NewMetricsComponent())
+	}
+
 	cookiesComponent := cookies.NewCookiesComponent()
 	s.Components = append(s.Components, cookiesComponent)
 
-	// sessionStorage := memorystorage.NewMemorySessionStorage()
-	sessionStorage := kubesessionstorage.NewKubeSessionStorage(kubeClient)
+	var keyStore *keystore.KubernetesKeyStore
+	if opt.KeyStore.SecretName != "" {
+		keyStore, err = buildKeyStore(kubeClient, opt.KeyStore)
+		if err != nil {
+			return nil, err
+		}
+		s.Components = append(s.Components, &keystore.Component{KeyStore: keyStore})
+	}
+
+	sessionStorage := opt.SessionStorage
+	if sessionStorage == nil {
+		sessionBackend := opt.SessionBackend
+		if sessionBackend == "" {
+			switch profile {
+			case ProfileKubernetes:
+				sessionBackend = SessionBackendKubernetes
+			case ProfileLocal:
+				sessionBackend = SessionBackendMemory
+			}
+		}
+
+		switch sessionBackend {
+		case SessionBackendKubernetes:
+			kubeSessionStorage := kubesessionstorage.NewKubeSessionStorage(kubeClient, opt.Sessions)
+			if controllerManager != nil {
+				if err := kubeSessionStorage.AddControllers(controllerManager); err != nil {
+					return nil, fmt.Errorf("error adding session controllers: %w", err)
+				}
+			} else {
+				klog.Warningf("controller manager is not enabled; expired sessions will not be removed")
+			}
+			sessionStorage = kubeSessionStorage
+		case SessionBackendMemory:
+			sessionStorage = memorysessionstorage.NewMemorySessionStorage()
+		case SessionBackendCookie:
+			if keyStore == nil {
+				return nil, fmt.Errorf("the cookie session backend requires the key store (set KeyStore.SecretName)")
+			}
+			cookieSessionOptions := opt.CookieSessions
+			cookieSessionOptions.KeyStore = keyStore
+			sessionStorage, err = cookiesessionstorage.NewCookieSessionStorage(cookieSessionOptions)
+			if err != nil {
+				return nil, fmt.Errorf("error building cookie session storage: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown session backend %q", sessionBackend)
+		}
+	}
 	sessionComponent := sessions.NewSessionComponent(sessionStorage)
 	s.Components = append(s.Components, sessionComponent)
 
-	pagesComponent := pages.New(opt.Pages)
-	s.Components = append(s.Components, pagesComponent)
+	if builtins.CSRF {
+		csrfComponent := csrf.New(opt.CSRF)
+		s.Components = append(s.Components, csrfComponent)
+	}
 
-	userComponent, err := users.NewUserComponent(kubeClient, opt.UserNamespaceStrategy)
-	if err != nil {
-		return nil, fmt.Errorf("error building user component: %w", err)
+	if builtins.Pages {
+		pagesComponent := pages.New(opt.Pages)
+		s.Components = append(s.Components, pagesComponent)
 	}
-	s.Components = append(s.Components, userComponent)
 
-	oauthsessions, err := oauthsessions.NewOAuthSessionsComponent(kubeClient)
-	if err != nil {
-		return nil, fmt.Errorf("error building oauth sessions component: %w", err)
+	// Users and oauth sessions are stored in kubernetes
+	var userComponent *users.UserComponent
+	if builtins.Users {
+		userComponent, err = users.NewUserComponent(kubeClient, opt.UserNamespaceStrategy)
+		if err != nil {
+			return nil, fmt.Errorf("error building user component: %w", err)
+		}
+		s.Components = append(s.Components, userComponent)
 	}
-	s.Components = append(s.Components, oauthsessions)
 
-	githubAppID := os.Getenv("GITHUB_APP_ID")
-	if githubAppID != "" {
-		// TODO: Get from kube secret or file?
-		if os.Getenv("GITHUB_APP_KEY") == "" {
-			return nil, fmt.Errorf("expected GITHUB_APP_KEY to be set")
+	var oauthSessionsComponent *oauthsessions.OAuthSessionsComponent
+	if builtins.OAuthSessions {
+		var oauthSessionsOptions oauthsessions.Options
+		oauthSessionsOptions.InitDefaults()
+		if keyStore != nil {
+			oauthSessionsOptions.KeyStore = keyStore
 		}
-		rsaPrivateKey, err := parsePrivateKey(os.Getenv("GITHUB_APP_KEY"))
+
+		oauthSessionsComponent, err = oauthsessions.NewOAuthSessionsComponent(kubeClient, oauthSessionsOptions)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("error building oauth sessions component: %w", err)
 		}
-		githubApp, err := github.New(kubeClient, githubAppID, rsaPrivateKey)
+		s.Components = append(s.Components, oauthSessionsComponent)
+		if controllerManager != nil {
+			if err := oauthSessionsComponent.AddControllers(controllerManager); err != nil {
+				return nil, fmt.Errorf("error adding oauth session controllers: %w", err)
+			}
+		} else {
+			klog.Warningf("controller manager is not enabled; oauth sessions will not be cleaned up")
+		}
+	}
+
+	if opt.GitHubApp.AppID != "" {
+		if userComponent == nil || controllerManager == nil {
+			return nil, fmt.Errorf("the github app integration requires the users component and the controller manager")
+		}
+		githubApp, err := buildGitHubApp(kubeClient, opt.GitHubApp)
 		if err != nil {
-			return nil, fmt.Errorf("error building github component: %w", err)
+			return nil, err
 		}
 		s.Components = append(s.Components, githubApp)
-
-		// TODO: Cron-type tasks
-		if err := githubApp.SyncInstallations(context.Background()); err != nil {
-			klog.Warningf("error syncing github installations: %v", err)
+		if err := githubApp.AddControllers(controllerManager); err != nil {
+			return nil, fmt.Errorf("error adding github controllers: %w", err)
 		}
 	}
 
-	loginComponent, err := login.NewComponent()
-	if err != nil {
-		return nil, err
+	var loginProviders []LoginProviderFactory
+	if opt.OAuth2.ClientID != "" {
+		loginProviders = append(loginProviders, opt.OAuth2.providerFactory)
 	}
-	s.Components = append(s.Components, loginComponent)
+	loginProviders = append(loginProviders, opt.LoginProviders...)
 
-	clientID := os.Getenv("OAUTH2_CLIENT_ID")
-	if clientID != "" {
-		clientSecret := os.Getenv("OAUTH2_CLIENT_SECRET")
-		authProvider := os.Getenv("OAUTH2_PROVIDER")
+	if builtins.Login {
+		loginComponent, err := login.NewComponent()
+		if err != nil {
+			return nil, err
+		}
+		s.Components = append(s.Components, loginComponent)
 
-		switch authProvider {
-		case "google":
-			googleProvider, err := loginwithgoogle.NewGoogleProvider("google", clientID, clientSecret, userComponent)
+		for _, factory := range loginProviders {
+			provider, err := factory(userComponent)
 			if err != nil {
-				return nil, fmt.Errorf("error building google provider: %w", err)
+				return nil, err
 			}
-			loginComponent.RegisterProvider(googleProvider)
-
-		case "github":
-			githubAuth, err := loginwithgithub.NewGithubProvider(clientID, clientSecret, userComponent)
-			if err != nil {
-				return nil, fmt.Errorf("error building github auth provider: %w", err)
+			loginComponent.RegisterProvider(provider)
+			if tokenProvider, ok := provider.(components.TokenProvider); ok && oauthSessionsComponent != nil {
+				oauthSessionsComponent.RegisterProvider(tokenProvider)
 			}
-			loginComponent.RegisterProvider(githubAuth)
-
-		case "":
-			return nil, fmt.Errorf("OAUTH2_PROVIDER must be set to one of google / github")
-
-		default:
-			return nil, fmt.Errorf("OAUTH2_PROVIDER %q not known", authProvider)
 		}
+	} else if len(loginProviders) != 0 {
+		return nil, fmt.Errorf("login providers are configured, but the login component is not enabled")
 	}
 
+	s.Components = append(s.Components, opt.Components...)
+
 	return s, nil
 }
 
+// buildKeyStore builds the key store, backed by a kubernetes secret.
+func buildKeyStore(kubeClient *kubeclient.Client, opt KeyStoreOptions) (*keystore.KubernetesKeyStore, error) {
+	clientset, err := kubernetes.NewForConfig(kubeClient.RESTConfig())
+	if err != nil {
+		return nil, fmt.Errorf("error building kubernetes client: %w", err)
+	}
+	keyStore, err := keystore.NewKubernetesKeyStore(clientset, opt.Namespace, opt.SecretName)
+	if err != nil {
+		return nil, fmt.Errorf("error building key store: %w", err)
+	}
+	return keyStore, nil
+}
+
+// buildGitHubApp builds the GitHub App component.
+func buildGitHubApp(kubeClient *kubeclient.Client, opt GitHubAppOptions) (*github.Component, error) {
+	// TODO: Get from kube secret or file?
+	if opt.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("expected GITHUB_APP_KEY to be set")
+	}
+	rsaPrivateKey, err := parsePrivateKey(opt.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	githubApp, err := github.New(kubeClient, github.Options{
+		AppID:         opt.AppID,
+		PrivateKey:    rsaPrivateKey,
+		AppSlug:       opt.Slug,
+		ClientID:      opt.ClientID,
+		ClientSecret:  opt.ClientSecret,
+		WebhookSecret: opt.WebhookSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error building github component: %w", err)
+	}
+	return githubApp, nil
+}
+
+// providerFactory builds the login provider configured by the options.
+func (o OAuth2Options) providerFactory(userMapper components.UserMapper) (components.AuthenticationProvider, error) {
+	switch o.Provider {
+	case "google":
+		googleProvider, err := loginwithgoogle.NewGoogleProvider("google", o.ClientID, o.ClientSecret, userMapper)
+		if err != nil {
+			return nil, fmt.Errorf("error building google provider: %w", err)
+		}
+		return googleProvider, nil
+
+	case "github":
+		githubAuth, err := loginwithgithub.NewGithubProvider(o.ClientID, o.ClientSecret, userMapper)
+		if err != nil {
+			return nil, fmt.Errorf("error building github auth provider: %w", err)
+		}
+		return githubAuth, nil
+
+	case "":
+		return nil, fmt.Errorf("OAUTH2_PROVIDER must be set to one of google / github")
+
+	default:
+		return nil, fmt.Errorf("OAUTH2_PROVIDER %q not known", o.Provider)
+	}
+}
+
 func (s *Server) ListenAndServe(ctx context.Context, listen string, tlsConfig *tls.Config, listening chan<- net.Addr) error {
 	defer func() {
 		if listening != nil {
@@ -167,6 +390,13 @@ func (s *Server) ListenAndServe(ctx context.Context, listen string, tlsConfig *t
 		return err
 	}
 
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := s.startComponents(ctxWithCancel); err != nil {
+		return err
+	}
+
 	klog.Infof("starting server on %q", listen)
 
 	httpServer := &http.Server{
@@ -178,9 +408,6 @@ func (s *Server) ListenAndServe(ctx context.Context, listen string, tlsConfig *t
 	}
 	httpServer.TLSConfig = tlsConfig
 
-	ctxWithCancel, cancel := context.WithCancel(ctx)
-	defer cancel()
-
 	go func() {
 		<-ctxWithCancel.Done()
 		shutdownContext, cancel := context.WithTimeout(context.Background(), time.Second*30)
@@ -224,6 +451,20 @@ func (s *Server) ListenAndServe(ctx context.Context, listen string, tlsConfig *t
 	return nil
 }
 
+// startComponents starts the background tasks of any components that are Runnable.
+func (s *Server) startComponents(ctx context.Context) error {
+	for _, component := range s.Components {
+		runnable, ok := component.(components.Runnable)
+		if !ok {
+			continue
+		}
+		if err := runnable.Start(ctx); err != nil {
+			return fmt.Errorf("error starting component %T: %w", component, err)
+		}
+	}
+	return nil
+}
+
 func parsePrivateKey(p string) (*rsa.PrivateKey, error) {
 	b, err := os.ReadFile(p)
 	if err != nil {
@@ -55,27 +55,32 @@ func (l *MustacheExpression) DebugString() string {
 }
 
 func (l *MustacheExpression) Eval(ctx context.Context, scope *scopes.Scope) (string, error) {
+	v, ok, err := l.EvalValue(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", nil
+	}
+	return fmt.Sprintf("%v", v), nil
+}
+
+// EvalValue evaluates the expression without converting the value to a string; ok is false if the value is not set.
+func (l *MustacheExpression) EvalValue(ctx context.Context, scope *scopes.Scope) (any, bool, error) {
 	// TODO: Pre-parse
 	p := fieldpath.Parser{}
 	p.Init(l.Expression)
 
 	exprTree, err := p.ParseExpression()
 	if err != nil {
-		return "", fmt.Errorf("error during parsing of %q: %w", l.Expression, err)
+		return nil, false, fmt.Errorf("error during parsing of %q: %w", l.Expression, err)
 	}
 	if err := p.Complete(); err != nil {
-		return "", fmt.Errorf("error parsing expression %q: %w", l.Expression, err)
+		return nil, false, fmt.Errorf("error parsing expression %q: %w", l.Expression, err)
 	}
 
 	klog.Infof("parsed expression %q => %q", l.Expression, exprTree.String())
-	v, ok, err := exprTree.Eval(ctx, scope)
-	if err != nil {
-		return "", err
-	}
-	if !ok {
-		return "", nil
-	}
-	return fmt.Sprintf("%v", v), nil
+	return exprTree.Eval(ctx, scope)
 }
 
 type Expression interface {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html/template"
 	"reflect"
 	"strings"
 
@@ -25,20 +26,46 @@ type Render struct {
 func (r *Render) renderTextNode(node *html.Node) error {
 	text := node.Data
 
-	if strings.Contains(text, "{{") {
-		el, err := mustache.ParseExpressionList(text)
+	if !strings.Contains(text, "{{") {
+		return escape(r.w, text)
+	}
+
+	el, err := mustache.ParseExpressionList(text)
+	if err != nil {
+		return err
+	}
+	klog.Infof("found mustache: %v", el.DebugString())
+	for _, e := range el.Expressions {
+		if mustacheExpression, ok := e.(*mustache.MustacheExpression); ok {
+			v, found, err := mustacheExpression.EvalValue(r.ctx, r.data)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+			// Values of type template.HTML are trusted markup (such as a CSRF form field), and are not escaped
+			if markup, ok := v.(template.HTML); ok {
+				if _, err := r.w.WriteString(string(markup)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := escape(r.w, fmt.Sprintf("%v", v)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		expanded, err := e.Eval(r.ctx, r.data)
 		if err != nil {
 			return err
 		}
-		expanded, err := el.Eval(r.ctx, r.data)
-		if err != nil {
+		if err := escape(r.w, expanded); err != nil {
 			return err
 		}
-		text = expanded
-		klog.Infof("found mustache: %v => %q", el.DebugString(), text)
 	}
-
-	return escape(r.w, text)
+	return nil
 }
 
 func (r *Render) renderAttributeValue(attr *html.Attribute) error {